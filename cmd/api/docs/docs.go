@@ -15,6 +15,144 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/admins": {
+            "get": {
+                "description": "Lists the admin accounts. Needs the admins permission.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Account Management"
+                ],
+                "summary": "List admins",
+                "operationId": "listAdmins",
+                "responses": {
+                    "200": {
+                        "description": "admins: []entity.AdminAccount",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Failed to list admins",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates an admin of a role without a password. Only super admins invite super admins. The setup token in the response is handed to the new admin, who sets their password with it within 72 hours.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Account Management"
+                ],
+                "summary": "Invite an admin",
+                "operationId": "inviteAdmin",
+                "parameters": [
+                    {
+                        "description": "Admin details",
+                        "name": "admin",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.AdminInvite"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "id: int, setuptoken: string",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Failed to invite admin",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/admins/{id}/deactivate": {
+            "patch": {
+                "description": "Stops an admin from logging in. Only super admins deactivate super admins, and the last active super admin cannot be deactivated.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Account Management"
+                ],
+                "summary": "Deactivate an admin",
+                "operationId": "deactivateAdmin",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Admin ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: admin deactivated",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Failed to deactivate admin",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/admins/{id}/reset": {
+            "post": {
+                "description": "Clears an admin's password and reactivates them. Only super admins reset super admins. The setup token in the response is handed to the admin, who sets a new password with it within 72 hours.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Account Management"
+                ],
+                "summary": "Reset an admin's password",
+                "operationId": "resetAdmin",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Admin ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "setuptoken: string",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Failed to reset admin",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/admin/categories": {
             "get": {
                 "description": "Retrieve a list of all categories",
@@ -172,7 +310,7 @@ const docTemplate = `{
         },
         "/admin/category/offer": {
             "post": {
-                "description": "Add an offer to a category based on the provided category ID and offer",
+                "description": "Put a percentage offer on every product of a category from now until it is cancelled",
                 "consumes": [
                     "multipart/form-data"
                 ],
@@ -373,7 +511,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "message\": \"Admin logged in successfully",
+                        "description": "message\": \"Admin logged in successfully\", \"tokens\": middleware.Tokens",
                         "schema": {
                             "type": "string"
                         }
@@ -389,7 +527,7 @@ const docTemplate = `{
         },
         "/admin/logout": {
             "post": {
-                "description": "Deletes the authentication token cookie to log the admin out",
+                "description": "Revokes the admin's tokens and deletes their cookies to log the admin out",
                 "produces": [
                     "application/json"
                 ],
@@ -413,6 +551,157 @@ const docTemplate = `{
                 }
             }
         },
+        "/admin/logout/all": {
+            "post": {
+                "description": "Ends every session of the admin, on every device.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Log the admin out of all devices",
+                "responses": {
+                    "200": {
+                        "description": "message: logged out of all devices",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error: logout failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/offer": {
+            "get": {
+                "description": "List offers, newest first, optionally filtered by status (scheduled, active, expired, cancelled)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Offer Management"
+                ],
+                "summary": "List offers",
+                "operationId": "allOffer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Offer status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination (default is 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items per page (default is 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Offer"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "error: Failed to retrieve offers",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Schedule a percentage or flat offer on a product, or on every product of a category, from valid_from (default now) until valid_until (default until cancelled)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Offer Management"
+                ],
+                "summary": "Schedule an offer",
+                "operationId": "addOffer",
+                "parameters": [
+                    {
+                        "description": "Offer details",
+                        "name": "offer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Offer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "offer: entity.Offer",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Failed to add offer",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/offer/{id}": {
+            "delete": {
+                "description": "End a scheduled or running offer now; its products go back to their price without it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Offer Management"
+                ],
+                "summary": "Cancel an offer",
+                "operationId": "cancelOffer",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Offer ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "offer: entity.Offer",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Failed to cancel offer",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/admin/order/cancel/{orderid}": {
             "patch": {
                 "description": "Cancels an order based on the provided order ID (for admin use).",
@@ -431,6 +720,12 @@ const docTemplate = `{
                         "name": "orderid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Refund prepaid orders to 'original' payment or 'wallet' (default original for razorpay and stripe orders, wallet otherwise)",
+                        "name": "refund",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
@@ -511,10 +806,16 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "New status for the order",
+                        "description": "New status (confirmed, packed, shipped, out_for_delivery, delivered, cancelled, returned, refunded)",
                         "name": "status",
                         "in": "formData",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Note recorded in the order status history",
+                        "name": "remark",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
@@ -535,7 +836,7 @@ const docTemplate = `{
         },
         "/admin/product/offer": {
             "post": {
-                "description": "Add an offer to a product based on the provided product ID and offer",
+                "description": "Put a percentage offer on a product from now until it is cancelled",
                 "consumes": [
                     "multipart/form-data"
                 ],
@@ -749,8 +1050,8 @@ const docTemplate = `{
             }
         },
         "/admin/products/{id}": {
-            "put": {
-                "description": "Edit an existing product based on the provided JSON data",
+            "delete": {
+                "description": "Delete an existing product based on the provided ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -758,10 +1059,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
+                    "Admin Product Management",
                     "Admin Product Management"
                 ],
-                "summary": "Edit a product",
-                "operationId": "editProduct",
+                "summary": "Delete a product",
                 "parameters": [
                     {
                         "type": "integer",
@@ -770,34 +1071,25 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Product object to be edited",
-                        "name": "category",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/entity.Product"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product edit success\" \"product\":entity.product",
+                        "description": "Product deleted",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Product edit failed",
+                        "description": "Product not found",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
             },
-            "delete": {
-                "description": "Delete an existing product based on the provided ID",
+            "patch": {
+                "description": "Edit an existing product based on the provided JSON data",
                 "consumes": [
                     "application/json"
                 ],
@@ -805,10 +1097,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Admin Product Management",
                     "Admin Product Management"
                 ],
-                "summary": "Delete a product",
+                "summary": "Edit a product",
+                "operationId": "editProduct",
                 "parameters": [
                     {
                         "type": "integer",
@@ -817,17 +1109,26 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Product object to be edited",
+                        "name": "category",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.Product"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product deleted",
+                        "description": "Product edit success\" \"product\":entity.product",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Product not found",
+                        "description": "Product edit failed",
                         "schema": {
                             "type": "string"
                         }
@@ -835,38 +1136,45 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/salesreport/date/{start}/{end}": {
+        "/admin/refunds": {
             "get": {
-                "description": "Generates a sales report based on the provided start and end dates.",
+                "description": "Retrieves refunds, optionally filtered by status (pending, processed, failed).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin Report"
+                    "Admin Orders"
                 ],
-                "summary": "Generate sales report by date range",
-                "operationId": "sales-report-by-date",
+                "summary": "List refunds (Admin)",
+                "operationId": "admin-get-refunds",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Start date for the report (format: 2-1-2006)",
-                        "name": "start",
-                        "in": "path",
-                        "required": true
+                        "description": "Refund status",
+                        "name": "status",
+                        "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "End date for the report (format: 2-1-2006)",
-                        "name": "end",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Page number for pagination (default is 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items per page (default is 5)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Sales report generated successfully",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Refund"
+                            }
                         }
                     },
                     "400": {
@@ -878,45 +1186,31 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/salesreport/payment/{start}/{end}/{paymentmethod}": {
-            "get": {
-                "description": "Generates a sales report based on the provided start and end dates and payment method.",
+        "/admin/refunds/{id}/retry": {
+            "post": {
+                "description": "Sends a refund that the payment gateway rejected once more.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin Report"
+                    "Admin Orders"
                 ],
-                "summary": "Generate sales report by payment method and date range",
-                "operationId": "sales-report-by-payment",
+                "summary": "Retry a failed refund (Admin)",
+                "operationId": "admin-retry-refund",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Start date for the report (format: 2-1-2006)",
-                        "name": "start",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "End date for the report (format: 2-1-2006)",
-                        "name": "end",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Payment method for the report",
-                        "name": "paymentmethod",
+                        "type": "integer",
+                        "description": "Refund ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Sales report generated successfully",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/entity.Refund"
                         }
                     },
                     "400": {
@@ -928,31 +1222,45 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/salesreport/period/{period}": {
+        "/admin/returns": {
             "get": {
-                "description": "Generates a sales report based on the provided period.",
+                "description": "Retrieves return requests, optionally filtered by status (requested, approved, rejected, refunded).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin Report"
+                    "Admin Orders"
                 ],
-                "summary": "Generate sales report by period",
-                "operationId": "sales-report-by-period",
+                "summary": "List return requests (Admin)",
+                "operationId": "admin-get-returns",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Period for the report (e.g., 'monthly', 'quarterly', 'yearly')",
-                        "name": "period",
-                        "in": "path",
-                        "required": true
+                        "description": "Return status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination (default is 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items per page (default is 5)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Sales report generated successfully",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.ReturnRequest"
+                            }
                         }
                     },
                     "400": {
@@ -964,47 +1272,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/search/users": {
-            "get": {
-                "description": "Retrieve a list of users based on search criteria, paginated with optional limit",
+        "/admin/returns/{id}/approve": {
+            "patch": {
+                "description": "Approves a requested return so the customer can send the items back.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin User Management"
+                    "Admin Orders"
                 ],
-                "summary": "Search users based on criteria",
-                "operationId": "searchUsers",
+                "summary": "Approve a return request (Admin)",
+                "operationId": "admin-approve-return",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Page number for pagination (default: 1)",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Limit the number of users per page (default: 5)",
-                        "name": "limit",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "Return request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Search criteria to filter users",
-                        "name": "search",
-                        "in": "query",
-                        "required": true
+                        "description": "Note for the customer",
+                        "name": "remark",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "userlist: []entity.User",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/entity.ReturnRequest"
                         }
                     },
                     "400": {
-                        "description": "error: Failed to retrieve userlist",
+                        "description": "Bad request",
                         "schema": {
                             "type": "string"
                         }
@@ -1012,26 +1314,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/stockless/products": {
-            "get": {
-                "description": "Retrieve a list of products with zero stock",
+        "/admin/returns/{id}/receive": {
+            "patch": {
+                "description": "Marks the items of an approved return as received, puts them back in stock and refunds the customer.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin Product Management"
+                    "Admin Orders"
+                ],
+                "summary": "Receive returned items (Admin)",
+                "operationId": "admin-receive-return",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Return request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get a list of stockless products",
-                "operationId": "stocklessProducts",
                 "responses": {
                     "200": {
-                        "description": "List of stockless products: []entity.Product",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/entity.ReturnRequest"
                         }
                     },
                     "400": {
-                        "description": "error: Failed to retrieve stockless products",
+                        "description": "Bad request",
                         "schema": {
                             "type": "string"
                         }
@@ -1039,90 +1350,68 @@ const docTemplate = `{
                 }
             }
         },
-        "/admin/users": {
-            "get": {
-                "description": "Get a paginated list of users.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/returns/{id}/reject": {
+            "patch": {
+                "description": "Rejects a requested return.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin User Management"
+                    "Admin Orders"
                 ],
-                "summary": "List Users",
-                "operationId": "list-users",
+                "summary": "Reject a return request (Admin)",
+                "operationId": "admin-reject-return",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Page number (default is 1)",
-                        "name": "page",
-                        "in": "query"
+                        "description": "Return request ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Number of users per page (default is 5)",
-                        "name": "limit",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Reason for the rejection",
+                        "name": "remark",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/entity.ListUsersResponse"
+                            "$ref": "#/definitions/entity.ReturnRequest"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Bad request",
                         "schema": {
-                            "$ref": "#/definitions/entity.ErrorResponse"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/admin/users/toggle-permission/{id}": {
-            "put": {
-                "description": "Toggle the permission of a user by providing the user's ID.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/admin/roles": {
+            "get": {
+                "description": "Returns the permission matrix, role by role, and every permission a role can be granted. Super admins hold every permission.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Admin User Management"
-                ],
-                "summary": "Toggle User Permission",
-                "operationId": "toggle-user-permission",
-                "parameters": [
-                    {
-                        "minimum": 1,
-                        "type": "integer",
-                        "format": "int32",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Admin Account Management"
                 ],
+                "summary": "List roles and their permissions",
+                "operationId": "listRoles",
                 "responses": {
                     "200": {
-                        "description": "success: User permission toggled successfully",
+                        "description": "roles: map[string][]string, permissions: []string",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "error: Invalid user ID",
-                        "schema": {
-                            "type": "string"
-                        }
-                    },
-                    "401": {
-                        "description": "error: User not found",
+                        "description": "error: Failed to list roles",
                         "schema": {
                             "type": "string"
                         }
@@ -1130,80 +1419,691 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/address": {
-            "post": {
-                "description": "Adds a new address associated with the authenticated user",
+        "/admin/roles/{role}": {
+            "put": {
+                "description": "Gives a role exactly the listed permissions, creating the role if it is new. The super admin role cannot be changed.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User Address"
+                    "Admin Account Management"
                 ],
-                "summary": "Adds a new address for the user",
+                "summary": "Set a role's permissions",
+                "operationId": "setRolePermissions",
                 "parameters": [
                     {
-                        "description": "Address information to be added",
-                        "name": "address",
+                        "type": "string",
+                        "description": "Role",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Permissions of the role",
+                        "name": "permissions",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/entity.UserAddress"
+                            "$ref": "#/definitions/entity.RolePermissions"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "address added successfully",
+                        "description": "message: role updated",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "error: Failed to update role",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            }
-        },
-        "/user/address/{type}": {
+            },
             "delete": {
-                "description": "Deletes a specific type of address for the authenticated user.",
+                "description": "Removes a role that no admin holds any more.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User Address"
+                    "Admin Account Management"
                 ],
-                "summary": "Delete user address",
-                "operationId": "delete-user-address",
+                "summary": "Delete a role",
+                "operationId": "deleteRole",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Type of address to be deleted (e.g., 'home', 'work')",
-                        "name": "type",
+                        "description": "Role",
+                        "name": "role",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "success\": \"address Deleted successfully\" \"Successful response",
+                        "description": "message: role deleted",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "error\": \"Error message\" \"Error response",
+                        "description": "error: Failed to delete role",
                         "schema": {
                             "type": "string"
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Edit the user's address of a specific type (e.g., home, work)",
+            }
+        },
+        "/admin/salesreport/date/{start}/{end}": {
+            "get": {
+                "description": "Generates a sales report based on the provided start and end dates.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Report"
+                ],
+                "summary": "Generate sales report by date range",
+                "operationId": "sales-report-by-date",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date for the report (format: 2-1-2006)",
+                        "name": "start",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date for the report (format: 2-1-2006)",
+                        "name": "end",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sales report generated successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/salesreport/payment/{start}/{end}/{paymentmethod}": {
+            "get": {
+                "description": "Generates a sales report based on the provided start and end dates and payment method.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Report"
+                ],
+                "summary": "Generate sales report by payment method and date range",
+                "operationId": "sales-report-by-payment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start date for the report (format: 2-1-2006)",
+                        "name": "start",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "End date for the report (format: 2-1-2006)",
+                        "name": "end",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payment method for the report",
+                        "name": "paymentmethod",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sales report generated successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/salesreport/period/{period}": {
+            "get": {
+                "description": "Generates a sales report based on the provided period.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Report"
+                ],
+                "summary": "Generate sales report by period",
+                "operationId": "sales-report-by-period",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Period for the report (e.g., 'monthly', 'quarterly', 'yearly')",
+                        "name": "period",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sales report generated successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/search/users": {
+            "get": {
+                "description": "Retrieve a list of users based on search criteria, paginated with optional limit",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin User Management"
+                ],
+                "summary": "Search users based on criteria",
+                "operationId": "searchUsers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Page number for pagination (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Limit the number of users per page (default: 5)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search criteria to filter users",
+                        "name": "search",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "userlist: []entity.User",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Failed to retrieve userlist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/setup-password": {
+            "post": {
+                "description": "Sets the password of an invited or reset admin with the setup token they were given. The token can only be used once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Account Management"
+                ],
+                "summary": "Set an admin's password",
+                "operationId": "setupAdminPassword",
+                "parameters": [
+                    {
+                        "description": "Setup token and new password",
+                        "name": "setup",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.AdminPasswordSetup"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "message: password set",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Failed to set password",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/stockless/products": {
+            "get": {
+                "description": "Retrieve a list of products with zero stock",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Product Management"
+                ],
+                "summary": "Get a list of stockless products",
+                "operationId": "stocklessProducts",
+                "responses": {
+                    "200": {
+                        "description": "List of stockless products: []entity.Product",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Failed to retrieve stockless products",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/token/refresh": {
+            "post": {
+                "description": "Exchanges a refresh token for a new access token and a new refresh token. Bearer clients send it as refresh_token in the body and get the new tokens back; browsers use the Refresh cookie and the X-CSRF-Token header. A refresh token can only be used once; using it again ends the session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Renew the admin's login",
+                "responses": {
+                    "200": {
+                        "description": "message: token refreshed, tokens: middleware.Tokens",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "error: invalid token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "error: missing or invalid csrf token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users": {
+            "get": {
+                "description": "Get a paginated list of users.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin User Management"
+                ],
+                "summary": "List Users",
+                "operationId": "list-users",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number (default is 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of users per page (default is 5)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.ListUsersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/entity.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/users/toggle-permission/{id}": {
+            "put": {
+                "description": "Toggle the permission of a user by providing the user's ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin User Management"
+                ],
+                "summary": "Toggle User Permission",
+                "operationId": "toggle-user-permission",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "format": "int32",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "success: User permission toggled successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid user ID",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "error: User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/wallet/reconcile": {
+            "post": {
+                "description": "Finds users whose stored wallet balance differs from their ledger and corrects them. Balances that predate the ledger are recorded as opening balances.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Wallet"
+                ],
+                "summary": "Reconcile wallets against the ledger (Admin)",
+                "operationId": "admin-reconcile-wallets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.WalletDiscrepancy"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/wallet/{userid}": {
+            "get": {
+                "description": "Retrieves the wallet balance and the ledger entries of a user, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Wallet"
+                ],
+                "summary": "Wallet balance and history of a user (Admin)",
+                "operationId": "admin-get-wallet",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination (default is 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items per page (default is 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.WalletTransaction"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/wallet/{userid}/adjust": {
+            "post": {
+                "description": "Credits a positive amount to or debits a negative amount from a user's wallet.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin Wallet"
+                ],
+                "summary": "Adjust a user's wallet (Admin)",
+                "operationId": "admin-adjust-wallet",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Amount in rupees to credit (positive) or debit (negative)",
+                        "name": "amount",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reason for the adjustment",
+                        "name": "remark",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/entity.WalletTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/address": {
+            "get": {
+                "description": "Lists every address in the authenticated user's address book, the default one first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Address"
+                ],
+                "summary": "List the user's addresses",
+                "responses": {
+                    "200": {
+                        "description": "addresses",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.UserAddress"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Adds a new address associated with the authenticated user. The first address, or one sent with isdefault, becomes the default.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Address"
+                ],
+                "summary": "Adds a new address for the user",
+                "parameters": [
+                    {
+                        "description": "Address information to be added",
+                        "name": "address",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/entity.UserAddress"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "address added successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/address/{id}": {
+            "delete": {
+                "description": "Deletes one of the authenticated user's addresses. If it was the default, the oldest remaining address becomes the default.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Address"
+                ],
+                "summary": "Delete user address",
+                "operationId": "delete-user-address",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of the address to be deleted",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "success\": \"address Deleted successfully\" \"Successful response",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error\": \"Error message\" \"Error response",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Edit one of the user's addresses. Orders already placed keep the address they were placed with.",
                 "produces": [
                     "application/json"
                 ],
@@ -1213,9 +2113,9 @@ const docTemplate = `{
                 "summary": "Edit the user's address",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Address type (e.g., home, work)",
-                        "name": "type",
+                        "type": "integer",
+                        "description": "Address ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
@@ -1231,13 +2131,48 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "success: address edited successfully",
+                        "description": "success: address edited successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/address/{id}/default": {
+            "patch": {
+                "description": "Makes the address the user's default delivery address",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Address"
+                ],
+                "summary": "Set the default address",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Address ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "default address updated",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "error: Bad Request",
+                        "description": "Bad Request",
                         "schema": {
                             "type": "string"
                         }
@@ -1247,7 +2182,7 @@ const docTemplate = `{
         },
         "/user/cart": {
             "get": {
-                "description": "Retrieve the user's cart based on the provided user ID",
+                "description": "Retrieve the user's cart repriced against the current products, offers and stock, with its GST for delivery to the given address, or to the default address. Lines whose price changed or that cannot be sold as they are carry a status; review is set until they are acknowledged. Guests get their guest cart priced as a sale within the seller's state.",
                 "produces": [
                     "application/json"
                 ],
@@ -1256,9 +2191,17 @@ const docTemplate = `{
                 ],
                 "summary": "Get the user's cart",
                 "operationId": "getCart",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Delivery address ID",
+                        "name": "addressid",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "usercart: entity.Cart",
+                        "description": "usercart: entity.Cart, items: []entity.CartItem, review: bool, coupons: coupon.Pricing, tax: tax.Bill",
                         "schema": {
                             "type": "string"
                         }
@@ -1272,7 +2215,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Add a product to the user's cart based on the provided product ID and quantity",
+                "description": "Add a product to the user's cart based on the provided product ID and quantity. Shoppers who are not logged in get a guest cart, kept by a signed guest token and merged into their cart when they log in or sign up.",
                 "consumes": [
                     "multipart/form-data"
                 ],
@@ -1322,9 +2265,36 @@ const docTemplate = `{
                 }
             }
         },
+        "/user/cart/acknowledge": {
+            "post": {
+                "description": "Accepts the changes flagged on the user's cart: new prices are taken, unavailable lines removed and lines short of stock cut down to what is left.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Products"
+                ],
+                "summary": "Acknowledge cart changes",
+                "operationId": "acknowledgeCart",
+                "responses": {
+                    "200": {
+                        "description": "usercart: entity.Cart, items: []entity.CartItem, coupons: coupon.Pricing",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Failed to acknowledge the cart",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/user/cart/coupon": {
             "post": {
-                "description": "Applies a coupon to the authenticated user's cart based on the provided coupon code.",
+                "description": "Applies a coupon to the authenticated user's cart based on the provided coupon code. Stackable coupons can be combined.",
                 "consumes": [
                     "multipart/form-data"
                 ],
@@ -1347,7 +2317,41 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Total offer prize and Coupon applied successfully",
+                        "description": "offer prize: total discount, coupons: coupon.Pricing",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Takes a coupon applied to the authenticated user's cart off it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Coupon"
+                ],
+                "summary": "Remove coupon from user's cart",
+                "operationId": "remove-coupon",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Coupon code to be removed",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "offer prize: total discount, coupons: coupon.Pricing",
                         "schema": {
                             "type": "string"
                         }
@@ -1594,13 +2598,223 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "message: User logged in successfully and cookie stored",
+                        "description": "message: User logged in successfully and cookie stored, tokens: middleware.Tokens",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "error: Invalid phone number or password",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/logout": {
+            "post": {
+                "description": "Revokes the user's tokens and deletes their cookies to log the user out",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Logs out the user",
+                "responses": {
+                    "200": {
+                        "description": "user logged out successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "cookie delete failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/logout/all": {
+            "post": {
+                "description": "Ends every session of the user, on every device.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Log the user out of all devices",
+                "responses": {
+                    "200": {
+                        "description": "message: logged out of all devices",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error: logout failed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/order/cancel/{orderid}": {
+            "patch": {
+                "description": "Cancels an order based on the provided order ID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Orders"
+                ],
+                "summary": "Cancel an order",
+                "operationId": "cancel-order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID to be canceled",
+                        "name": "orderid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Refund prepaid orders to 'original' payment or 'wallet' (default original for razorpay and stripe orders, wallet otherwise)",
+                        "name": "refund",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order canceled successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/order/history": {
+            "get": {
+                "description": "Retrieves the order history for the authenticated user based on pagination parameters.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Orders"
+                ],
+                "summary": "Retrieve order history for the authenticated user",
+                "operationId": "get-order-history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination (default is 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items per page (default is 5)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Order history retrieved successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/order/invoice": {
+            "get": {
+                "description": "Downloads the GST invoice of one of the user's orders. The invoice is numbered and stored when the sale completes, so every download is the same document.",
+                "produces": [
+                    "application/pdf"
+                ],
+                "tags": [
+                    "User Orders"
+                ],
+                "summary": "Download the tax invoice of an order",
+                "operationId": "print-invoice",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID for which the invoice should be generated",
+                        "name": "orderid",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Invoice PDF",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
                         "schema": {
                             "type": "string"
                         }
+                    }
+                }
+            }
+        },
+        "/user/order/items/{orderid}": {
+            "get": {
+                "description": "Retrieves the items of one of the authenticated user's orders. The item id is used to request a return.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Orders"
+                ],
+                "summary": "List the items of an order",
+                "operationId": "get-order-items",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Order ID",
+                        "name": "orderid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.OrderItem"
+                            }
+                        }
                     },
                     "400": {
-                        "description": "error: Invalid phone number or password",
+                        "description": "Bad request",
                         "schema": {
                             "type": "string"
                         }
@@ -1608,25 +2822,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/logout": {
+        "/user/order/place/{addressid}/{payment}": {
             "post": {
-                "description": "Deletes the authentication token cookie to log the user out",
+                "description": "Places an order for the authenticated user based on the selected payment method.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "User"
+                    "User Orders"
+                ],
+                "summary": "Place an order",
+                "operationId": "place-order",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID of one of the user's addresses to deliver to",
+                        "name": "addressid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Payment method ('cod', 'razorpay', 'wallet')",
+                        "name": "payment",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "For razorpay, pay what the wallet covers and the rest through razorpay",
+                        "name": "usewallet",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Logs out the user",
                 "responses": {
                     "200": {
-                        "description": "user logged out successfully",
+                        "description": "Invoice details\" \"Successful response for Wallet payment",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "400": {
-                        "description": "cookie delete failed",
+                        "description": "Bad request",
                         "schema": {
                             "type": "string"
                         }
@@ -1634,31 +2874,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/order/cancel/{orderid}": {
-            "patch": {
-                "description": "Cancels an order based on the provided order ID.",
+        "/user/order/refunds": {
+            "get": {
+                "description": "Retrieves the refunds of the authenticated user's cancellations and returns, newest first.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "User Orders"
                 ],
-                "summary": "Cancel an order",
-                "operationId": "cancel-order",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Order ID to be canceled",
-                        "name": "orderid",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
+                "summary": "List refunds",
+                "operationId": "get-user-refunds",
                 "responses": {
                     "200": {
-                        "description": "Order canceled successfully",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.Refund"
+                            }
                         }
                     },
                     "400": {
@@ -1670,36 +2904,57 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/order/history": {
-            "get": {
-                "description": "Retrieves the order history for the authenticated user based on pagination parameters.",
+        "/user/order/return/{orderitemid}": {
+            "post": {
+                "description": "Opens a return for some or all units of a delivered order item, within 7 days of delivery.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "User Orders"
                 ],
-                "summary": "Retrieve order history for the authenticated user",
-                "operationId": "get-order-history",
+                "summary": "Request a return for an order item",
+                "operationId": "request-return",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Page number for pagination (default is 1)",
-                        "name": "page",
-                        "in": "query"
+                        "description": "Order item ID",
+                        "name": "orderitemid",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "Number of items per page (default is 5)",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "Number of units to return",
+                        "name": "quantity",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Reason code (damaged, wrong_item, not_as_described, size_issue, quality_issue, no_longer_needed)",
+                        "name": "reason",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Details about the return",
+                        "name": "comment",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Refund to 'wallet' or 'original' payment (default original for razorpay and stripe orders, wallet otherwise)",
+                        "name": "refund",
+                        "in": "formData"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Order history retrieved successfully",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/entity.ReturnRequest"
                         }
                     },
                     "400": {
@@ -1711,31 +2966,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/order/invoice": {
+        "/user/order/returns": {
             "get": {
-                "description": "Generates and downloads the invoice for a specific order.",
+                "description": "Retrieves the return requests of the authenticated user, newest first.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "User Orders"
                 ],
-                "summary": "Print invoice for an order",
-                "operationId": "print-invoice",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Order ID for which the invoice should be generated",
-                        "name": "orderid",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
+                "summary": "List return requests",
+                "operationId": "get-user-returns",
                 "responses": {
                     "200": {
-                        "description": "Invoice generated and downloaded successfully",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.ReturnRequest"
+                            }
                         }
                     },
                     "400": {
@@ -1747,41 +2996,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/order/place/{addressid}/{payment}": {
-            "post": {
-                "description": "Places an order for the authenticated user based on the selected payment method.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/user/order/timeline/{orderid}": {
+            "get": {
+                "description": "Retrieves every status change of one of the authenticated user's orders, oldest first.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "User Orders"
                 ],
-                "summary": "Place an order",
-                "operationId": "place-order",
+                "summary": "Get the status timeline of an order",
+                "operationId": "get-order-timeline",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Address ID for the order",
-                        "name": "addressid",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Payment method ('cod', 'razorpay', 'wallet')",
-                        "name": "payment",
+                        "description": "Order ID",
+                        "name": "orderid",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Invoice details\" \"Successful response for Wallet payment",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.OrderStatusHistory"
+                            }
                         }
                     },
                     "400": {
@@ -1972,14 +3214,14 @@ const docTemplate = `{
                 "operationId": "sort-products-by-filter",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Minimum prize for product filtering",
+                        "type": "number",
+                        "description": "Minimum prize in rupees for product filtering",
                         "name": "minprize",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Maximum prize for product filtering",
+                        "type": "number",
+                        "description": "Maximum prize in rupees for product filtering",
                         "name": "maxprize",
                         "in": "query"
                     },
@@ -2250,6 +3492,85 @@ const docTemplate = `{
                 }
             }
         },
+        "/user/token/refresh": {
+            "post": {
+                "description": "Exchanges a refresh token for a new access token and a new refresh token. Bearer clients send it as refresh_token in the body and get the new tokens back; browsers use the Refresh cookie and the X-CSRF-Token header. A refresh token can only be used once; using it again ends the session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Renew the user's login",
+                "responses": {
+                    "200": {
+                        "description": "message: token refreshed, tokens: middleware.Tokens",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "error: invalid token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "error: missing or invalid csrf token",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/wallet": {
+            "get": {
+                "description": "Retrieves the wallet balance and the ledger entries of the authenticated user, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Wallet"
+                ],
+                "summary": "Wallet balance and history",
+                "operationId": "get-wallet",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number for pagination (default is 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items per page (default is 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/entity.WalletTransaction"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/user/wishlist": {
             "get": {
                 "description": "Retrieves and returns the products in the user's wishlist.",
@@ -2357,13 +3678,85 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "error\": \"Bad Request: error message",
+                        "description": "error\": \"Bad Request: error message",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "error\": \"Internal Server Error: failed to remove from wishlist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhook": {
+            "post": {
+                "description": "Receives Stripe events signed with the Stripe-Signature header. payment_intent.created places the order, payment_intent.succeeded marks it paid and payment_intent.payment_failed marks it failed; redelivered events are ignored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payment"
+                ],
+                "summary": "Stripe webhook",
+                "operationId": "stripe-webhook",
+                "responses": {
+                    "200": {
+                        "description": "Event processed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid signature or body",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Event could not be processed, Stripe retries it",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/webhook/razorpay": {
+            "post": {
+                "description": "Receives Razorpay events signed with the X-Razorpay-Signature header. Handles payment.authorized, payment.captured, payment.failed and refund.processed; redelivered events are ignored.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Payment"
+                ],
+                "summary": "Razorpay webhook",
+                "operationId": "razorpay-webhook",
+                "responses": {
+                    "200": {
+                        "description": "Event processed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid signature or body",
                         "schema": {
                             "type": "string"
                         }
                     },
                     "500": {
-                        "description": "error\": \"Internal Server Error: failed to remove from wishlist",
+                        "description": "Event could not be processed, Razorpay retries it",
                         "schema": {
                             "type": "string"
                         }
@@ -2373,6 +3766,28 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "entity.AdminInvite": {
+            "type": "object",
+            "required": [
+                "adminname",
+                "email",
+                "role"
+            ],
+            "properties": {
+                "adminname": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
         "entity.AdminLogin": {
             "type": "object",
             "properties": {
@@ -2384,6 +3799,22 @@ const docTemplate = `{
                 }
             }
         },
+        "entity.AdminPasswordSetup": {
+            "type": "object",
+            "required": [
+                "password",
+                "token"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
         "entity.Category": {
             "type": "object",
             "required": [
@@ -2394,35 +3825,93 @@ const docTemplate = `{
                 "description": {
                     "type": "string"
                 },
+                "gstrate": {
+                    "type": "integer",
+                    "enum": [
+                        0,
+                        3,
+                        5,
+                        12,
+                        18,
+                        28
+                    ]
+                },
+                "hsncode": {
+                    "type": "string",
+                    "maxLength": 8,
+                    "minLength": 4
+                },
                 "id": {
                     "type": "integer"
                 },
                 "name": {
                     "type": "string"
+                },
+                "taxexclusive": {
+                    "description": "TaxExclusive prices have the GST added at checkout instead of\nincluding it.",
+                    "type": "boolean"
                 }
             }
         },
         "entity.Coupon": {
             "type": "object",
             "required": [
-                "amount",
                 "code",
                 "type",
-                "usage_limit"
+                "usage_limit",
+                "valid_until"
             ],
             "properties": {
-                "amount": {
-                    "type": "integer"
+                "category": {
+                    "type": "integer",
+                    "minimum": 0
                 },
                 "code": {
                     "type": "string",
                     "maxLength": 8
                 },
+                "first_order_only": {
+                    "type": "boolean"
+                },
+                "flat": {
+                    "type": "integer",
+                    "minimum": 0
+                },
                 "id": {
                     "type": "integer"
                 },
+                "max_discount": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "min_cart_value": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "per_user_limit": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "percent": {
+                    "description": "Percent is what a percentage coupon takes off, Flat what a flat\ncoupon does. Only the one matching Type is set.",
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 0
+                },
+                "product_id": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "stackable": {
+                    "description": "Stackable coupons can be used together; any other coupon has to be\nthe only one on the cart.",
+                    "type": "boolean"
+                },
                 "type": {
-                    "type": "string"
+                    "type": "string",
+                    "enum": [
+                        "percentage",
+                        "flat"
+                    ]
                 },
                 "usage_limit": {
                     "type": "integer"
@@ -2430,6 +3919,9 @@ const docTemplate = `{
                 "usedcount": {
                     "type": "integer"
                 },
+                "valid_from": {
+                    "type": "string"
+                },
                 "valid_until": {
                     "type": "string"
                 }
@@ -2457,6 +3949,9 @@ const docTemplate = `{
                 },
                 "quantity": {
                     "type": "integer"
+                },
+                "reserved": {
+                    "type": "integer"
                 }
             }
         },
@@ -2471,6 +3966,141 @@ const docTemplate = `{
                 }
             }
         },
+        "entity.Offer": {
+            "type": "object",
+            "required": [
+                "name",
+                "type"
+            ],
+            "properties": {
+                "category": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "flat": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "minprice": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "percent": {
+                    "description": "Percent is what a percentage offer takes off the unit price, Flat\nwhat a flat offer does. Only the one matching Type is set.",
+                    "type": "integer",
+                    "maximum": 99,
+                    "minimum": 0
+                },
+                "product_id": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "status": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "percentage",
+                        "flat"
+                    ]
+                },
+                "usage_limit": {
+                    "type": "integer"
+                },
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_until": {
+                    "description": "ValidUntil nil runs the offer until it is cancelled.",
+                    "type": "string"
+                }
+            }
+        },
+        "entity.OrderItem": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "type": "integer"
+                },
+                "cgst": {
+                    "type": "integer"
+                },
+                "discountshare": {
+                    "type": "integer"
+                },
+                "gstrate": {
+                    "type": "integer"
+                },
+                "hsncode": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "igst": {
+                    "type": "integer"
+                },
+                "offerprice": {
+                    "type": "integer"
+                },
+                "orderid": {
+                    "type": "integer"
+                },
+                "prize": {
+                    "type": "integer"
+                },
+                "productid": {
+                    "type": "integer"
+                },
+                "productname": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "sgst": {
+                    "type": "integer"
+                },
+                "taxable": {
+                    "type": "integer"
+                },
+                "unitprice": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.OrderStatusHistory": {
+            "type": "object",
+            "properties": {
+                "changedat": {
+                    "type": "string"
+                },
+                "changedby": {
+                    "type": "string"
+                },
+                "changedbyid": {
+                    "type": "integer"
+                },
+                "fromstatus": {
+                    "type": "string"
+                },
+                "orderid": {
+                    "type": "integer"
+                },
+                "remark": {
+                    "type": "string"
+                },
+                "tostatus": {
+                    "type": "string"
+                }
+            }
+        },
         "entity.Product": {
             "type": "object",
             "required": [
@@ -2496,6 +4126,7 @@ const docTemplate = `{
                     "type": "integer"
                 },
                 "price": {
+                    "description": "Price is read from forms by the handler: form binding would take the\nrupees for paise.",
                     "type": "integer"
                 },
                 "removed": {
@@ -2506,6 +4137,93 @@ const docTemplate = `{
                 }
             }
         },
+        "entity.Refund": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "integer"
+                },
+                "failurereason": {
+                    "type": "string"
+                },
+                "gatewayrefundid": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "orderid": {
+                    "type": "integer"
+                },
+                "processedat": {
+                    "type": "string"
+                },
+                "returnrequestid": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "userid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.ReturnRequest": {
+            "type": "object",
+            "properties": {
+                "adminremark": {
+                    "type": "string"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "orderid": {
+                    "type": "integer"
+                },
+                "orderitemid": {
+                    "type": "integer"
+                },
+                "quantity": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "refundamount": {
+                    "type": "integer"
+                },
+                "refundmethod": {
+                    "type": "string"
+                },
+                "reviewedby": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "userid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.RolePermissions": {
+            "type": "object",
+            "properties": {
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "entity.User": {
             "type": "object",
             "required": [
@@ -2533,6 +4251,9 @@ const docTemplate = `{
                 },
                 "wallet": {
                     "type": "integer"
+                },
+                "walletheld": {
+                    "type": "integer"
                 }
             }
         },
@@ -2542,8 +4263,7 @@ const docTemplate = `{
                 "address",
                 "country",
                 "pin",
-                "state",
-                "type"
+                "state"
             ],
             "properties": {
                 "address": {
@@ -2555,14 +4275,70 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "isdefault": {
+                    "type": "boolean"
+                },
                 "pin": {
                     "type": "string"
                 },
                 "state": {
                     "type": "string"
                 },
+                "type": {
+                    "description": "Type is a free label such as home or work; several addresses may\nshare one.",
+                    "type": "string"
+                }
+            }
+        },
+        "entity.WalletDiscrepancy": {
+            "type": "object",
+            "properties": {
+                "entries": {
+                    "type": "integer"
+                },
+                "ledger": {
+                    "type": "integer"
+                },
+                "stored": {
+                    "type": "integer"
+                },
+                "userid": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entity.WalletTransaction": {
+            "type": "object",
+            "properties": {
+                "adminid": {
+                    "type": "integer"
+                },
+                "amount": {
+                    "type": "integer"
+                },
+                "balanceafter": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "orderid": {
+                    "type": "integer"
+                },
+                "postedat": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "remark": {
+                    "type": "string"
+                },
                 "type": {
                     "type": "string"
+                },
+                "userid": {
+                    "type": "integer"
                 }
             }
         },
@@ -0,0 +1,75 @@
+// Command razorpaystub is a local stand-in for the parts of the Razorpay API
+// the shop calls. Point RAZOPAYBASEURL at it to place and refund orders
+// without reaching Razorpay. Refunds of payment ids starting with
+// "pay_fail" are rejected so failure handling can be exercised.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+var seq int64
+
+func nextId(prefix string) string {
+	return fmt.Sprintf("%s_stub%06d", prefix, atomic.AddInt64(&seq, 1))
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func main() {
+	addr := flag.String("addr", ":9090", "listen address")
+	flag.Parse()
+
+	http.HandleFunc("/v1/orders", func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"id":       nextId("order"),
+			"entity":   "order",
+			"amount":   req["amount"],
+			"currency": req["currency"],
+			"receipt":  req["receipt"],
+			"status":   "created",
+		})
+	})
+
+	http.HandleFunc("/v1/payments/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/payments/"), "/")
+		if len(parts) != 2 || parts[1] != "refund" {
+			http.NotFound(w, r)
+			return
+		}
+		paymentId := parts[0]
+		if strings.HasPrefix(paymentId, "pay_fail") {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"error": map[string]interface{}{
+					"code":        "BAD_REQUEST_ERROR",
+					"description": "The payment could not be refunded",
+				},
+			})
+			return
+		}
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"id":         nextId("rfnd"),
+			"entity":     "refund",
+			"amount":     req["amount"],
+			"payment_id": paymentId,
+			"status":     "processed",
+		})
+	})
+
+	log.Printf("razorpay stub listening on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
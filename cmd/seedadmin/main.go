@@ -0,0 +1,46 @@
+// Command seedadmin creates the first super admin, who can then invite the
+// other admins through the API. It reads the database settings from the
+// same .env as the server and the password from ADMIN_PASSWORD, so it does
+// not show up in the process list. It does nothing once an active super
+// admin exists.
+//
+//	ADMIN_PASSWORD=... go run ./cmd/seedadmin -email admin@example.com -name Admin
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"project/config"
+	adminrepository "project/repository/admin"
+	"project/repository/infrastructure"
+	adminusecase "project/usecase/admin"
+)
+
+func main() {
+	name := flag.String("name", "", "admin name")
+	email := flag.String("email", "", "admin email, used to log in")
+	phone := flag.String("phone", "", "admin phone")
+	flag.Parse()
+
+	password := os.Getenv("ADMIN_PASSWORD")
+	if *name == "" || *email == "" || password == "" {
+		flag.Usage()
+		log.Fatal("name, email and ADMIN_PASSWORD are required")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal("error loading files using viper")
+	}
+	db, err := infrastructure.ConnectDb(cfg.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	admins := adminusecase.NewAdmin(adminrepository.NewAdminRepository(db))
+	admin, err := admins.ExecuteSeedSuperAdmin(*name, *email, *phone, password)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("super admin %s created with id %d", admin.Email, admin.ID)
+}
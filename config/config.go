@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -17,14 +19,65 @@ type DataBase struct {
 	DBHost     string `mapstructure:"DBHOST"`
 	DBPort     string `mapstructure:"DBPORT"`
 }
+// OTP picks who sends the one time passwords. Provider is "twilio", "db"
+// or "console"; the self-hosted db and console providers make codes that
+// last TTL and allow MaxAttempts guesses, and write them to File or else
+// standard output. CountryCode is added to phone numbers without one.
 type OTP struct {
-	AccountSid string `mapstructure:"AccountSid"`
-	AuthToken  string `mapstructure:"AuthToken"`
-	ServiceSid string `mapstructure:"ServiceSid"`
+	AccountSid  string        `mapstructure:"AccountSid"`
+	AuthToken   string        `mapstructure:"AuthToken"`
+	ServiceSid  string        `mapstructure:"ServiceSid"`
+	Provider    string        `mapstructure:"OTPPROVIDER"`
+	CountryCode string        `mapstructure:"OTPCOUNTRYCODE"`
+	TTL         time.Duration `mapstructure:"OTPTTL"`
+	MaxAttempts int           `mapstructure:"OTPMAXATTEMPTS"`
+	File        string        `mapstructure:"OTPFILE"`
 }
 type Razopay struct {
 	RazopayKey    string `mapstructure:"RAZOPAYKEY"`
 	RazopaySecret string `mapstructure:"RAZOPAYSECRET"`
+	// RazopayBaseURL points the client at another host, such as a local
+	// stand-in for the Razorpay API. Empty means the real API.
+	RazopayBaseURL string `mapstructure:"RAZOPAYBASEURL"`
+	// RazopayWebhookSecret signs the events Razorpay posts to /webhook/razorpay.
+	RazopayWebhookSecret string `mapstructure:"RAZOPAYWEBHOOKSECRET"`
+}
+
+type Stripe struct {
+	StripeSecretKey string `mapstructure:"STRIPESECRETKEY"`
+	// StripeWebhookSecret signs the events Stripe posts to /webhook.
+	StripeWebhookSecret string `mapstructure:"STRIPEWEBHOOKSECRET"`
+}
+
+type Payment struct {
+	// Gateway set to "fake" serves every payment method from an in-process
+	// fake gateway, for local development and tests.
+	Gateway string `mapstructure:"PAYMENTGATEWAY"`
+}
+
+// Seller is printed on tax invoices. SellerState decides whether a sale is
+// taxed as CGST and SGST or as IGST.
+type Seller struct {
+	SellerName    string `mapstructure:"SELLERNAME"`
+	SellerAddress string `mapstructure:"SELLERADDRESS"`
+	SellerState   string `mapstructure:"SELLERSTATE"`
+	SellerGSTIN   string `mapstructure:"SELLERGSTIN"`
+	// GSTRate is the GST percentage for categories without an HSN code.
+	GSTRate int `mapstructure:"GSTRATE"`
+}
+
+// Guest signs the tokens that keep the carts of shoppers who are not
+// logged in.
+type Guest struct {
+	GuestSecret string `mapstructure:"GUESTSECRET"`
+}
+
+// JWT signs the login tokens. Access tokens last AccessTokenTTL and
+// refresh tokens RefreshTokenTTL, given as durations such as "15m".
+type JWT struct {
+	JWTSecret       string        `mapstructure:"JWTSECRET"`
+	AccessTokenTTL  time.Duration `mapstructure:"ACCESSTOKENTTL"`
+	RefreshTokenTTL time.Duration `mapstructure:"REFRESHTOKENTTL"`
 }
 
 type Config struct {
@@ -32,6 +85,11 @@ type Config struct {
 	DB DataBase
 	Otp OTP
 	Razopay Razopay
+	Stripe Stripe
+	Payment Payment
+	Seller Seller
+	Guest Guest
+	JWT JWT
 }
 
 func LoadConfig() (*Config, error) {
@@ -40,6 +98,11 @@ func LoadConfig() (*Config, error) {
 		db DataBase
 		otp OTP
 		razorpay Razopay
+		stripe Stripe
+		payment Payment
+		seller Seller
+		guest Guest
+		jwt JWT
 	)
 
 	viper.AddConfigPath("./")
@@ -66,6 +129,26 @@ func LoadConfig() (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	config := Config{S3aws: s3,DB: db,Razopay: razorpay,Otp:otp}
+	err = viper.Unmarshal(&stripe)
+	if err != nil {
+		return nil, err
+	}
+	err = viper.Unmarshal(&payment)
+	if err != nil {
+		return nil, err
+	}
+	err = viper.Unmarshal(&seller)
+	if err != nil {
+		return nil, err
+	}
+	err = viper.Unmarshal(&guest)
+	if err != nil {
+		return nil, err
+	}
+	err = viper.Unmarshal(&jwt)
+	if err != nil {
+		return nil, err
+	}
+	config := Config{S3aws: s3,DB: db,Razopay: razorpay,Otp:otp,Stripe: stripe,Payment: payment,Seller: seller,Guest: guest,JWT: jwt}
 	return &config, nil
 }
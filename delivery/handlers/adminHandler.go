@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"project/delivery/middleware"
 	"project/domain/entity"
+	"project/domain/money"
 	usecase "project/usecase/admin"
 	product "project/usecase/product"
 
@@ -30,7 +32,7 @@ func NewAdminHandler(AdminUsecase *usecase.AdminUseCase, ProductUsecase *product
 // @Accept json
 // @Produce json
 // @Param			admin	body		entity.AdminLogin	true	"Admin Data"
-// @Success 200 {object} string "message": "Admin logged in successfully"
+// @Success 200 {object} string "message": "Admin logged in successfully", "tokens": middleware.Tokens
 // @Failure 400 {object} string "error": "Empty request body"
 // @Router /admin/login [post]
 func (uh *AdminHandler) AdminLoginWithPassword(c *gin.Context) {
@@ -51,10 +53,13 @@ func (uh *AdminHandler) AdminLoginWithPassword(c *gin.Context) {
 		fmt.Printf("Authentication failed: %v\n", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Authentication failed", "details": err.Error()})
 		return
-	} else {
-		middleware.CreateToken(adminId, email, "admin", c)
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Admin logged in succesfully"})
+	tokens, err := middleware.CreateToken(adminId, email, "admin", c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create token"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Admin logged in succesfully", "tokens": tokens})
 }
 
 // @Summary List Users
@@ -270,6 +275,12 @@ func (cp *AdminHandler) CreateProduct(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	price, err := money.Parse(c.PostForm("price"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid price"})
+		return
+	}
+	input.Price = price
 
 	image, _ := c.FormFile("image")
 	category, err := cp.ProductUseCase.ExecuteGetCategory(entity.Category{ID: input.Category})
@@ -345,7 +356,7 @@ func (cp *AdminHandler) CreateProduct(c *gin.Context) {
 // @Failure 400 {string} string "String conversion error"
 // @Failure 400 {string} string "JSON binding failed"
 // @Failure 400 {string} string "Product edit failed"
-// @Router /admin/products/{id} [put]
+// @Router /admin/products/{id} [patch]
 func (ep *AdminHandler) EditProduct(c *gin.Context) {
 	var product entity.Product
 	ids := c.Param("id")
@@ -524,9 +535,88 @@ func (ad *AdminHandler) DeleteCoupon(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "coupon succesfully deleted"})
 }
 
+// AddOffer godoc
+// @Summary Schedule an offer
+// @Description Schedule a percentage or flat offer on a product, or on every product of a category, from valid_from (default now) until valid_until (default until cancelled)
+// @ID addOffer
+// @Tags Admin Offer Management
+// @Accept json
+// @Produce json
+// @Param offer body entity.Offer true "Offer details"
+// @Success 200 {string} string "offer: entity.Offer"
+// @Failure 400 {string} string "error: Failed to add offer"
+// @Router /admin/offer [post]
+func (ad *AdminHandler) AddOffer(c *gin.Context) {
+	var offer entity.Offer
+	if err := c.ShouldBindJSON(&offer); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := ad.ProductUseCase.ExecuteAddOffer(&offer); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "offer added succesfully", "offer": offer})
+}
+
+// AllOffer godoc
+// @Summary List offers
+// @Description List offers, newest first, optionally filtered by status (scheduled, active, expired, cancelled)
+// @ID allOffer
+// @Tags Admin Offer Management
+// @Produce json
+// @Param status query string false "Offer status"
+// @Param page query int false "Page number for pagination (default is 1)"
+// @Param limit query int false "Number of items per page (default is 10)"
+// @Success 200 {array} entity.Offer
+// @Failure 400 {string} string "error: Failed to retrieve offers"
+// @Router /admin/offer [get]
+func (ad *AdminHandler) AllOffer(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	offers, err := ad.ProductUseCase.ExecuteAdminOffers(page, limit, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"offers": offers})
+}
+
+// CancelOffer godoc
+// @Summary Cancel an offer
+// @Description End a scheduled or running offer now; its products go back to their price without it
+// @ID cancelOffer
+// @Tags Admin Offer Management
+// @Produce json
+// @Param id path int true "Offer ID"
+// @Success 200 {string} string "offer: entity.Offer"
+// @Failure 400 {string} string "error: Failed to cancel offer"
+// @Router /admin/offer/{id} [delete]
+func (ad *AdminHandler) CancelOffer(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	offer, err := ad.ProductUseCase.ExecuteCancelOffer(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "offer cancelled", "offer": offer})
+}
+
 // AddProductOffer godoc
 // @Summary Add an offer to a product
-// @Description Add an offer to a product based on the provided product ID and offer
+// @Description Put a percentage offer on a product from now until it is cancelled
 // @ID addProductOffer
 // @Tags Admin Offer Management
 // @Accept multipart/form-data
@@ -561,7 +651,7 @@ func (ad *AdminHandler) AddProductOffer(c *gin.Context) {
 
 // AddCategoryOffer godoc
 // @Summary Add an offer to a category
-// @Description Add an offer to a category based on the provided category ID and offer
+// @Description Put a percentage offer on every product of a category from now until it is cancelled
 // @ID addCategoryOffer
 // @Tags Admin Offer Management
 // @Accept multipart/form-data
@@ -678,20 +768,252 @@ func (or *AdminHandler) AddStock(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"Updated result :": inventory})
 }
 
+// RefreshToken godoc
+// @Summary Renew the admin's login
+// @Description Exchanges a refresh token for a new access token and a new refresh token. Bearer clients send it as refresh_token in the body and get the new tokens back; browsers use the Refresh cookie and the X-CSRF-Token header. A refresh token can only be used once; using it again ends the session.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Success 200 {string} string "message: token refreshed, tokens: middleware.Tokens"
+// @Failure 401 {string} string "error: invalid token"
+// @Failure 403 {string} string "error: missing or invalid csrf token"
+// @Router /admin/token/refresh [post]
+func (ad *AdminHandler) RefreshToken(c *gin.Context) {
+	tokens, err := middleware.RefreshToken(c, "admin")
+	if errors.Is(err, middleware.ErrCSRF) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if tokens != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "token refreshed", "tokens": tokens})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "token refreshed"})
+}
+
+// LogoutAll godoc
+// @Summary Log the admin out of all devices
+// @Description Ends every session of the admin, on every device.
+// @Tags Admin
+// @Produce json
+// @Success 200 {string} string "message: logged out of all devices"
+// @Failure 500 {string} string "error: logout failed"
+// @Router /admin/logout/all [post]
+func (ad *AdminHandler) LogoutAll(c *gin.Context) {
+	adminId, _ := c.Get("UserId")
+	if err := middleware.DeleteAllTokens(c, adminId.(int), "admin"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "logout failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out of all devices"})
+}
+
 // Logout godoc
 // @Summary Logs out the Admin
-// @Description Deletes the authentication token cookie to log the admin out
+// @Description Revokes the admin's tokens and deletes their cookies to log the admin out
 // @Tags Admin
 // @Produce json
 // @Success 200 {string} string "Admin logged out successfully"
 // @Failure 400 {string} string "cookie delete failed"
 // @Router /admin/logout [post]
 func (cu *AdminHandler) Logout(c *gin.Context) {
-	err := middleware.DeleteToken(c)
-	if err != nil {
+	err := middleware.DeleteToken(c, "admin")
+	if errors.Is(err, middleware.ErrCSRF) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"errror": "cookie delete failed"})
 		return
 	} else {
 		c.JSON(http.StatusOK, gin.H{"message": "Admin logged out succesfully"})
 	}
 }
+
+// Admins godoc
+// @Summary List admins
+// @Description Lists the admin accounts. Needs the admins permission.
+// @ID listAdmins
+// @Tags Admin Account Management
+// @Produce json
+// @Success 200 {string} string "admins: []entity.AdminAccount"
+// @Failure 400 {string} string "error: Failed to list admins"
+// @Router /admin/admins [get]
+func (ad *AdminHandler) Admins(c *gin.Context) {
+	admins, err := ad.AdminUseCase.ExecuteAdmins()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"admins": admins})
+}
+
+// InviteAdmin godoc
+// @Summary Invite an admin
+// @Description Creates an admin of a role without a password. Only super admins invite super admins. The setup token in the response is handed to the new admin, who sets their password with it within 72 hours.
+// @ID inviteAdmin
+// @Tags Admin Account Management
+// @Accept json
+// @Produce json
+// @Param admin body entity.AdminInvite true "Admin details"
+// @Success 200 {string} string "id: int, setuptoken: string"
+// @Failure 400 {string} string "error: Failed to invite admin"
+// @Router /admin/admins [post]
+func (ad *AdminHandler) InviteAdmin(c *gin.Context) {
+	var invite entity.AdminInvite
+	if err := c.ShouldBindJSON(&invite); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	adminId, _ := c.Get("UserId")
+	admin, token, err := ad.AdminUseCase.ExecuteInviteAdmin(adminId.(int), invite)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "admin invited", "id": admin.ID, "setuptoken": token})
+}
+
+// DeactivateAdmin godoc
+// @Summary Deactivate an admin
+// @Description Stops an admin from logging in. Only super admins deactivate super admins, and the last active super admin cannot be deactivated.
+// @ID deactivateAdmin
+// @Tags Admin Account Management
+// @Produce json
+// @Param id path int true "Admin ID"
+// @Success 200 {string} string "message: admin deactivated"
+// @Failure 400 {string} string "error: Failed to deactivate admin"
+// @Router /admin/admins/{id}/deactivate [patch]
+func (ad *AdminHandler) DeactivateAdmin(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	adminId, _ := c.Get("UserId")
+	if err := ad.AdminUseCase.ExecuteDeactivateAdmin(adminId.(int), id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := middleware.EndSessions(id, "admin"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "admin deactivated but ending their sessions failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "admin deactivated"})
+}
+
+// ResetAdmin godoc
+// @Summary Reset an admin's password
+// @Description Clears an admin's password and reactivates them. Only super admins reset super admins. The setup token in the response is handed to the admin, who sets a new password with it within 72 hours.
+// @ID resetAdmin
+// @Tags Admin Account Management
+// @Produce json
+// @Param id path int true "Admin ID"
+// @Success 200 {string} string "setuptoken: string"
+// @Failure 400 {string} string "error: Failed to reset admin"
+// @Router /admin/admins/{id}/reset [post]
+func (ad *AdminHandler) ResetAdmin(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	adminId, _ := c.Get("UserId")
+	token, err := ad.AdminUseCase.ExecuteResetAdmin(adminId.(int), id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := middleware.EndSessions(id, "admin"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "admin reset but ending their sessions failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "admin password reset", "setuptoken": token})
+}
+
+// SetupAdminPassword godoc
+// @Summary Set an admin's password
+// @Description Sets the password of an invited or reset admin with the setup token they were given. The token can only be used once.
+// @ID setupAdminPassword
+// @Tags Admin Account Management
+// @Accept json
+// @Produce json
+// @Param setup body entity.AdminPasswordSetup true "Setup token and new password"
+// @Success 200 {string} string "message: password set"
+// @Failure 400 {string} string "error: Failed to set password"
+// @Router /admin/setup-password [post]
+func (ad *AdminHandler) SetupAdminPassword(c *gin.Context) {
+	var setup entity.AdminPasswordSetup
+	if err := c.ShouldBindJSON(&setup); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := ad.AdminUseCase.ExecuteSetupAdminPassword(setup); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password set, log in with it"})
+}
+
+// Roles godoc
+// @Summary List roles and their permissions
+// @Description Returns the permission matrix, role by role, and every permission a role can be granted. Super admins hold every permission.
+// @ID listRoles
+// @Tags Admin Account Management
+// @Produce json
+// @Success 200 {string} string "roles: map[string][]string, permissions: []string"
+// @Failure 400 {string} string "error: Failed to list roles"
+// @Router /admin/roles [get]
+func (ad *AdminHandler) Roles(c *gin.Context) {
+	roles, err := ad.AdminUseCase.ExecuteRoles()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": roles, "permissions": entity.AdminPermissions})
+}
+
+// SetRolePermissions godoc
+// @Summary Set a role's permissions
+// @Description Gives a role exactly the listed permissions, creating the role if it is new. The super admin role cannot be changed.
+// @ID setRolePermissions
+// @Tags Admin Account Management
+// @Accept json
+// @Produce json
+// @Param role path string true "Role"
+// @Param permissions body entity.RolePermissions true "Permissions of the role"
+// @Success 200 {string} string "message: role updated"
+// @Failure 400 {string} string "error: Failed to update role"
+// @Router /admin/roles/{role} [put]
+func (ad *AdminHandler) SetRolePermissions(c *gin.Context) {
+	var permissions entity.RolePermissions
+	if err := c.ShouldBindJSON(&permissions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := ad.AdminUseCase.ExecuteSetRolePermissions(c.Param("role"), permissions.Permissions); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "role updated"})
+}
+
+// DeleteRole godoc
+// @Summary Delete a role
+// @Description Removes a role that no admin holds any more.
+// @ID deleteRole
+// @Tags Admin Account Management
+// @Produce json
+// @Param role path string true "Role"
+// @Success 200 {string} string "message: role deleted"
+// @Failure 400 {string} string "error: Failed to delete role"
+// @Router /admin/roles/{role} [delete]
+func (ad *AdminHandler) DeleteRole(c *gin.Context) {
+	if err := ad.AdminUseCase.ExecuteDeleteRole(c.Param("role")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "role deleted"})
+}
@@ -1,30 +1,25 @@
 package handlers
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"project/config"
-	"project/domain/entity"
+	"project/domain/payment"
 	usecase "project/usecase/order"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/stripe/stripe-go"
-	"github.com/stripe/stripe-go/paymentintent"
-	"github.com/stripe/stripe-go/webhook"
 )
 
 type OrderHandler struct {
 	OrderUseCase *usecase.OrderUseCase
-	Razorpay     config.Razopay
 }
 
-func NewOrderHandler(OrderUseCase *usecase.OrderUseCase, Razorpay config.Razopay) *OrderHandler {
-	return &OrderHandler{OrderUseCase, Razorpay}
+func NewOrderHandler(OrderUseCase *usecase.OrderUseCase) *OrderHandler {
+	return &OrderHandler{OrderUseCase}
 }
 
 // PlaceOrder godoc
@@ -34,8 +29,9 @@ func NewOrderHandler(OrderUseCase *usecase.OrderUseCase, Razorpay config.Razopay
 // @Accept json
 // @Tags User Orders
 // @Produce json
-// @Param addressid path int true "Address ID for the order"
+// @Param addressid path int true "ID of one of the user's addresses to deliver to"
 // @Param payment path string true "Payment method ('cod', 'razorpay', 'wallet')"
+// @Param usewallet query bool false "For razorpay, pay what the wallet covers and the rest through razorpay"
 // @Success 200 {string} string "Invoice details" "Successful response for COD payment"
 // @Success 200 {string} string "Complete your Razorpay payment through. Razorpay ID: {razorId}, Order ID: {orderid}, User ID: {userid}" "Successful response for Razorpay payment"
 // @Success 200 {string} string "Invoice details" "Successful response for Wallet payment"
@@ -60,7 +56,8 @@ func (oh *OrderHandler) PlaceOrder(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"invoice": invoice})
 		}
 	} else if PaymentMethod == "razorpay" {
-		razorId, orderId, err1 := oh.OrderUseCase.ExecuteRazorPay(userid, addressId)
+		useWallet := c.Query("usewallet") == "true"
+		razorId, orderId, err1 := oh.OrderUseCase.ExecuteRazorPay(userid, addressId, useWallet)
 		if err1 != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err1.Error()})
 			return
@@ -112,17 +109,20 @@ func (co *OrderHandler) PaymentVerification(c *gin.Context) {
 // @Tags User Orders
 // @Produce json
 // @Param orderid path int true "Order ID to be canceled"
+// @Param refund formData string false "Refund prepaid orders to 'original' payment or 'wallet' (default original for razorpay and stripe orders, wallet otherwise)"
 // @Success 200 {string} string "Order canceled successfully"
 // @Failure 400 {string} string "Bad request"
 // @Router /user/order/cancel/{orderid} [patch]
 func (co *OrderHandler) CancelOrder(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userid := userID.(int)
 	strorderId := c.Param("orderid")
 	orderid, err := strconv.Atoi(strorderId)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
 		return
 	}
-	err1 := co.OrderUseCase.ExecuteCancelOrder(orderid)
+	refunds, err1 := co.OrderUseCase.ExecuteCancelOrder(userid, orderid, c.PostForm("refund"))
 	if err1 != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err1.Error()})
 		return
@@ -132,7 +132,7 @@ func (co *OrderHandler) CancelOrder(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err2.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"order cancceled ": updatedorder})
+	c.JSON(http.StatusOK, gin.H{"order cancceled ": updatedorder, "refunds": refunds})
 }
 
 // OrderHistory godoc
@@ -169,6 +169,32 @@ func (co *OrderHandler) OrderHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"orderlist": orderlist})
 }
 
+// OrderTimeline godoc
+// @Summary Get the status timeline of an order
+// @Description Retrieves every status change of one of the authenticated user's orders, oldest first.
+// @ID get-order-timeline
+// @Tags User Orders
+// @Produce json
+// @Param orderid path int true "Order ID"
+// @Success 200 {array} entity.OrderStatusHistory
+// @Failure 400 {string} string "Bad request"
+// @Router /user/order/timeline/{orderid} [get]
+func (co *OrderHandler) OrderTimeline(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userid := userID.(int)
+	orderid, err := strconv.Atoi(c.Param("orderid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	timeline, err := co.OrderUseCase.ExecuteOrderTimeline(userid, orderid)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"timeline": timeline})
+}
+
 // AdminOrderUpdate godoc
 // @Summary Update order status (Admin)
 // @Description Updates the status of an order based on the provided order ID and status (for admin use).
@@ -176,7 +202,8 @@ func (co *OrderHandler) OrderHistory(c *gin.Context) {
 // @Tags Admin Orders
 // @Produce json
 // @Param orderid path int true "Order ID to be updated"
-// @Param status formData string true "New status for the order"
+// @Param status formData string true "New status (confirmed, packed, shipped, out_for_delivery, delivered, cancelled, returned, refunded)"
+// @Param remark formData string false "Note recorded in the order status history"
 // @Success 200 {string} string "Order updated successfully. Updated order status: {updated order status}"
 // @Failure 400 {string} string "Bad request"
 // @Router /admin/order/update/{orderid} [patch]
@@ -187,11 +214,12 @@ func (op *OrderHandler) AdminOrderUpdate(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "strign conversion failed"})
 		return
 	}
+	adminID, _ := c.Get("UserId")
+	adminId := adminID.(int)
 	status := c.PostForm("status")
+	remark := c.PostForm("remark")
 
-
-	fmt.Println("status", status)
-	err1 := op.OrderUseCase.ExecuteOrderUpdate(orderid, status)
+	err1 := op.OrderUseCase.ExecuteOrderUpdate(orderid, status, adminId, remark)
 	if err1 != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err1.Error()})
 		return
@@ -242,6 +270,7 @@ func (op *OrderHandler) AdminOrderDetails(c *gin.Context) {
 // @Tags Admin Orders
 // @Produce json
 // @Param orderid path int true "Order ID to be canceled"
+// @Param refund formData string false "Refund prepaid orders to 'original' payment or 'wallet' (default original for razorpay and stripe orders, wallet otherwise)"
 // @Success 200 {string} string "Order cancelled successfully"
 // @Failure 400 {string} string "Bad request"
 // @Router /admin/order/cancel/{orderid} [patch]
@@ -252,12 +281,14 @@ func (op *OrderHandler) AdminCancelOrder(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "string covertion failed"})
 		return
 	}
-	err1 := op.OrderUseCase.ExecuteAdminCancelOrder(orderid)
+	adminID, _ := c.Get("UserId")
+	adminId := adminID.(int)
+	refunds, err1 := op.OrderUseCase.ExecuteAdminCancelOrder(orderid, adminId, c.PostForm("refund"))
 	if err1 != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err1.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "order cancelled"})
+	c.JSON(http.StatusOK, gin.H{"message": "order cancelled", "refunds": refunds})
 }
 
 // SalesReportByDate godoc
@@ -348,168 +379,74 @@ func (or *OrderHandler) ExecutePaymentStripe(c *gin.Context) {
 	userId := UserID.(int)
 	straddress := c.PostForm("address")
 	addresid, err := strconv.Atoi(straddress)
-
-	stripe.Key = "sk_test_51OFC9hSJxogb8Is5XfYeIuKpqDOMKzH7NPVdwTZDVu0I6wc0sOX4CCZ66scJRKM7iYemPXk2D5fvRLKGrHFe60OF00psv6EYzW"
-
-	orders, err := or.OrderUseCase.ExecuteCartit(userId)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "strng conversion failed"})
 		return
 	}
-	params := &stripe.PaymentIntentParams{
-		Params: stripe.Params{
-			Metadata: map[string]string{
-				"user_id":    strconv.Itoa(userId),
-				"address_id": strconv.Itoa(addresid),
-			},
-		},
-		Amount:   stripe.Int64(int64(orders.TotalPrize)),
-		Currency: stripe.String("INR"),
-	}
-
-	intent, err := paymentintent.New(params)
+	useWallet := c.PostForm("usewallet") == "true"
+	clientSecret, err := or.OrderUseCase.ExecuteStripeIntent(userId, addresid, useWallet)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.HTML(http.StatusOK, "stripe.html", gin.H{
-		"ClientSecret": intent.ClientSecret,
+		"ClientSecret": clientSecret,
 	})
 
 }
 
-var invoiceMap = make(map[string]*entity.Invoice)
-
+// HandleWebhook godoc
+// @Summary Stripe webhook
+// @Description Receives Stripe events signed with the Stripe-Signature header. payment_intent.created places the order, payment_intent.succeeded marks it paid and payment_intent.payment_failed marks it failed; redelivered events are ignored.
+// @ID stripe-webhook
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Success 200 {string} string "Event processed"
+// @Failure 400 {string} string "Invalid signature or body"
+// @Failure 500 {string} string "Event could not be processed, Stripe retries it"
+// @Router /webhook [post]
 func (cr *OrderHandler) HandleWebhook(c *gin.Context) {
-	var Resul *entity.Invoice
+	cr.handleWebhook(c, payment.ProviderStripe)
+}
 
+// HandleRazorpayWebhook godoc
+// @Summary Razorpay webhook
+// @Description Receives Razorpay events signed with the X-Razorpay-Signature header. Handles payment.authorized, payment.captured, payment.failed and refund.processed; redelivered events are ignored.
+// @ID razorpay-webhook
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Success 200 {string} string "Event processed"
+// @Failure 400 {string} string "Invalid signature or body"
+// @Failure 500 {string} string "Event could not be processed, Razorpay retries it"
+// @Router /webhook/razorpay [post]
+func (cr *OrderHandler) HandleRazorpayWebhook(c *gin.Context) {
+	cr.handleWebhook(c, payment.ProviderRazorpay)
+}
+
+func (cr *OrderHandler) handleWebhook(c *gin.Context, provider string) {
 	const MaxBodyBytes = int64(65536)
 	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxBodyBytes)
 	payload, err := io.ReadAll(c.Request.Body)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading request body: %v\n", err)
-		c.AbortWithStatus(http.StatusServiceUnavailable)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "error reading request body"})
 		return
 	}
-
-	endpointSecret := "whsec_907f81648d3e7562e20257062f4a8a175b9584e0eb9d2c97f772d99237824749"
-
-	event, err := webhook.ConstructEvent(payload, c.Request.Header.Get("Stripe-Signature"), endpointSecret)
-
+	event, err := cr.OrderUseCase.ParseWebhook(provider, payload, c.Request.Header)
 	if err != nil {
-
-		fmt.Fprintf(os.Stderr, "Error verifying webhook signature: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error verifying %s webhook: %v\n", provider, err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	switch event.Type {
-	case "payment_intent.created":
-		var paymentIntent stripe.PaymentIntent
-		err := json.Unmarshal(event.Data.Raw, &paymentIntent)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing webhook JSON: %v\n", err)
-			c.AbortWithStatus(http.StatusBadRequest)
-			return
-		}
-		userID, _ := paymentIntent.Metadata["user_id"]
-		addressID, _ := paymentIntent.Metadata["address_id"]
-
-		userid, _ := strconv.Atoi(userID)
-		addressid, _ := strconv.Atoi(addressID)
-
-		result, err := cr.OrderUseCase.ExecuteInvoiceStripe(userid, addressid)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"errror": "invoice creation failed"})
-			return
-		}
-		invoiceMap[paymentIntent.ID] = result
-		Resul = result
-		fmt.Println("Invoice Created successfully")
-		fmt.Println("res", Resul.OrderId)
-
-	case "payment_intent.succeeded":
-		var paymentIntent stripe.PaymentIntent
-		err := json.Unmarshal(event.Data.Raw, &paymentIntent)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing webhook JSON: %v\n", err)
-			c.AbortWithStatus(http.StatusBadRequest)
-			return
-		}
-		Resul = invoiceMap[paymentIntent.ID]
-
-		fmt.Println("res", Resul.OrderId)
-		if paymentIntent.Status == "succeeded" {
-			if Resul != nil {
-
-				err := cr.OrderUseCase.UpdateInvoiceStatus(int(Resul.OrderId), "succesfull")
-				if err != nil {
-					fmt.Fprintf(os.Stderr, "Error updating invoice status: %v\n", err)
-
-				}
-				fmt.Println("Invoice Created successfully succes")
-			}
-		}
-
-		if paymentIntent.Status != "succeeded" {
-			_, confirmErr := paymentintent.Confirm(
-				paymentIntent.ID,
-				&stripe.PaymentIntentConfirmParams{
-					PaymentMethod: stripe.String(paymentIntent.PaymentMethod.ID),
-				},
-			)
-
-			if confirmErr != nil {
-				fmt.Fprintf(os.Stderr, "Error confirming PaymentIntent: %v\n", confirmErr)
-				c.AbortWithStatus(http.StatusBadRequest)
-				return
-			}
-
-			fmt.Println("PaymentIntent confirmed successfully")
-		} else {
-
-			fmt.Println("PaymentIntent is already succeeded")
-		}
-
-	case "payment_method.attached":
-		var paymentMethod stripe.PaymentMethod
-		err := json.Unmarshal(event.Data.Raw, &paymentMethod)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing webhook JSON: %v\n", err)
-			c.AbortWithStatus(http.StatusBadRequest)
-			return
-		}
-	case "payment_intent.failed":
-
-		var paymentIntent stripe.PaymentIntent
-		err := json.Unmarshal(event.Data.Raw, &paymentIntent)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing webhook JSON: %v\n", err)
-			c.AbortWithStatus(http.StatusBadRequest)
-			return
-		}
-		userID, _ := paymentIntent.Metadata["user_id"]
-		addressID, _ := paymentIntent.Metadata["address_id"]
-
-		userid, _ := strconv.Atoi(userID)
-		addressid, _ := strconv.Atoi(addressID)
-
-		invoice, err := cr.OrderUseCase.CreateInvoiceForFailedPayment(userid, addressid)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-		Resul = invoice
-		fmt.Println("Invoice Created successfully fail")
-
-	default:
-		fmt.Fprintf(os.Stderr, "Unhandled event type: %s\n", event.Type)
-
+	invoice, err := cr.OrderUseCase.ExecuteWebhook(provider, event)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error processing %s webhook %s: %v\n", provider, event.Type, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{"status": "suscces", "invoice": Resul})
-
+	c.JSON(http.StatusOK, gin.H{"status": "processed", "invoice": invoice})
 }
 
 // OrderStatus godoc
@@ -539,13 +476,13 @@ func (or *OrderHandler) OrderStatus(c *gin.Context) {
 }
 
 // PrintInvoice godoc
-// @Summary Print invoice for an order
-// @Description Generates and downloads the invoice for a specific order.
+// @Summary Download the tax invoice of an order
+// @Description Downloads the GST invoice of one of the user's orders. The invoice is numbered and stored when the sale completes, so every download is the same document.
 // @ID print-invoice
 // @Tags User Orders
-// @Produce json
+// @Produce application/pdf
 // @Param orderid query int true "Order ID for which the invoice should be generated"
-// @Success 200 {string} string "Invoice generated and downloaded successfully"
+// @Success 200 {file} file "Invoice PDF"
 // @Failure 400 {string} string "Bad request"
 // @Router /user/order/invoice [get]
 func (or *OrderHandler) PrintInvoice(c *gin.Context) {
@@ -557,29 +494,279 @@ func (or *OrderHandler) PrintInvoice(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	pdf, err := or.OrderUseCase.ExecutPrintInvoice(orderid, userid)
+	invoice, err := or.OrderUseCase.ExecutPrintInvoice(orderid, userid)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.Header("Content-Disposition", "attachment;filename=Invoice.pdf")
-	c.Header("Content_Type", "application/pdf")
+	filename := strings.ReplaceAll(invoice.Number, "/", "-") + ".pdf"
+	c.Header("Content-Disposition", "attachment;filename="+filename)
+	c.Data(http.StatusOK, "application/pdf", invoice.Pdf)
+}
 
-	err = pdf.Output(c.Writer)
+// OrderItems godoc
+// @Summary List the items of an order
+// @Description Retrieves the items of one of the authenticated user's orders. The item id is used to request a return.
+// @ID get-order-items
+// @Tags User Orders
+// @Produce json
+// @Param orderid path int true "Order ID"
+// @Success 200 {array} entity.OrderItem
+// @Failure 400 {string} string "Bad request"
+// @Router /user/order/items/{orderid} [get]
+func (co *OrderHandler) OrderItems(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userid := userID.(int)
+	orderid, err := strconv.Atoi(c.Param("orderid"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"errror": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
 		return
 	}
+	items, err := co.OrderUseCase.ExecuteOrderItems(userid, orderid)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items})
+}
 
-	pdfFilePath := "salesreport/invoice.pdf"
+// RequestReturn godoc
+// @Summary Request a return for an order item
+// @Description Opens a return for some or all units of a delivered order item, within 7 days of delivery.
+// @ID request-return
+// @Tags User Orders
+// @Produce json
+// @Param orderitemid path int true "Order item ID"
+// @Param quantity formData int true "Number of units to return"
+// @Param reason formData string true "Reason code (damaged, wrong_item, not_as_described, size_issue, quality_issue, no_longer_needed)"
+// @Param comment formData string false "Details about the return"
+// @Param refund formData string false "Refund to 'wallet' or 'original' payment (default original for razorpay and stripe orders, wallet otherwise)"
+// @Success 200 {object} entity.ReturnRequest
+// @Failure 400 {string} string "Bad request"
+// @Router /user/order/return/{orderitemid} [post]
+func (co *OrderHandler) RequestReturn(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userid := userID.(int)
+	orderitemid, err := strconv.Atoi(c.Param("orderitemid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	quantity, err := strconv.Atoi(c.PostForm("quantity"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid quantity"})
+		return
+	}
+	request, err := co.OrderUseCase.ExecuteReturnRequest(userid, orderitemid, quantity, c.PostForm("reason"), c.PostForm("comment"), c.PostForm("refund"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "return requested", "return": request})
+}
+
+// UserReturns godoc
+// @Summary List return requests
+// @Description Retrieves the return requests of the authenticated user, newest first.
+// @ID get-user-returns
+// @Tags User Orders
+// @Produce json
+// @Success 200 {array} entity.ReturnRequest
+// @Failure 400 {string} string "Bad request"
+// @Router /user/order/returns [get]
+func (co *OrderHandler) UserReturns(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userid := userID.(int)
+	returns, err := co.OrderUseCase.ExecuteUserReturns(userid)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"returns": returns})
+}
+
+// AdminReturns godoc
+// @Summary List return requests (Admin)
+// @Description Retrieves return requests, optionally filtered by status (requested, approved, rejected, refunded).
+// @ID admin-get-returns
+// @Tags Admin Orders
+// @Produce json
+// @Param status query string false "Return status"
+// @Param page query int false "Page number for pagination (default is 1)"
+// @Param limit query int false "Number of items per page (default is 5)"
+// @Success 200 {array} entity.ReturnRequest
+// @Failure 400 {string} string "Bad request"
+// @Router /admin/returns [get]
+func (op *OrderHandler) AdminReturns(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	returns, err := op.OrderUseCase.ExecuteAdminReturns(page, limit, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"returns": returns})
+}
+
+// AdminApproveReturn godoc
+// @Summary Approve a return request (Admin)
+// @Description Approves a requested return so the customer can send the items back.
+// @ID admin-approve-return
+// @Tags Admin Orders
+// @Produce json
+// @Param id path int true "Return request ID"
+// @Param remark formData string false "Note for the customer"
+// @Success 200 {object} entity.ReturnRequest
+// @Failure 400 {string} string "Bad request"
+// @Router /admin/returns/{id}/approve [patch]
+func (op *OrderHandler) AdminApproveReturn(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	adminID, _ := c.Get("UserId")
+	adminId := adminID.(int)
+	request, err := op.OrderUseCase.ExecuteApproveReturn(id, adminId, c.PostForm("remark"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "return approved", "return": request})
+}
+
+// AdminRejectReturn godoc
+// @Summary Reject a return request (Admin)
+// @Description Rejects a requested return.
+// @ID admin-reject-return
+// @Tags Admin Orders
+// @Produce json
+// @Param id path int true "Return request ID"
+// @Param remark formData string false "Reason for the rejection"
+// @Success 200 {object} entity.ReturnRequest
+// @Failure 400 {string} string "Bad request"
+// @Router /admin/returns/{id}/reject [patch]
+func (op *OrderHandler) AdminRejectReturn(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	adminID, _ := c.Get("UserId")
+	adminId := adminID.(int)
+	request, err := op.OrderUseCase.ExecuteRejectReturn(id, adminId, c.PostForm("remark"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "return rejected", "return": request})
+}
+
+// AdminReceiveReturn godoc
+// @Summary Receive returned items (Admin)
+// @Description Marks the items of an approved return as received, puts them back in stock and refunds the customer.
+// @ID admin-receive-return
+// @Tags Admin Orders
+// @Produce json
+// @Param id path int true "Return request ID"
+// @Success 200 {object} entity.ReturnRequest
+// @Failure 400 {string} string "Bad request"
+// @Router /admin/returns/{id}/receive [patch]
+func (op *OrderHandler) AdminReceiveReturn(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	adminID, _ := c.Get("UserId")
+	adminId := adminID.(int)
+	request, refunds, err := op.OrderUseCase.ExecuteReceiveReturn(id, adminId)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "return refunded", "return": request, "refunds": refunds})
+}
 
-	err = pdf.OutputFileAndClose(pdfFilePath)
+// UserRefunds godoc
+// @Summary List refunds
+// @Description Retrieves the refunds of the authenticated user's cancellations and returns, newest first.
+// @ID get-user-refunds
+// @Tags User Orders
+// @Produce json
+// @Success 200 {array} entity.Refund
+// @Failure 400 {string} string "Bad request"
+// @Router /user/order/refunds [get]
+func (co *OrderHandler) UserRefunds(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userid := userID.(int)
+	refunds, err := co.OrderUseCase.ExecuteUserRefunds(userid)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.File(pdfFilePath)
+	c.JSON(http.StatusOK, gin.H{"refunds": refunds})
+}
 
-	c.JSON(http.StatusOK, gin.H{"pdf": pdfFilePath})
+// AdminRefunds godoc
+// @Summary List refunds (Admin)
+// @Description Retrieves refunds, optionally filtered by status (pending, processed, failed).
+// @ID admin-get-refunds
+// @Tags Admin Orders
+// @Produce json
+// @Param status query string false "Refund status"
+// @Param page query int false "Page number for pagination (default is 1)"
+// @Param limit query int false "Number of items per page (default is 5)"
+// @Success 200 {array} entity.Refund
+// @Failure 400 {string} string "Bad request"
+// @Router /admin/refunds [get]
+func (op *OrderHandler) AdminRefunds(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	refunds, err := op.OrderUseCase.ExecuteAdminRefunds(page, limit, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"refunds": refunds})
+}
 
+// AdminRetryRefund godoc
+// @Summary Retry a failed refund (Admin)
+// @Description Sends a refund that the payment gateway rejected once more.
+// @ID admin-retry-refund
+// @Tags Admin Orders
+// @Produce json
+// @Param id path int true "Refund ID"
+// @Success 200 {object} entity.Refund
+// @Failure 400 {string} string "Bad request"
+// @Router /admin/refunds/{id}/retry [post]
+func (op *OrderHandler) AdminRetryRefund(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	refund, err := op.OrderUseCase.ExecuteRetryRefund(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "refund": refund})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "refund processed", "refund": refund})
 }
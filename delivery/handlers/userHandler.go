@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"project/delivery/middleware"
 	"project/delivery/models"
 	"project/domain/entity"
+	"project/domain/money"
 	Cartusecase "project/usecase/cart"
 	Productusecase "project/usecase/product"
+	"project/usecase/tax"
 	usecase "project/usecase/user"
 	"strconv"
 
@@ -67,11 +71,12 @@ func (uh *UserHandler) SignupWithOtp(c *gin.Context) {
 func (uh *UserHandler) SignupOtpValidation(c *gin.Context) {
 	key := c.PostForm("key")
 	otp := c.PostForm("otp")
-	err := uh.UserUseCase.ExecuteSignupOtpValidation(key, otp)
+	userId, err := uh.UserUseCase.ExecuteSignupOtpValidation(key, otp)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	} else {
+		uh.mergeGuestCart(c, userId)
 		c.JSON(http.StatusOK, gin.H{"message": "user signup succesfull"})
 	}
 }
@@ -85,7 +90,7 @@ func (uh *UserHandler) SignupOtpValidation(c *gin.Context) {
 // @Produce json
 // @Param phone formData string true "Phone number of the user"
 // @Param password formData string true "User password"
-// @Success 200 {string} string "message: User logged in successfully and cookie stored"
+// @Success 200 {string} string "message: User logged in successfully and cookie stored, tokens: middleware.Tokens"
 // @Failure 400 {string} string "error: Invalid phone number or password"
 // @Router /user/login [post]
 func (uh *UserHandler) LoginWithPassword(c *gin.Context) {
@@ -99,12 +104,31 @@ func (uh *UserHandler) LoginWithPassword(c *gin.Context) {
 		return
 	} else {
 		fmt.Println("userId:", userId)
-		middleware.CreateToken(userId, phone, "user", c)
-		c.JSON(http.StatusOK, gin.H{"message": "user logged in succesfully and cookie stored"})
+		tokens, err := middleware.CreateToken(userId, phone, "user", c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create token"})
+			return
+		}
+		uh.mergeGuestCart(c, userId)
+		c.JSON(http.StatusOK, gin.H{"message": "user logged in succesfully and cookie stored", "tokens": tokens})
 	}
 
 }
 
+// mergeGuestCart moves the cart the shopper built as a guest into their
+// own cart. A failed merge leaves the guest cart for the next login.
+func (uh *UserHandler) mergeGuestCart(c *gin.Context, userId int) {
+	guestId, ok := middleware.GuestId(c)
+	if !ok {
+		return
+	}
+	if err := uh.CartUSeCase.ExecuteMergeGuestCart(guestId, userId); err != nil {
+		log.Printf("merging guest cart into the cart of user %d failed: %v", userId, err)
+		return
+	}
+	middleware.DeleteGuestToken(c)
+}
+
 // Products godoc
 // @Summary Get a list of products
 // @Description Retrieve a list of products with pagination
@@ -186,7 +210,7 @@ func (pd *UserHandler) ProductDetails(c *gin.Context) {
 
 // AddToCart godoc
 // @Summary Add a product to the user's cart
-// @Description Add a product to the user's cart based on the provided product ID and quantity
+// @Description Add a product to the user's cart based on the provided product ID and quantity. Shoppers who are not logged in get a guest cart, kept by a signed guest token and merged into their cart when they log in or sign up.
 // @ID addToCart
 // @Tags User Products
 // @Accept multipart/form-data
@@ -200,15 +224,6 @@ func (pd *UserHandler) ProductDetails(c *gin.Context) {
 // @Failure 500 {string} string "error: Failed to retrieve cart items"
 // @Router /user/cart [post]
 func (ac *UserHandler) AddToCart(c *gin.Context) {
-	userID, exists := c.Get("userId")
-	if !exists || userID == nil {
-		// Handle the case where "userid" is not set in the context or is nil
-		c.JSON(http.StatusBadRequest, gin.H{"error": "userid not found in the context"})
-		return
-	}
-	userid := userID.(int)
-	fmt.Println("userId", userid)
-
 	strid := c.PostForm("productid")
 	strquantity := c.PostForm("quantity")
 	id, err := strconv.Atoi(strid)
@@ -222,12 +237,25 @@ func (ac *UserHandler) AddToCart(c *gin.Context) {
 		return
 	}
 
-	err = ac.CartUSeCase.ExecuteAddToCart(id, quantity, userid)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	var addedProduct []entity.CartItem
+	if userID, exists := c.Get("userId"); exists {
+		userid := userID.(int)
+		if err := ac.CartUSeCase.ExecuteAddToCart(id, quantity, userid); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		addedProduct, err = ac.CartUSeCase.ExecuteCartItems(userid)
+	} else {
+		guestid := c.GetString("guestId")
+		if err := ac.CartUSeCase.ExecuteGuestAddToCart(id, quantity, guestid); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		var items *[]entity.CartItem
+		if items, err = ac.CartUSeCase.ExecuteGuestCartitem(guestid); err == nil {
+			addedProduct = *items
+		}
 	}
-	addedProduct, err := ac.CartUSeCase.ExecuteCartItems(userid)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -248,8 +276,6 @@ func (ac *UserHandler) AddToCart(c *gin.Context) {
 // @Failure 400 {string} string "error: Failed to remove product from cart"
 // @Router /user/cart/{id} [delete]
 func (rc *UserHandler) RemoveFromCart(c *gin.Context) {
-	userID, _ := c.Get("userId")
-	userid := userID.(int)
 	id := c.Param("id")
 
 	Id, err := strconv.Atoi(id)
@@ -257,7 +283,12 @@ func (rc *UserHandler) RemoveFromCart(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "str convertion failed"})
 		return
 	}
-	err1 := rc.CartUSeCase.ExecuteRemoveCartItem(userid, Id)
+	var err1 error
+	if userID, exists := c.Get("userId"); exists {
+		err1 = rc.CartUSeCase.ExecuteRemoveCartItem(userID.(int), Id)
+	} else {
+		err1 = rc.CartUSeCase.ExecuteGuestRemoveCartItem(c.GetString("guestId"), Id)
+	}
 	if err1 != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err1.Error()})
 		return
@@ -267,25 +298,60 @@ func (rc *UserHandler) RemoveFromCart(c *gin.Context) {
 
 // Cart godoc
 // @Summary Get the user's cart
-// @Description Retrieve the user's cart based on the provided user ID
+// @Description Retrieve the user's cart repriced against the current products, offers and stock, with its GST for delivery to the given address, or to the default address. Lines whose price changed or that cannot be sold as they are carry a status; review is set until they are acknowledged. Guests get their guest cart priced as a sale within the seller's state.
 // @ID getCart
 // @Tags User Products
 // @Produce json
-// @Success 200 {string} string "usercart: entity.Cart"
+// @Param addressid query int false "Delivery address ID"
+// @Success 200 {string} string "usercart: entity.Cart, items: []entity.CartItem, review: bool, coupons: coupon.Pricing, tax: tax.Bill"
 // @Failure 400 {string} string "error: userId not found in the context"
 // @Failure 400 {string} string "error: Failed to retrieve user's cart"
 // @Router /user/cart [get]
 func (cu *UserHandler) Cart(c *gin.Context) {
+	addressid := 0
+	if c.Query("addressid") != "" {
+		id, err := strconv.Atoi(c.Query("addressid"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address id"})
+			return
+		}
+		addressid = id
+	}
+	var usercartresponse entity.Cart
+	var view *Cartusecase.CartView
+	var bill *tax.Bill
+	var err error
+	if userID, exists := c.Get("userId"); exists {
+		view, bill, err = cu.CartUSeCase.ExecuteCartTax(userID.(int), addressid)
+	} else {
+		view, bill, err = cu.CartUSeCase.ExecuteGuestCartTax(c.GetString("guestId"))
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	copier.Copy(&usercartresponse, view.Cart)
+	c.JSON(http.StatusOK, gin.H{"usercart": usercartresponse, "items": view.Items, "review": view.Review, "coupons": view.Coupons, "tax": bill})
+}
+
+// AcknowledgeCart godoc
+// @Summary Acknowledge cart changes
+// @Description Accepts the changes flagged on the user's cart: new prices are taken, unavailable lines removed and lines short of stock cut down to what is left.
+// @ID acknowledgeCart
+// @Tags User Products
+// @Produce json
+// @Success 200 {string} string "usercart: entity.Cart, items: []entity.CartItem, coupons: coupon.Pricing"
+// @Failure 400 {string} string "error: Failed to acknowledge the cart"
+// @Router /user/cart/acknowledge [post]
+func (cu *UserHandler) AcknowledgeCart(c *gin.Context) {
 	userID, _ := c.Get("userId")
 	userid := userID.(int)
-	var usercartresponse entity.Cart
-	usercart, err1 := cu.CartUSeCase.ExecuteCart(userid)
-	if err1 != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err1.Error()})
+	view, err := cu.CartUSeCase.ExecuteAcknowledgeCart(userid)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	copier.Copy(&usercartresponse, &usercart)
-	c.JSON(http.StatusOK, gin.H{"usercart": usercartresponse})
+	c.JSON(http.StatusOK, gin.H{"message": "cart changes acknowledged", "usercart": view.Cart, "items": view.Items, "coupons": view.Coupons})
 }
 
 // AddToWishList handles the endpoint to add a product to the user's wishlist.
@@ -373,17 +439,63 @@ func (cu *UserHandler) ViewWishlist(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": wishlist})
 }
 
+// RefreshToken godoc
+// @Summary Renew the user's login
+// @Description Exchanges a refresh token for a new access token and a new refresh token. Bearer clients send it as refresh_token in the body and get the new tokens back; browsers use the Refresh cookie and the X-CSRF-Token header. A refresh token can only be used once; using it again ends the session.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Success 200 {string} string "message: token refreshed, tokens: middleware.Tokens"
+// @Failure 401 {string} string "error: invalid token"
+// @Failure 403 {string} string "error: missing or invalid csrf token"
+// @Router /user/token/refresh [post]
+func (uh *UserHandler) RefreshToken(c *gin.Context) {
+	tokens, err := middleware.RefreshToken(c, "user")
+	if errors.Is(err, middleware.ErrCSRF) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if tokens != nil {
+		c.JSON(http.StatusOK, gin.H{"message": "token refreshed", "tokens": tokens})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "token refreshed"})
+}
+
+// LogoutAll godoc
+// @Summary Log the user out of all devices
+// @Description Ends every session of the user, on every device.
+// @Tags User
+// @Produce json
+// @Success 200 {string} string "message: logged out of all devices"
+// @Failure 500 {string} string "error: logout failed"
+// @Router /user/logout/all [post]
+func (uh *UserHandler) LogoutAll(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	if err := middleware.DeleteAllTokens(c, userID.(int), "user"); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "logout failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out of all devices"})
+}
+
 // Logout godoc
 // @Summary Logs out the user
-// @Description Deletes the authentication token cookie to log the user out
+// @Description Revokes the user's tokens and deletes their cookies to log the user out
 // @Tags User
 // @Produce json
 // @Success 200 {string} string "user logged out successfully"
 // @Failure 400 {string} string "cookie delete failed"
 // @Router /user/logout [post]
 func (cu *UserHandler) Logout(c *gin.Context) {
-	err := middleware.DeleteToken(c)
-	if err != nil {
+	err := middleware.DeleteToken(c, "user")
+	if errors.Is(err, middleware.ErrCSRF) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"errror": "cookie delete failed"})
 		return
 	} else {
@@ -393,7 +505,7 @@ func (cu *UserHandler) Logout(c *gin.Context) {
 
 // AddAddress godoc
 // @Summary Adds a new address for the user
-// @Description Adds a new address associated with the authenticated user
+// @Description Adds a new address associated with the authenticated user. The first address, or one sent with isdefault, becomes the default.
 // @Produce json
 // @Tags User Address
 // @Param address body entity.UserAddress true "Address information to be added"
@@ -409,20 +521,58 @@ func (cu *UserHandler) AddAddress(c *gin.Context) {
 		return
 	}
 	address.User_id = userid
-	err1:=cu.UserUseCase.CheckAddress(userid,address.Type)
-	if err1 != nil{
-		c.JSON(http.StatusBadRequest,gin.H{"error":err1.Error()})
-		return
-	}
 	err:= cu.UserUseCase.ExecuteAddAddress(&address)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	} else {
-		c.JSON(http.StatusOK, gin.H{"message": "address added succesfully"})
+		c.JSON(http.StatusOK, gin.H{"message": "address added succesfully", "address": address})
 	}
 }
 
+// Addresses godoc
+// @Summary List the user's addresses
+// @Description Lists every address in the authenticated user's address book, the default one first
+// @Produce json
+// @Tags User Address
+// @Success 200 {array} entity.UserAddress "addresses"
+// @Failure 400 {string} string "Bad Request"
+// @Router /user/address [get]
+func (cu *UserHandler) Addresses(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userid := userID.(int)
+	addresses, err := cu.UserUseCase.ExecuteAddresses(userid)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"addresses": addresses})
+}
+
+// SetDefaultAddress godoc
+// @Summary Set the default address
+// @Description Makes the address the user's default delivery address
+// @Produce json
+// @Tags User Address
+// @Param id path int true "Address ID"
+// @Success 200 {string} string "default address updated"
+// @Failure 400 {string} string "Bad Request"
+// @Router /user/address/{id}/default [patch]
+func (cu *UserHandler) SetDefaultAddress(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userid := userID.(int)
+	addressid, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "strng conversion failed"})
+		return
+	}
+	if err := cu.UserUseCase.ExecuteSetDefaultAddress(userid, addressid); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "default address updated"})
+}
+
 // ShowUserDetails godoc
 // @Summary Retrieve details for the authenticated user
 // @Description Retrieve user details and associated address based on the provided user ID
@@ -534,10 +684,13 @@ func (cp *UserHandler) OtpValidationPassword(c *gin.Context) {
 //	@Failure 400 {string} string "error: Bad Request"
 //	@Router /user/cartlist [get]
 func (cl *UserHandler) CartItems(c *gin.Context) {
-
-	userID, _ := c.Get("userId")
-	userid := userID.(int)
-	cartitems, err := cl.CartUSeCase.ExecuteCartitem(userid)
+	var cartitems *[]entity.CartItem
+	var err error
+	if userID, exists := c.Get("userId"); exists {
+		cartitems, err = cl.CartUSeCase.ExecuteCartitem(userID.(int))
+	} else {
+		cartitems, err = cl.CartUSeCase.ExecuteGuestCartitem(c.GetString("guestId"))
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -548,14 +701,14 @@ func (cl *UserHandler) CartItems(c *gin.Context) {
 // EditAddress godoc
 //
 //		@Summary Edit the user's address
-//		@Description Edit the user's address of a specific type (e.g., home, work)
+//		@Description Edit one of the user's addresses. Orders already placed keep the address they were placed with.
 //		@Produce json
 //	 @Tags User Address
-//		@Param type path string true "Address type (e.g., home, work)"
+//		@Param id path int true "Address ID"
 //		@Param useraddress body entity.UserAddress true "Updated address information"
 //		@Success 200 {string} string "success: address edited successfully"
 //		@Failure 400 {string} string "error: Bad Request"
-//		@Router /user/address/{type} [patch]
+//		@Router /user/address/{id} [patch]
 func (ea *UserHandler) EditAddress(c *gin.Context) {
 	var useraddress entity.UserAddress
 	if err := c.ShouldBindJSON(&useraddress); err != nil {
@@ -564,8 +717,12 @@ func (ea *UserHandler) EditAddress(c *gin.Context) {
 	}
 	userID, _ := c.Get("userId")
 	userid := userID.(int)
-	addresstype := c.Param("type")
-	err := ea.UserUseCase.ExecuteEditAddress(useraddress, userid, addresstype)
+	addressid, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "strng conversion failed"})
+		return
+	}
+	err = ea.UserUseCase.ExecuteEditAddress(useraddress, userid, addressid)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -574,19 +731,23 @@ func (ea *UserHandler) EditAddress(c *gin.Context) {
 }
 
 // @Summary Delete user address
-// @Description Deletes a specific type of address for the authenticated user.
+// @Description Deletes one of the authenticated user's addresses. If it was the default, the oldest remaining address becomes the default.
 // @ID delete-user-address
 // @Tags User Address
 // @Produce json
-// @Param type path string true "Type of address to be deleted (e.g., 'home', 'work')"
+// @Param id path int true "ID of the address to be deleted"
 // @Success 200 {object} string "success": "address Deleted successfully" "Successful response"
 // @Failure 400 {object} string "error": "Error message" "Error response"
-// @Router /user/address/{type} [delete]
+// @Router /user/address/{id} [delete]
 func (da *UserHandler) DeleteAddress(c *gin.Context) {
 	userID, _ := c.Get("userId")
 	userid := userID.(int)
-	addresstype := c.Param("type")
-	err := da.UserUseCase.ExecuteDeleteAddress(userid, addresstype)
+	addressid, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "strng conversion failed"})
+		return
+	}
+	err = da.UserUseCase.ExecuteDeleteAddress(userid, addressid)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -681,8 +842,8 @@ func (sc *UserHandler) SortByCategory(c *gin.Context) {
 // @ID sort-products-by-filter
 // @Tags User Sort
 // @Produce json
-// @Param minprize query int false "Minimum prize for product filtering"
-// @Param maxprize query int false "Maximum prize for product filtering"
+// @Param minprize query number false "Minimum prize in rupees for product filtering"
+// @Param maxprize query number false "Maximum prize in rupees for product filtering"
 // @Param category query int false "Category ID for product filtering"
 // @Param size query string false "Product size for filtering"
 // @Success 200 {string} string "Product list retrieved successfully"
@@ -693,8 +854,21 @@ func (sc *UserHandler) SortByFilter(c *gin.Context) {
 	strmaxPrize := c.Query("maxprize")
 	strcategory := c.Query("category")
 	size := c.Query("size")
-	minPrize, _ := strconv.Atoi(strminPrize)
-	maxPrize, _ := strconv.Atoi(strmaxPrize)
+	// A bound left out is no bound.
+	var minPrize, maxPrize money.Money
+	var err error
+	if strminPrize != "" {
+		if minPrize, err = money.Parse(strminPrize); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid minprize"})
+			return
+		}
+	}
+	if strmaxPrize != "" {
+		if maxPrize, err = money.Parse(strmaxPrize); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid maxprize"})
+			return
+		}
+	}
 	category, _ := strconv.Atoi(strcategory)
 	productlist, err1 := sc.ProductUseCase.ExecuteProductFilter(size, minPrize, maxPrize, category)
 	if err1 != nil {
@@ -706,13 +880,13 @@ func (sc *UserHandler) SortByFilter(c *gin.Context) {
 
 // ApplyCoupon godoc
 // @Summary Apply coupon to user's cart
-// @Description Applies a coupon to the authenticated user's cart based on the provided coupon code.
+// @Description Applies a coupon to the authenticated user's cart based on the provided coupon code. Stackable coupons can be combined.
 // @ID apply-coupon
 // @Accept multipart/form-data
 // @Tags User Coupon
 // @Produce json
 // @Param code formData string true "Coupon code to be applied"
-// @Success 200 {string} string "Total offer prize and Coupon applied successfully"
+// @Success 200 {string} string "offer prize: total discount, coupons: coupon.Pricing"
 // @Failure 400 {string} string "Bad request"
 // @Router /user/cart/coupon [post]
 func (sc *UserHandler) ApplyCoupon(c *gin.Context) {
@@ -720,12 +894,35 @@ func (sc *UserHandler) ApplyCoupon(c *gin.Context) {
 	userid := userID.(int)
 	code := c.PostForm("code")
 
-	totaloffer, err := sc.CartUSeCase.ExecuteApplyCoupon(userid, code)
+	pricing, err := sc.CartUSeCase.ExecuteApplyCoupon(userid, code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"offer prize": pricing.Discount, "coupons": pricing, "offer": "applied succesfully"})
+}
+
+// RemoveCoupon godoc
+// @Summary Remove coupon from user's cart
+// @Description Takes a coupon applied to the authenticated user's cart off it.
+// @ID remove-coupon
+// @Tags User Coupon
+// @Produce json
+// @Param code query string true "Coupon code to be removed"
+// @Success 200 {string} string "offer prize: total discount, coupons: coupon.Pricing"
+// @Failure 400 {string} string "Bad request"
+// @Router /user/cart/coupon [delete]
+func (sc *UserHandler) RemoveCoupon(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userid := userID.(int)
+	code := c.Query("code")
+
+	pricing, err := sc.CartUSeCase.ExecuteRemoveCoupon(userid, code)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"offer prize": totaloffer, "offer": "applied succesfully"})
+	c.JSON(http.StatusOK, gin.H{"offer prize": pricing.Discount, "coupons": pricing, "offer": "removed succesfully"})
 }
 
 // AvailableCoupons godoc
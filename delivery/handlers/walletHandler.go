@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"project/domain/money"
+	usecase "project/usecase/wallet"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WalletHandler struct {
+	WalletUseCase *usecase.WalletUseCase
+}
+
+func NewWalletHandler(WalletUseCase *usecase.WalletUseCase) *WalletHandler {
+	return &WalletHandler{WalletUseCase}
+}
+
+// Wallet godoc
+// @Summary Wallet balance and history
+// @Description Retrieves the wallet balance and the ledger entries of the authenticated user, newest first.
+// @ID get-wallet
+// @Tags User Wallet
+// @Produce json
+// @Param page query int false "Page number for pagination (default is 1)"
+// @Param limit query int false "Number of items per page (default is 10)"
+// @Success 200 {array} entity.WalletTransaction
+// @Failure 400 {string} string "Bad request"
+// @Router /user/wallet [get]
+func (wh *WalletHandler) Wallet(c *gin.Context) {
+	userID, _ := c.Get("userId")
+	userid := userID.(int)
+	wh.history(c, userid)
+}
+
+// AdminUserWallet godoc
+// @Summary Wallet balance and history of a user (Admin)
+// @Description Retrieves the wallet balance and the ledger entries of a user, newest first.
+// @ID admin-get-wallet
+// @Tags Admin Wallet
+// @Produce json
+// @Param userid path int true "User ID"
+// @Param page query int false "Page number for pagination (default is 1)"
+// @Param limit query int false "Number of items per page (default is 10)"
+// @Success 200 {array} entity.WalletTransaction
+// @Failure 400 {string} string "Bad request"
+// @Router /admin/wallet/{userid} [get]
+func (wh *WalletHandler) AdminUserWallet(c *gin.Context) {
+	userid, err := strconv.Atoi(c.Param("userid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	wh.history(c, userid)
+}
+
+func (wh *WalletHandler) history(c *gin.Context, userid int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	balance, held, txns, err := wh.WalletUseCase.ExecuteWalletHistory(userid, page, limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"balance": balance, "held": held, "transactions": txns})
+}
+
+// AdminAdjustWallet godoc
+// @Summary Adjust a user's wallet (Admin)
+// @Description Credits a positive amount to or debits a negative amount from a user's wallet.
+// @ID admin-adjust-wallet
+// @Tags Admin Wallet
+// @Produce json
+// @Param userid path int true "User ID"
+// @Param amount formData number true "Amount in rupees to credit (positive) or debit (negative)"
+// @Param remark formData string true "Reason for the adjustment"
+// @Success 200 {object} entity.WalletTransaction
+// @Failure 400 {string} string "Bad request"
+// @Router /admin/wallet/{userid}/adjust [post]
+func (wh *WalletHandler) AdminAdjustWallet(c *gin.Context) {
+	userid, err := strconv.Atoi(c.Param("userid"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "string convertion failed"})
+		return
+	}
+	amount, err := money.Parse(c.PostForm("amount"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount"})
+		return
+	}
+	adminID, _ := c.Get("UserId")
+	adminId := adminID.(int)
+	txn, err := wh.WalletUseCase.ExecuteAdminAdjustment(adminId, userid, amount, c.PostForm("remark"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "wallet adjusted", "transaction": txn})
+}
+
+// AdminReconcileWallets godoc
+// @Summary Reconcile wallets against the ledger (Admin)
+// @Description Finds users whose stored wallet balance differs from their ledger and corrects them. Balances that predate the ledger are recorded as opening balances.
+// @ID admin-reconcile-wallets
+// @Tags Admin Wallet
+// @Produce json
+// @Success 200 {array} entity.WalletDiscrepancy
+// @Failure 400 {string} string "Bad request"
+// @Router /admin/wallet/reconcile [post]
+func (wh *WalletHandler) AdminReconcileWallets(c *gin.Context) {
+	discrepancies, err := wh.WalletUseCase.ExecuteReconcile()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"reconciled": discrepancies})
+}
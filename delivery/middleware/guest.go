@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+// guestCookie holds the signed token of an anonymous shopper's cart.
+const guestCookie = "Guest"
+
+// guestTokenAge is how long a guest cart is kept for, in seconds.
+const guestTokenAge = 30 * 24 * 3600
+
+var guestSecret []byte
+
+// SetGuestSecret sets the key guest tokens are signed with. Without one a
+// random key is used, so guest carts do not survive a restart.
+func SetGuestSecret(secret string) {
+	if secret != "" {
+		guestSecret = []byte(secret)
+		return
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatalf("generating guest token key failed: %v", err)
+	}
+	log.Println("GUESTSECRET not set, guest carts will not survive a restart")
+	guestSecret = key
+}
+
+// UserOrGuest lets logged in users and anonymous shoppers through. A user
+// gets "userId" set as with UserRetreiveCookie; anyone else gets "guestId"
+// from their guest token, which is issued on their first visit.
+func UserOrGuest(c *gin.Context) {
+	if ValidToken(c) {
+		userId, phone, role, err := RetreiveToken(c)
+		if errors.Is(err, ErrCSRF) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if err == nil && role == "user" {
+			c.Set("userId", userId)
+			c.Set("phonenumber", phone)
+			c.Next()
+			return
+		}
+	}
+	guestId, ok := GuestId(c)
+	if !ok {
+		var err error
+		if guestId, err = createGuestToken(c); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create guest token"})
+			c.Abort()
+			return
+		}
+	}
+	c.Set("guestId", guestId)
+	c.Next()
+}
+
+func createGuestToken(c *gin.Context) (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	guestId := hex.EncodeToString(id)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"guestId": guestId,
+		"role":    "guest",
+		"exp":     time.Now().Add(guestTokenAge * time.Second).Unix(),
+	})
+	tokenstring, err := token.SignedString(guestSecret)
+	if err != nil {
+		return "", err
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(guestCookie, tokenstring, guestTokenAge, "", "", false, true)
+	return guestId, nil
+}
+
+// GuestId returns the guest id from the request's guest token, if it
+// carries a valid one.
+func GuestId(c *gin.Context) (string, bool) {
+	cookie, _ := c.Cookie(guestCookie)
+	if cookie == "" {
+		return "", false
+	}
+	token, err := jwt.Parse(cookie, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return guestSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return "", false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["role"] != "guest" {
+		return "", false
+	}
+	guestId, ok := claims["guestId"].(string)
+	return guestId, ok && guestId != ""
+}
+
+// DeleteGuestToken drops the guest token once its cart has been merged.
+func DeleteGuestToken(c *gin.Context) {
+	c.SetCookie(guestCookie, "", -1, "", "", false, true)
+}
@@ -2,15 +2,13 @@ package middleware
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
+	"project/usecase/auth"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
 )
 
-var secretkey = []byte("123455666")
-
 func UserRetreiveCookie(c *gin.Context) {
 	valid := ValidToken(c)
 
@@ -19,9 +17,14 @@ func UserRetreiveCookie(c *gin.Context) {
 		c.Abort()
 	} else {
 		userId, phone, role, err := RetreiveToken(c)
-		if err != nil {
+		if errors.Is(err, ErrCSRF) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		} else if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "occured while rereiving"})
 			c.Abort()
+			return
 		} else {
 			
 			c.Set("userId", userId)
@@ -44,9 +47,14 @@ func AdminRetreiveToken(c *gin.Context) {
 		c.Abort()
 	} else {
 		Userid, Phone, role, err := RetreiveToken(c)
-		if err != nil {
+		if errors.Is(err, ErrCSRF) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		} else if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "unable to retreive token"})
 			c.Abort()
+			return
 		} else {
 			c.Set("UserId", Userid)
 			c.Set("Phonenumber", Phone)
@@ -60,94 +68,156 @@ func AdminRetreiveToken(c *gin.Context) {
 	}
 }
 
-func CreateToken(userId int, useremail string, role string, c *gin.Context) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"userId": userId,
-		"email":  useremail,
-		"role":   role,
-	})
-	tokenstring, err := token.SignedString([]byte("12345678"))
+// refreshCookie holds the refresh token that renews the access token in
+// the Authorise cookie. The cookies of a role are scoped to its routes,
+// /user or /admin, so a browser can hold both sessions.
+const refreshCookie = "Refresh"
+
+var sessions *auth.Sessions
+
+// SetSessions sets what issues and checks the login tokens.
+func SetSessions(s *auth.Sessions) {
+	sessions = s
+}
+
+// Tokens are a session's tokens as handed to clients that send them in
+// the Authorization header instead of cookies. CSRFToken goes with the
+// cookies, for browser clients.
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	CSRFToken    string `json:"csrf_token,omitempty"`
+}
 
-	if err == nil {
-		fmt.Println("token created")
+// CreateToken starts a session. Its tokens are set in the Authorise and
+// Refresh cookies and returned for bearer clients.
+func CreateToken(userId int, useremail string, role string, c *gin.Context) (*Tokens, error) {
+	access, refresh, err := sessions.Issue(userId, useremail, role)
+	if err != nil {
+		return nil, err
 	}
+	return setTokenCookies(c, role, access, refresh), nil
+}
 
+// RefreshToken renews the session of the role. Bearer clients send the
+// refresh token as refresh_token in a JSON body and get the new tokens
+// back; browsers have it read from the Refresh cookie and the cookies
+// replaced.
+func RefreshToken(c *gin.Context, role string) (*Tokens, error) {
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	c.ShouldBindJSON(&body)
+	refresh, fromCookie := body.RefreshToken, false
+	if refresh == "" {
+		refresh, _ = c.Cookie(refreshCookie)
+		fromCookie = true
+	}
+	if refresh == "" {
+		return nil, errors.New("refresh token not found")
+	}
+	if fromCookie && !csrfValid(c) {
+		return nil, ErrCSRF
+	}
+	access, next, err := sessions.Refresh(refresh, role)
+	if err != nil {
+		return nil, err
+	}
+	if fromCookie {
+		setTokenCookies(c, role, access, next)
+		return nil, nil
+	}
+	return newTokens(access, next), nil
+}
+
+func newTokens(access, refresh string) *Tokens {
+	return &Tokens{AccessToken: access, RefreshToken: refresh, TokenType: "Bearer", ExpiresIn: int(sessions.AccessTTL().Seconds())}
+}
+
+func setTokenCookies(c *gin.Context, role, access, refresh string) *Tokens {
+	path := "/" + role
 	c.SetSameSite(http.SameSiteLaxMode)
-	c.SetCookie("Authorise", tokenstring, 3600, "", "", false, true)
+	c.SetCookie("Authorise", access, int(sessions.AccessTTL().Seconds()), path, "", false, true)
+	c.SetCookie(refreshCookie, refresh, int(sessions.RefreshTTL().Seconds()), path, "", false, true)
+	tokens := newTokens(access, refresh)
+	tokens.CSRFToken = setCSRFCookie(c, path, int(sessions.RefreshTTL().Seconds()))
+	return tokens
 }
 
-func ValidToken(c *gin.Context) bool {
-	cookie, _ := c.Cookie("Authorise")
-	if cookie == "" {
-		fmt.Println("cookie not found")
-		return false
-	} else {
-		return true
+// accessToken returns the request's access token, from the Authorization
+// header or else the Authorise cookie, and whether it came from the cookie.
+func accessToken(c *gin.Context) (string, bool) {
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")), false
 	}
+	cookie, _ := c.Cookie("Authorise")
+	return cookie, true
 }
+
+func ValidToken(c *gin.Context) bool {
+	token, _ := accessToken(c)
+	return token != ""
+}
+
+// RetreiveToken checks the request's access token. Changes made with the
+// Authorise cookie need the CSRF token as well.
 func RetreiveToken(c *gin.Context) (int, int, string, error) {
-	cookie, _ := c.Cookie("Authorise")
-	
-	if cookie == "" {
+	token, fromCookie := accessToken(c)
+	if token == "" {
 		return 0, 0, "", errors.New("cookie not found")
-	} else {
-		token, err := jwt.Parse(cookie, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte("12345678"), nil
-		})
-		if err != nil {
-			return 0, 0, "", err
-		}
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			
-			var userId, userPhone int
-			var role string
-
-			// for key, value := range claims {
-			// 	switch key {
-			// 	case "userid":
-			// 		if id, ok := value.(float64); ok {
-			// 			userId = int(id)
-			// 		}
-			// 	case "phone":
-			// 		if phone, ok := value.(float64); ok {
-			// 			userPhone = int(phone)
-			// 		}
-			// 	case "role":
-			// 		if r, ok := value.(string); ok {
-			// 			role = r
-			// 		}
-			// 	}
-			// }
-			if id, exists := claims["userId"]; exists {
-				if idFloat, ok := id.(float64); ok {
-					userId = int(idFloat)
-				}
-			}
-
-			if phone, exists := claims["phone"]; exists {
-				if phoneFloat, ok := phone.(float64); ok {
-					userPhone = int(phoneFloat)
-				}
-			}
-
-			if r, exists := claims["role"]; exists {
-				if roleString, ok := r.(string); ok {
-					role = roleString
-				}
-			}
-			
-			return userId, userPhone, role, nil
-		} else {
-			return 0, 0, "", fmt.Errorf("invalid token")
+	}
+	if fromCookie && !csrfValid(c) {
+		return 0, 0, "", ErrCSRF
+	}
+	claims, err := sessions.Validate(token)
+	if err != nil {
+		return 0, 0, "", err
+	}
+	return claims.UserId, 0, claims.Role, nil
+}
+
+// DeleteToken ends the session of the request's tokens and clears the
+// cookies of the role. Bearer clients can send their refresh token as
+// refresh_token in a JSON body to have it revoked too.
+func DeleteToken(c *gin.Context, role string) error {
+	access, fromCookie := accessToken(c)
+	refresh, _ := c.Cookie(refreshCookie)
+	if !fromCookie {
+		var body struct {
+			RefreshToken string `json:"refresh_token"`
 		}
+		c.ShouldBindJSON(&body)
+		refresh = body.RefreshToken
+	} else if (access != "" || refresh != "") && !csrfValid(c) {
+		return ErrCSRF
+	}
+	if err := sessions.Revoke(access, refresh); err != nil {
+		return err
 	}
+	clearTokenCookies(c, role)
+	return nil
 }
 
-func DeleteToken(c *gin.Context) error {
-	c.SetCookie("Authorise", "", 0, "", "", true, true)
-	fmt.Println("cookie deleted")
+// DeleteAllTokens ends every session of the user or admin, on all their
+// devices, and clears the cookies of the role.
+func DeleteAllTokens(c *gin.Context, userId int, role string) error {
+	if err := EndSessions(userId, role); err != nil {
+		return err
+	}
+	clearTokenCookies(c, role)
 	return nil
 }
+
+// EndSessions ends every session of the user or admin.
+func EndSessions(userId int, role string) error {
+	return sessions.RevokeAll(userId, role)
+}
+
+func clearTokenCookies(c *gin.Context, role string) {
+	path := "/" + role
+	c.SetCookie("Authorise", "", -1, path, "", false, true)
+	c.SetCookie(refreshCookie, "", -1, path, "", false, true)
+	clearCSRFCookie(c, path)
+}
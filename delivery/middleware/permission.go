@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionChecker tells whether an admin may use a permission.
+type PermissionChecker interface {
+	HasPermission(adminId int, permission string) (bool, error)
+}
+
+var permissions PermissionChecker
+
+// SetPermissionChecker sets what AdminPermission checks admins against.
+func SetPermissionChecker(checker PermissionChecker) {
+	permissions = checker
+}
+
+// AdminPermission lets through admins whose role grants the permission.
+// It goes after AdminRetreiveToken, which sets the admin's id.
+func AdminPermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminId, ok := c.Get("UserId")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "admin dont have a token"})
+			c.Abort()
+			return
+		}
+		if permissions == nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "permissions are not configured"})
+			c.Abort()
+			return
+		}
+		allowed, err := permissions.HasPermission(adminId.(int), permission)
+		if err != nil {
+			log.Printf("checking permission %s of admin %v failed: %v", permission, adminId, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permission"})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "permission denied: " + permission})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
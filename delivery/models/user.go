@@ -1,5 +1,7 @@
 package models
 
+import "project/domain/money"
+
 type EditUser struct {
 	Name  string `json:"name" binding:"required" `
 	Email string `json:"email" binding:"required"`
@@ -30,8 +32,8 @@ type CombinedOrderDetails struct {
 type ProductWithQuantityResponse struct {
 	ID         int    `json:"id" gorm:"column:id"`
 	Name       string `json:"name"`
-	Price      int    `json:"price"`
-	OfferPrize int    `json:"offerprice"  `
+	Price      money.Money `json:"price"`
+	OfferPrize money.Money `json:"offerprice"  `
 	Size       string `json:"size"`
 	Category   int    `json:"category"`
 	ImageURL   string `json:"image_url"`
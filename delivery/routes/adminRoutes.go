@@ -4,41 +4,54 @@ import (
 	"project/delivery/handlers"
 
 	m "project/delivery/middleware"
+	"project/domain/entity"
 
 	"github.com/gin-gonic/gin"
 )
 
 func AdminRouter(r *gin.Engine, adminHandler *handlers.AdminHandler) *gin.Engine {
 	r.POST("/admin/login", adminHandler.AdminLoginWithPassword)
-	r.GET("/admin/home", m.AdminRetreiveToken, adminHandler.Home)
-
-	r.GET("admin/users", m.AdminRetreiveToken, adminHandler.UsersList)
-	r.PUT("/admin/users/toggle-permission/:id", m.AdminRetreiveToken, adminHandler.TogglePermission)
-	r.GET("admin/search/users", m.AdminRetreiveToken, adminHandler.SearchUsers)
-
-	r.GET("/admin/categories", m.AdminRetreiveToken, adminHandler.AllCategory)
-	r.POST("/admin/categories", m.AdminRetreiveToken, adminHandler.CreateCategory)
-	r.PUT("/admin/categories/:id", m.AdminRetreiveToken, adminHandler.EditCategory)
-	r.DELETE("/admin/categories/:id", m.AdminRetreiveToken, adminHandler.DeleteCategory)
-
-	r.GET("/admin/products", m.AdminRetreiveToken, adminHandler.AdminProductlist)
-	r.POST("/admin/products", m.AdminRetreiveToken, adminHandler.CreateProduct)
-	r.PUT("/admin/products/stocks/:id", m.AdminRetreiveToken, adminHandler.AddStock)
-
-	r.PATCH("/admin/products/:id", m.AdminRetreiveToken, adminHandler.EditProduct)
-	r.DELETE("admin/products/:id", m.AdminRetreiveToken, adminHandler.DeleteProduct)
-
-	r.POST("/admin/coupons", m.AdminRetreiveToken, adminHandler.AddCoupon)
-	r.GET("/admin/coupons", m.AdminRetreiveToken, adminHandler.AllCoupons)
-	r.DELETE("/admin/coupons", m.AdminRetreiveToken, adminHandler.DeleteCoupon)
-
-	// r.POST("/admin/offer", m.AdminRetreiveToken, adminHandler.AddOffer)
-	// r.GET("/admin/offer", m.AdminRetreiveToken, adminHandler.AllOffer)
-
-	r.GET("/admin/stockless/products", m.AdminRetreiveToken, adminHandler.StocklessProducts)
-
-	r.POST("/admin/product/offer", m.AdminRetreiveToken, adminHandler.AddProductOffer)
-	r.POST("/admin/category/offer", m.AdminRetreiveToken, adminHandler.AddCategoryOffer)
+	r.POST("/admin/setup-password", adminHandler.SetupAdminPassword)
+	r.GET("/admin/home", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionReports), adminHandler.Home)
+
+	r.GET("/admin/admins", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionAdmins), adminHandler.Admins)
+	r.POST("/admin/admins", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionAdmins), adminHandler.InviteAdmin)
+	r.PATCH("/admin/admins/:id/deactivate", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionAdmins), adminHandler.DeactivateAdmin)
+	r.POST("/admin/admins/:id/reset", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionAdmins), adminHandler.ResetAdmin)
+	r.GET("/admin/roles", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionAdmins), adminHandler.Roles)
+	r.PUT("/admin/roles/:role", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionAdmins), adminHandler.SetRolePermissions)
+	r.DELETE("/admin/roles/:role", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionAdmins), adminHandler.DeleteRole)
+
+	r.GET("admin/users", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionUsers), adminHandler.UsersList)
+	r.PUT("/admin/users/toggle-permission/:id", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionUsers), adminHandler.TogglePermission)
+	r.GET("admin/search/users", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionUsers), adminHandler.SearchUsers)
+
+	r.GET("/admin/categories", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionCatalog), adminHandler.AllCategory)
+	r.POST("/admin/categories", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionCatalog), adminHandler.CreateCategory)
+	r.PUT("/admin/categories/:id", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionCatalog), adminHandler.EditCategory)
+	r.DELETE("/admin/categories/:id", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionCatalog), adminHandler.DeleteCategory)
+
+	r.GET("/admin/products", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionCatalog), adminHandler.AdminProductlist)
+	r.POST("/admin/products", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionCatalog), adminHandler.CreateProduct)
+	r.PUT("/admin/products/stocks/:id", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionCatalog), adminHandler.AddStock)
+
+	r.PATCH("/admin/products/:id", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionCatalog), adminHandler.EditProduct)
+	r.DELETE("admin/products/:id", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionCatalog), adminHandler.DeleteProduct)
+
+	r.POST("/admin/coupons", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionPromotions), adminHandler.AddCoupon)
+	r.GET("/admin/coupons", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionPromotions), adminHandler.AllCoupons)
+	r.DELETE("/admin/coupons", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionPromotions), adminHandler.DeleteCoupon)
+
+	r.POST("/admin/offer", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionPromotions), adminHandler.AddOffer)
+	r.GET("/admin/offer", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionPromotions), adminHandler.AllOffer)
+	r.DELETE("/admin/offer/:id", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionPromotions), adminHandler.CancelOffer)
+
+	r.GET("/admin/stockless/products", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionCatalog), adminHandler.StocklessProducts)
+
+	r.POST("/admin/product/offer", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionPromotions), adminHandler.AddProductOffer)
+	r.POST("/admin/category/offer", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionPromotions), adminHandler.AddCategoryOffer)
+	r.POST("/admin/token/refresh", adminHandler.RefreshToken)
 	r.POST("/admin/logout", adminHandler.Logout)
+	r.POST("/admin/logout/all", m.AdminRetreiveToken, adminHandler.LogoutAll)
 	return r
 }
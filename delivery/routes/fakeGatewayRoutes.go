@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"net/http"
+	"project/domain/payment"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FakeGatewayRouter exposes the customer side of fake gateways under
+// /fakegateway/{provider}, so payments can be made without a provider's
+// checkout page. Real gateways are skipped.
+func FakeGatewayRouter(r *gin.Engine, gateways ...payment.PaymentGateway) *gin.Engine {
+	for _, gateway := range gateways {
+		fake, ok := gateway.(*payment.FakeGateway)
+		if !ok {
+			continue
+		}
+		prefix := "/fakegateway/" + fake.Name()
+		r.POST(prefix+"/*action", gin.WrapH(http.StripPrefix(prefix, fake.Handler())))
+	}
+	return r
+}
@@ -3,29 +3,42 @@ package routes
 import (
 	"project/delivery/handlers"
 	m "project/delivery/middleware"
+	"project/domain/entity"
 
 	"github.com/gin-gonic/gin"
 )
 
 func OrderRouter(r *gin.Engine, orderHandler *handlers.OrderHandler) *gin.Engine {
 	r.POST("/user/order/place/:addressid/:payment", m.UserRetreiveCookie, orderHandler.PlaceOrder)
-	r.GET("/user/order/place/:addressid/:payment", m.UserRetreiveCookie, orderHandler.PlaceOrder)
 	r.POST("/user/payment/verify", m.UserRetreiveCookie, orderHandler.PaymentVerification)
 	r.GET("/user/order/history", m.UserRetreiveCookie, orderHandler.OrderHistory)
 	r.PATCH("/user/order/cancel/:orderid", m.UserRetreiveCookie, orderHandler.CancelOrder)
+	r.GET("/user/order/timeline/:orderid", m.UserRetreiveCookie, orderHandler.OrderTimeline)
+	r.GET("/user/order/items/:orderid", m.UserRetreiveCookie, orderHandler.OrderItems)
+	r.POST("/user/order/return/:orderitemid", m.UserRetreiveCookie, orderHandler.RequestReturn)
+	r.GET("/user/order/returns", m.UserRetreiveCookie, orderHandler.UserReturns)
+	r.GET("/user/order/refunds", m.UserRetreiveCookie, orderHandler.UserRefunds)
 
-	r.PATCH("/admin/order/update/:orderid", m.AdminRetreiveToken, orderHandler.AdminOrderUpdate)
-	r.GET("/admin/order/details", m.AdminRetreiveToken, orderHandler.AdminOrderDetails)
-	r.PATCH("/admin/order/cancel/:orderid", m.AdminRetreiveToken, orderHandler.AdminCancelOrder)
+	r.PATCH("/admin/order/update/:orderid", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionOrders), orderHandler.AdminOrderUpdate)
+	r.GET("/admin/order/details", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionOrders), orderHandler.AdminOrderDetails)
+	r.PATCH("/admin/order/cancel/:orderid", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionOrders), orderHandler.AdminCancelOrder)
 
-	r.GET("/admin/salesreport/period/:period", m.AdminRetreiveToken, orderHandler.SalesReportByPeriod)
-	r.GET("/admin/salesreport/date/:start/:end", m.AdminRetreiveToken, orderHandler.SalesReportByDate)
-	r.GET("/admin/salesreport/payment/:start/:end/:payment", m.AdminRetreiveToken, orderHandler.SalesReportByPayment)
+	r.GET("/admin/returns", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionReturns), orderHandler.AdminReturns)
+	r.PATCH("/admin/returns/:id/approve", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionReturns), orderHandler.AdminApproveReturn)
+	r.PATCH("/admin/returns/:id/reject", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionReturns), orderHandler.AdminRejectReturn)
+	r.PATCH("/admin/returns/:id/receive", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionReturns), orderHandler.AdminReceiveReturn)
+	r.GET("/admin/refunds", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionRefunds), orderHandler.AdminRefunds)
+	r.POST("/admin/refunds/:id/retry", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionRefunds), orderHandler.AdminRetryRefund)
+
+	r.GET("/admin/salesreport/period/:period", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionReports), orderHandler.SalesReportByPeriod)
+	r.GET("/admin/salesreport/date/:start/:end", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionReports), orderHandler.SalesReportByDate)
+	r.GET("/admin/salesreport/payment/:start/:end/:paymentmethod", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionReports), orderHandler.SalesReportByPayment)
 
 	r.GET("/user/order/invoice", m.UserRetreiveCookie, orderHandler.PrintInvoice)
 
 	r.GET("/user/stripe", m.UserRetreiveCookie, orderHandler.ExecutePaymentStripe)
 	r.POST("/webhook", orderHandler.HandleWebhook)
+	r.POST("/webhook/razorpay", orderHandler.HandleRazorpayWebhook)
 	r.GET("/user/orderstatus/:orderid", m.UserRetreiveCookie, orderHandler.OrderStatus)
 	return r
 }
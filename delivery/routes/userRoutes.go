@@ -14,8 +14,10 @@ func UserRouter(r *gin.Engine, userHandler *handlers.UserHandler) *gin.Engine {
 
 	r.POST("/user/login", userHandler.LoginWithPassword)
 	r.POST("/user/address", m.UserRetreiveCookie, userHandler.AddAddress)
-	r.PATCH("/user/address/:type", m.UserRetreiveCookie, userHandler.EditAddress)
-	r.DELETE("/user/address/:type", m.UserRetreiveCookie, userHandler.DeleteAddress)
+	r.GET("/user/address", m.UserRetreiveCookie, userHandler.Addresses)
+	r.PATCH("/user/address/:id", m.UserRetreiveCookie, userHandler.EditAddress)
+	r.PATCH("/user/address/:id/default", m.UserRetreiveCookie, userHandler.SetDefaultAddress)
+	r.DELETE("/user/address/:id", m.UserRetreiveCookie, userHandler.DeleteAddress)
 	r.GET("/user/details", m.UserRetreiveCookie, userHandler.ShowUserDetails)
 	r.PATCH("/user/profile", m.UserRetreiveCookie, userHandler.EditProfile)
 	r.POST("/user/change-password", m.UserRetreiveCookie, userHandler.ChangePassword)
@@ -27,15 +29,19 @@ func UserRouter(r *gin.Engine, userHandler *handlers.UserHandler) *gin.Engine {
 	r.GET("/user/products/sort", m.UserRetreiveCookie, userHandler.SortByCategory)
 	r.GET("/user/products/filter", m.UserRetreiveCookie, userHandler.SortByFilter)
 
-	r.POST("/user/cart", m.UserRetreiveCookie, userHandler.AddToCart)
-	r.DELETE("user/cart/:id", m.UserRetreiveCookie, userHandler.RemoveFromCart)
-	r.GET("/user/cart", m.UserRetreiveCookie, userHandler.Cart)
-	r.GET("/user/cartlist", m.UserRetreiveCookie, userHandler.CartItems)
+	r.POST("/user/cart", m.UserOrGuest, userHandler.AddToCart)
+	r.DELETE("user/cart/:id", m.UserOrGuest, userHandler.RemoveFromCart)
+	r.GET("/user/cart", m.UserOrGuest, userHandler.Cart)
+	r.POST("/user/cart/acknowledge", m.UserRetreiveCookie, userHandler.AcknowledgeCart)
+	r.GET("/user/cartlist", m.UserOrGuest, userHandler.CartItems)
 	r.POST("/user/wishlist", m.UserRetreiveCookie, userHandler.AddToWishList)
 	r.DELETE("/user/wishlist/:id", m.UserRetreiveCookie, userHandler.RemoveFromWishlist)
 	r.GET("/user/wishlist", m.UserRetreiveCookie, userHandler.ViewWishlist)
 	r.GET("/user/coupons", m.UserRetreiveCookie, userHandler.AvailableCoupons)
 	r.POST("/user/cart/coupon", m.UserRetreiveCookie, userHandler.ApplyCoupon)
+	r.DELETE("/user/cart/coupon", m.UserRetreiveCookie, userHandler.RemoveCoupon)
+	r.POST("/user/token/refresh", userHandler.RefreshToken)
 	r.POST("/user/logout", userHandler.Logout)
+	r.POST("/user/logout/all", m.UserRetreiveCookie, userHandler.LogoutAll)
 	return r
 }
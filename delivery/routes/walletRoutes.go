@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"project/delivery/handlers"
+	m "project/delivery/middleware"
+	"project/domain/entity"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WalletRouter(r *gin.Engine, walletHandler *handlers.WalletHandler) *gin.Engine {
+	r.GET("/user/wallet", m.UserRetreiveCookie, walletHandler.Wallet)
+
+	r.POST("/admin/wallet/reconcile", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionWallets), walletHandler.AdminReconcileWallets)
+	r.GET("/admin/wallet/:userid", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionWallets), walletHandler.AdminUserWallet)
+	r.POST("/admin/wallet/:userid/adjust", m.AdminRetreiveToken, m.AdminPermission(entity.PermissionWallets), walletHandler.AdminAdjustWallet)
+	return r
+}
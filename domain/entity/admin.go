@@ -1,15 +1,101 @@
 package entity
 
-import "gorm.io/gorm"
+import (
+	"project/domain/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Admin roles. Super admins hold every permission; what the other roles
+// may do is kept in their RolePermissions.
+const (
+	AdminRoleSuper          = "super_admin"
+	AdminRoleAdmin          = "admin"
+	AdminRoleCatalogManager = "catalog_manager"
+	AdminRoleOrderOps       = "order_ops"
+	AdminRoleFinance        = "finance"
+)
+
+// Admin permissions, each covering a group of admin routes.
+const (
+	PermissionUsers      = "users"
+	PermissionCatalog    = "catalog"
+	PermissionPromotions = "promotions"
+	PermissionOrders     = "orders"
+	PermissionReturns    = "returns"
+	PermissionRefunds    = "refunds"
+	PermissionWallets    = "wallets"
+	PermissionReports    = "reports"
+	PermissionAdmins     = "admins"
+)
+
+// AdminPermissions lists every permission a role can be granted.
+var AdminPermissions = []string{
+	PermissionUsers,
+	PermissionCatalog,
+	PermissionPromotions,
+	PermissionOrders,
+	PermissionReturns,
+	PermissionRefunds,
+	PermissionWallets,
+	PermissionReports,
+	PermissionAdmins,
+}
+
+// RolePermission grants the admins of a role one permission.
+type RolePermission struct {
+	Id         uint   `json:"-" gorm:"primarykey"`
+	Role       string `json:"role" gorm:"uniqueIndex:idx_role_permission"`
+	Permission string `json:"permission" gorm:"uniqueIndex:idx_role_permission"`
+}
 
 type Admin struct {
 	gorm.Model `json:"-"`
 	AdminName  string `json:"adminname"`
 	Email      string `json:"email"`
 	Phone      string `json:"phone"`
-	Password   string `json:"password"`
-	Role       string `json:"role"`
-	IsActive   bool   `json:"isactive"`
+	// Password is the bcrypt hash of the admin's password, empty until an
+	// invited or reset admin sets one.
+	Password string `json:"-"`
+	Role     string `json:"role"`
+	IsActive bool   `json:"isactive"`
+	// SetupTokenHash is the SHA-256 of the token an invited or reset admin
+	// sets their password with, valid until SetupTokenExpiry.
+	SetupTokenHash   string     `json:"-" gorm:"index"`
+	SetupTokenExpiry *time.Time `json:"-"`
+}
+
+// AdminAccount is an admin as super admins see them.
+type AdminAccount struct {
+	Id        uint   `json:"id"`
+	AdminName string `json:"adminname"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+	Role      string `json:"role"`
+	IsActive  bool   `json:"isactive"`
+	// PasswordSet is false while an invited or reset admin has not set
+	// their password.
+	PasswordSet bool `json:"passwordset"`
+}
+
+// AdminInvite is a new admin invited by a super admin.
+type AdminInvite struct {
+	AdminName string `json:"adminname" validate:"required"`
+	Email     string `json:"email" validate:"required,email"`
+	Phone     string `json:"phone"`
+	Role      string `json:"role" validate:"required"`
+}
+
+// RolePermissions is the set of permissions a role is given.
+type RolePermissions struct {
+	Permissions []string `json:"permissions"`
+}
+
+// AdminPasswordSetup sets an invited or reset admin's password.
+type AdminPasswordSetup struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
 }
 
 //	type AdminDashboard struct {
@@ -20,15 +106,15 @@ type Admin struct {
 //		TotalOrders       int `json:"totalorders"`
 //	}
 type AdminDashboard struct {
-	TotalUsers        int `json:"totalusers"`
-	NewUsers          int `json:"newusers"`
-	TotalProducts     int `json:"totalproducts"`
-	StocklessProducts int `json:"stocklessrproducts"`
-	TotalOrders       int `json:"totalorders"`
-	AverageOrderValue int `json:"averageordervalue"`
-	PendingOrders     int `json:"pendingorders"`
-	ReturnOrders      int `json:"returnorders"`
-	TotalRevenue      int `json:"totalrevenue"`
+	TotalUsers        int         `json:"totalusers"`
+	NewUsers          int         `json:"newusers"`
+	TotalProducts     int         `json:"totalproducts"`
+	StocklessProducts int         `json:"stocklessrproducts"`
+	TotalOrders       int         `json:"totalorders"`
+	AverageOrderValue money.Money `json:"averageordervalue"`
+	PendingOrders     int         `json:"pendingorders"`
+	ReturnOrders      int         `json:"returnorders"`
+	TotalRevenue      money.Money `json:"totalrevenue"`
 }
 
 type AdminLogin struct {
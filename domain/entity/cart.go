@@ -1,15 +1,30 @@
 package entity
 
-import "gorm.io/gorm"
+import (
+	"project/domain/money"
+
+	"gorm.io/gorm"
+)
 
 type Cart struct{
 	gorm.Model `json:"-"`
 	UserId int `json:"userid"`
+	// GuestId keys the cart of an anonymous shopper, whose cart has no
+	// user until it is merged on login.
+	GuestId string `json:"-" gorm:"index"`
 	ProductQuantity int `json:"productquantity"`
-	TotalPrize int `json:"totalprize"`
-	OfferPrize int `json:"offerprize"`
+	TotalPrize money.Money `json:"totalprize"`
+	OfferPrize money.Money `json:"offerprize"`
 }
 
+// A cart item's Status says what changed since the user last reviewed the
+// cart. Lines with a status have to be acknowledged before checkout.
+const (
+	CartItemPriceChanged = "price_changed"
+	CartItemShort        = "short"
+	CartItemUnavailable  = "unavailable"
+)
+
 type CartItem struct{
 	gorm.Model `json:"-"`
 	CartId int `json:"cartid"`
@@ -17,7 +32,14 @@ type CartItem struct{
 	ProductId int  `json:"productid"`
 	ProductName string `json:"productname"`
 	Quantity int `json:"quantity"`
-	Price int `json:"prize"`
+	Price money.Money `json:"prize"`
+	// Discount is the line's share of the coupons on the cart.
+	Discount money.Money `json:"discount"`
+	// PreviousPrice is the price the user last saw while a price change
+	// waits to be acknowledged.
+	PreviousPrice money.Money `json:"previousprice"`
+	Status        string      `json:"status"`
+	Note          string      `json:"note"`
 }
 
 type WishList struct{
@@ -26,5 +48,5 @@ type WishList struct{
 	Category int `json:"category"`
 	ProductId int `json:"productid"`
 	ProductName string `json:"productname"`
-	Prize int `json:"prize"`
+	Prize money.Money `json:"prize"`
 }
\ No newline at end of file
@@ -1,47 +1,251 @@
 package entity
 
-import "gorm.io/gorm"
+import (
+	"project/domain/money"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Order struct {
+	gorm.Model    `json:"-"`
+	ID            int         `gorm:"primarykey" json:"id"`
+	UserId        int         `json:"orderid"`
+	Addressid     int         `json:"addressid"`
+	Total         money.Money `json:"total"`
+	Status        string      `json:"status"`
+	PaymentMethod string      `json:"paymentmethod"`
+	PaymentStatus string      `json:"payemntstatus"`
+	PaymentId     string      `json:"paymentid"`
+	// GatewayOrderId is the razorpay order or stripe payment intent behind
+	// the order, kept after PaymentId is replaced by the gateway's payment id.
+	GatewayOrderId string      `json:"gatewayorderid" gorm:"index:idx_orders_gateway_order_id,unique,where:gateway_order_id <> ''"`
+	WalletAmount   money.Money `json:"walletamount"`
+	WalletStatus   string      `json:"walletstatus"`
+	// Discount is the cart discount taken off Total, spread over the
+	// items as their DiscountShare.
+	Discount money.Money `json:"discount"`
+	// Taxable and the GST columns split Total, which includes the tax.
+	Taxable money.Money `json:"taxable"`
+	CGST    money.Money `json:"cgst"`
+	SGST    money.Money `json:"sgst"`
+	IGST    money.Money `json:"igst"`
+}
+
+// OrderAddress is the delivery address as it was when the order was placed.
+// It is only ever created, so later edits to the address book do not change
+// where an order went.
+type OrderAddress struct {
 	gorm.Model    `json:"-"`
 	ID            int    `gorm:"primarykey" json:"id"`
-	UserId        int    `json:"orderid"`
-	Addressid     int    `json:"addressid"`
-	Total         int    `json:"total"`
-	Status        string `json:"status"`
-	PaymentMethod string `json:"paymentmethod"`
-	PaymentStatus string `json:"payemntstatus"`
-	PaymentId     string `json:"paymentid"`
+	OrderId       int    `json:"orderid" gorm:"uniqueIndex"`
+	UserAddressId int    `json:"useraddressid"`
+	Address       string `json:"address"`
+	State         string `json:"state"`
+	Country       string `json:"country"`
+	Pin           string `json:"pin"`
+	Type          string `json:"type"`
 }
 
+// OrderItem keeps the product as it was sold. Prize is the unit price
+// charged; UnitPrice and OfferPrice are the product's list and offer price
+// at the time, and DiscountShare is the line's part of the order discount.
+// The tax columns are the GST on the line as charged; Taxable plus the tax
+// is what the customer paid for it.
 type OrderItem struct {
-	gorm.Model `json:"-"`
-	OrderId    int `json:"orderid"`
-	ProductId  int `json:"productid"`
-	Category   int `json:"category"`
-	Quantity   int `json:"quantity"`
-	Prize      int `json:"prize"`
+	gorm.Model    `json:"-"`
+	ID            int         `gorm:"primarykey" json:"id"`
+	OrderId       int         `json:"orderid"`
+	ProductId     int         `json:"productid"`
+	ProductName   string      `json:"productname"`
+	Category      int         `json:"category"`
+	Quantity      int         `json:"quantity"`
+	Prize         money.Money `json:"prize"`
+	UnitPrice     money.Money `json:"unitprice"`
+	OfferPrice    money.Money `json:"offerprice"`
+	DiscountShare money.Money `json:"discountshare"`
+	HSNCode       string      `json:"hsncode"`
+	GSTRate       int         `json:"gstrate"`
+	Taxable       money.Money `json:"taxable"`
+	CGST          money.Money `json:"cgst"`
+	SGST          money.Money `json:"sgst"`
+	IGST          money.Money `json:"igst"`
 }
 type Invoice struct {
 	gorm.Model  `json:"-"`
-	OrderId     int     `json:"orderid"`
-	UserId      int     `json:"userid"`
-	AddressType string  `json:"addresstype"`
-	Quantity    int     `json:"quantity"`
-	Price       float64 `json:"price"`
-	Payment     string  `json:"payment"`
-	Status      string  `json:"status"`
-	PaymentId   string  `json:"paymentid"`
-	Remark      string  `json:"remark" gorm:"default lapify_festiv"`
+	OrderId     int         `json:"orderid"`
+	UserId      int         `json:"userid"`
+	AddressType string      `json:"addresstype"`
+	Quantity    int         `json:"quantity"`
+	Price       money.Money `json:"price"`
+	Payment     string      `json:"payment"`
+	Status      string      `json:"status"`
+	PaymentId   string      `json:"paymentid"`
+	Remark      string      `json:"remark" gorm:"default lapify_festiv"`
 }
 type SalesReport struct {
-	TotalSales   float64
-	TotalOrders  int64
-	AverageOrder float64
+	TotalSales    money.Money
+	TotalOrders   int64
+	AverageOrder  money.Money
+	TotalDiscount money.Money
+	TotalTax      money.Money
 }
 type Charge struct {
 	gorm.Model   `json:"-"`
-	Amount       int64  `json:"amount"`
-	ReceiptEmail string `json:"receiptMail"`
-	ProductName  string `json:"productName"`
+	Amount       money.Money `json:"amount"`
+	ReceiptEmail string      `json:"receiptMail"`
+	ProductName  string      `json:"productName"`
+}
+
+const (
+	OrderPending        = "pending"
+	OrderConfirmed      = "confirmed"
+	OrderPacked         = "packed"
+	OrderShipped        = "shipped"
+	OrderOutForDelivery = "out_for_delivery"
+	OrderDelivered      = "delivered"
+	OrderCancelled      = "cancelled"
+	OrderReturned       = "returned"
+	OrderRefunded       = "refunded"
+	OrderPaymentExpired = "payment_expired"
+)
+
+// Payment statuses of an order. PaymentSucceeded is the status of a paid
+// order, reports and filters query this spelling. PaymentRefunded is the
+// status of a payment that was given back.
+const (
+	PaymentPending   = "pending"
+	PaymentSucceeded = "succesfull"
+	PaymentFailed    = "failed"
+	PaymentExpired   = "expired"
+	PaymentRefunded  = "refund"
+)
+
+// PaymentCod is the payment method of cash on delivery orders.
+const PaymentCod = "cod"
+
+// SalesStatuses are the order statuses that count towards revenue.
+var SalesStatuses = []string{OrderConfirmed, OrderPacked, OrderShipped, OrderOutForDelivery, OrderDelivered}
+
+type OrderStatusHistory struct {
+	gorm.Model  `json:"-"`
+	OrderId     int       `json:"orderid" gorm:"index"`
+	FromStatus  string    `json:"fromstatus"`
+	ToStatus    string    `json:"tostatus"`
+	ChangedBy   string    `json:"changedby"`
+	ChangedById int       `json:"changedbyid"`
+	Remark      string    `json:"remark"`
+	ChangedAt   time.Time `json:"changedat"`
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
+
+// Wallet statuses of an order that is partly paid from the wallet.
+const (
+	WalletHeld      = "held"
+	WalletCommitted = "committed"
+	WalletReleased  = "released"
+)
+
+const (
+	ReturnRequested = "requested"
+	ReturnApproved  = "approved"
+	ReturnRejected  = "rejected"
+	ReturnRefunded  = "refunded"
+)
+
+const (
+	ReturnReasonDamaged        = "damaged"
+	ReturnReasonWrongItem      = "wrong_item"
+	ReturnReasonNotAsDescribed = "not_as_described"
+	ReturnReasonSizeIssue      = "size_issue"
+	ReturnReasonQualityIssue   = "quality_issue"
+	ReturnReasonNotNeeded      = "no_longer_needed"
+)
+
+// ReturnReasons are the reason codes a customer can pick for a return.
+var ReturnReasons = []string{ReturnReasonDamaged, ReturnReasonWrongItem, ReturnReasonNotAsDescribed, ReturnReasonSizeIssue, ReturnReasonQualityIssue, ReturnReasonNotNeeded}
+
+const (
+	RefundToWallet   = "wallet"
+	RefundToOriginal = "original"
+)
+
+type ReturnRequest struct {
+	gorm.Model   `json:"-"`
+	ID           int         `gorm:"primarykey" json:"id"`
+	OrderId      int         `json:"orderid" gorm:"index"`
+	OrderItemId  int         `json:"orderitemid" gorm:"index"`
+	UserId       int         `json:"userid" gorm:"index"`
+	Quantity     int         `json:"quantity"`
+	Reason       string      `json:"reason"`
+	Comment      string      `json:"comment"`
+	Status       string      `json:"status" gorm:"index"`
+	RefundMethod string      `json:"refundmethod"`
+	RefundAmount money.Money `json:"refundamount"`
+	AdminRemark  string      `json:"adminremark"`
+	ReviewedBy   int         `json:"reviewedby"`
+}
+
+const (
+	RefundPending   = "pending"
+	RefundProcessed = "processed"
+	RefundFailed    = "failed"
+)
+
+// Refund is money going back to a customer for a cancellation or a return.
+// Wallet refunds are processed on the spot, gateway refunds stay pending
+// until the gateway accepts them.
+type Refund struct {
+	gorm.Model      `json:"-"`
+	ID              int         `gorm:"primarykey" json:"id"`
+	OrderId         int         `json:"orderid" gorm:"index"`
+	ReturnRequestId int         `json:"returnrequestid" gorm:"index"`
+	UserId          int         `json:"userid" gorm:"index"`
+	Method          string      `json:"method"`
+	Amount          money.Money `json:"amount"`
+	Status          string      `json:"status" gorm:"index"`
+	GatewayRefundId string      `json:"gatewayrefundid"`
+	FailureReason   string      `json:"failurereason"`
+	ProcessedAt     time.Time   `json:"processedat"`
+}
+
+// WebhookEvent records a processed payment gateway event so redelivered
+// events are skipped.
+type WebhookEvent struct {
+	gorm.Model  `json:"-"`
+	Provider    string    `json:"provider" gorm:"uniqueIndex:idx_webhook_event"`
+	EventId     string    `json:"eventid" gorm:"uniqueIndex:idx_webhook_event"`
+	Event       string    `json:"event"`
+	ProcessedAt time.Time `json:"processedat"`
+}
+
+// TaxInvoice is the GST invoice issued for an order. Sequence runs without
+// gaps within a financial year, and the rendered PDF is kept so every
+// download is the same document.
+type TaxInvoice struct {
+	gorm.Model    `json:"-"`
+	ID            int         `gorm:"primarykey" json:"id"`
+	OrderId       int         `json:"orderid" gorm:"uniqueIndex"`
+	FinancialYear string      `json:"financialyear" gorm:"uniqueIndex:idx_tax_invoice_sequence"`
+	Sequence      int         `json:"sequence" gorm:"uniqueIndex:idx_tax_invoice_sequence"`
+	Number        string      `json:"number" gorm:"uniqueIndex"`
+	IssuedAt      time.Time   `json:"issuedat"`
+	PlaceOfSupply string      `json:"placeofsupply"`
+	Taxable       money.Money `json:"taxable"`
+	CGST          money.Money `json:"cgst"`
+	SGST          money.Money `json:"sgst"`
+	IGST          money.Money `json:"igst"`
+	Total         money.Money `json:"total"`
+	Pdf           []byte      `json:"-"`
+}
+
+// InvoiceSequence is the last invoice number used in a financial year. It
+// is bumped inside the transaction that issues the invoice, so a rolled
+// back invoice gives its number back.
+type InvoiceSequence struct {
+	FinancialYear string `gorm:"primarykey"`
+	LastNumber    int
 }
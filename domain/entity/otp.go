@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OtpCode is a code sent by the self-hosted OTP provider, kept only as its
+// hash. A code is good for one successful check until ExpiresAt, and stops
+// working after too many wrong guesses.
+type OtpCode struct {
+	gorm.Model `json:"-"`
+	Phone      string     `json:"phone" gorm:"index"`
+	CodeHash   string     `json:"-"`
+	ExpiresAt  time.Time  `json:"expiresat" gorm:"index"`
+	Attempts   int        `json:"attempts"`
+	UsedAt     *time.Time `json:"usedat"`
+}
@@ -1,6 +1,7 @@
 package entity
 
 import (
+	"project/domain/money"
 	"time"
 
 	"gorm.io/gorm"
@@ -10,12 +11,14 @@ type Product struct {
 	gorm.Model `json:"-"`
 	ID         int    `gorm:"primarykey"`
 	Name       string `json:"name" validate:"required" form:"name"`
-	Price      int    `json:"price" validate:"required,number" form:"price"`
-	OfferPrize int    `json:"offerprice"  `
-	Size       string `json:"size" validate:"required" form:"size"`
-	Removed    bool   `json:"removed"`
-	Category   int    `form:"category" gorm:"foreignKey:ID;references:ID" validate:"required,numeric"`
-	ImageURL   string `json:"imageurl" `
+	// Price is read from forms by the handler: form binding would take the
+	// rupees for paise.
+	Price      money.Money `json:"price" validate:"required,number" form:"-"`
+	OfferPrize money.Money `json:"offerprice"  `
+	Size       string      `json:"size" validate:"required" form:"size"`
+	Removed    bool        `json:"removed"`
+	Category   int         `form:"category" gorm:"foreignKey:ID;references:ID" validate:"required,numeric"`
+	ImageURL   string      `json:"imageurl" `
 }
 
 type ProductDetails struct {
@@ -35,43 +38,127 @@ type Inventory struct {
 	gorm.Model      `json:"-"`
 	ProductId       int
 	Quantity        int `validate:"required,numeric" form:"quantity"`
+	Reserved        int `json:"reserved" gorm:"not null;default:0"`
 	ProductCategory int
 }
+
+const (
+	ReservationHeld      = "held"
+	ReservationCommitted = "committed"
+	ReservationReleased  = "released"
+)
+
+// StockReservation holds inventory for an order awaiting online payment.
+// Held units are counted in Inventory.Reserved until the payment commits
+// them or the hold expires.
+type StockReservation struct {
+	gorm.Model      `json:"-"`
+	OrderId         int       `json:"orderid" gorm:"index"`
+	ProductId       int       `json:"productid"`
+	ProductCategory int       `json:"productcategory"`
+	Quantity        int       `json:"quantity"`
+	Status          string    `json:"status"`
+	ExpiresAt       time.Time `json:"expiresat" gorm:"index"`
+}
+
+// Category carries the GST classification of its products. A category
+// without an HSN code is taxed at the configured default rate.
 type Category struct {
 	gorm.Model  `json:"-"`
 	ID          int    `gorm:"primarykey"`
 	Name        string `json:"name" validate:"required,alpha"`
 	Description string `json:"description" validate:"required"`
+	HSNCode     string `json:"hsncode" validate:"omitempty,numeric,min=4,max=8"`
+	GSTRate     int    `json:"gstrate" validate:"omitempty,oneof=0 3 5 12 18 28"`
+	// TaxExclusive prices have the GST added at checkout instead of
+	// including it.
+	TaxExclusive bool `json:"taxexclusive"`
 }
 
+const (
+	CouponPercentage = "percentage"
+	CouponFlat       = "flat"
+)
+
+// Coupon is a discount code. Category and ProductId scope it to those cart
+// items, zero meaning the whole cart. MinCartValue is checked against the
+// whole cart, MaxDiscount caps what a percentage coupon takes off and zero
+// means no cap. PerUserLimit zero lets a user redeem the coupon until the
+// UsageLimit runs out.
 type Coupon struct {
 	gorm.Model `json:"-"`
-	Id         int       `json:"id" `
-	Code       string    `json:"code" validate:"required,max=8"   `
-	Type       string    `json:"type" validate:"required,alpha"`
-	Amount     int       `json:"amount" validate:"required,numeric,positive"`
-	ValidFrom  time.Time `json:"-"`
-	Validuntil time.Time `json:"valid_until"`
-	UsageLimit int       `json:"usage_limit" validate:"required,numeric"`
-	UsedCount  int       `json:"usedcount"`
+	Id         int    `json:"id" `
+	Code       string `json:"code" validate:"required,max=8"   `
+	Type       string `json:"type" validate:"required,oneof=percentage flat"`
+	// Percent is what a percentage coupon takes off, Flat what a flat
+	// coupon does. Only the one matching Type is set.
+	Percent        int         `json:"percent" validate:"min=0,max=100"`
+	Flat           money.Money `json:"flat" validate:"min=0"`
+	ValidFrom      time.Time   `json:"valid_from"`
+	Validuntil     time.Time   `json:"valid_until" validate:"required"`
+	UsageLimit     int         `json:"usage_limit" validate:"required,numeric"`
+	UsedCount      int         `json:"usedcount"`
+	MinCartValue   money.Money `json:"min_cart_value" validate:"min=0"`
+	MaxDiscount    money.Money `json:"max_discount" validate:"min=0"`
+	PerUserLimit   int         `json:"per_user_limit" validate:"min=0"`
+	FirstOrderOnly bool        `json:"first_order_only"`
+	Category       int         `json:"category" validate:"min=0"`
+	ProductId      int         `json:"product_id" validate:"min=0"`
+	// Stackable coupons can be used together; any other coupon has to be
+	// the only one on the cart.
+	Stackable bool `json:"stackable"`
+}
+
+// CartCoupon is a coupon applied to a cart and what it currently takes off.
+// Coupons are only redeemed when the order is placed.
+type CartCoupon struct {
+	gorm.Model `json:"-"`
+	CartId     int         `json:"cartid" gorm:"index"`
+	CouponId   int         `json:"couponid"`
+	Code       string      `json:"code"`
+	Discount   money.Money `json:"discount"`
 }
 
+const (
+	OfferScheduled = "scheduled"
+	OfferActive    = "active"
+	OfferExpired   = "expired"
+	OfferCancelled = "cancelled"
+
+	OfferPercentage = "percentage"
+	OfferFlat       = "flat"
+)
+
+// Offer is a price cut on a product, or on every product of Category when
+// ProductId is 0, running from ValidFrom until ValidUntil. A product sells
+// at the lowest price its running offers give; Product.OfferPrize keeps
+// that price for listings and is updated as offers start and end.
 type Offer struct {
 	gorm.Model `json:"-"`
-	Id         int       `json:"-" gorm:"primarykey"`
-	Name       string    `json:"name"`
-	Type       string    `json:"type"`
-	Amount     int       `json:"amount"`
-	MinPrice   int       `json:"minprice"`
-	ValidFrom  time.Time `json:"-"`
-	ValidUntil time.Time `json:"valid_until"`
-	UsageLimit int       `json:"usage_limit"`
-	UsedCount  int       `json:"-"`
-	Category   int       `json:"category"`
-	ProductId  int       `json:"product_id"`
+	Id         int    `json:"id" gorm:"primarykey"`
+	Name       string `json:"name" validate:"required"`
+	Type       string `json:"type" validate:"required,oneof=percentage flat"`
+	// Percent is what a percentage offer takes off the unit price, Flat
+	// what a flat offer does. Only the one matching Type is set.
+	Percent   int         `json:"percent" validate:"min=0,max=99"`
+	Flat      money.Money `json:"flat" validate:"min=0"`
+	MinPrice  money.Money `json:"minprice"`
+	ValidFrom time.Time   `json:"valid_from"`
+	// ValidUntil nil runs the offer until it is cancelled.
+	ValidUntil *time.Time `json:"valid_until"`
+	UsageLimit int        `json:"usage_limit"`
+	UsedCount  int        `json:"-"`
+	Category   int        `json:"category" validate:"min=0"`
+	ProductId  int        `json:"product_id" validate:"min=0"`
+	Status     string     `json:"status" gorm:"index"`
 }
 
+// UsedCoupon records a redemption. Rows written before coupons were
+// redeemed at checkout have no order.
 type UsedCoupon struct {
-	UserId     int    `json:"userid"`
-	CouponCode string `json:"couponcode"`
+	UserId     int         `json:"userid" gorm:"index"`
+	CouponCode string      `json:"couponcode"`
+	OrderId    int         `json:"orderid" gorm:"index"`
+	Discount   money.Money `json:"discount"`
+	CreatedAt  time.Time   `json:"createdat"`
 }
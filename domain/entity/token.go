@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is a refresh token handed out at login, kept only as its
+// hash. Each refresh replaces the token with a new one of the same Family;
+// a replaced token used again revokes the whole family.
+type RefreshToken struct {
+	gorm.Model `json:"-"`
+	UserId     int        `json:"userid" gorm:"index:idx_refresh_subject"`
+	Role       string     `json:"role" gorm:"index:idx_refresh_subject"`
+	Email      string     `json:"email"`
+	Family     string     `json:"-" gorm:"index"`
+	TokenHash  string     `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt  time.Time  `json:"expiresat"`
+	RevokedAt  *time.Time `json:"revokedat"`
+	ReplacedBy uint       `json:"-"`
+}
+
+// RevokedToken is an access token revoked before it expired. It is kept
+// until then.
+type RevokedToken struct {
+	Jti       string    `gorm:"primarykey"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+// SessionCutoff ends every session a user or admin started before
+// RevokedBefore, for logging out of all devices.
+type SessionCutoff struct {
+	UserId        int    `gorm:"primarykey"`
+	Role          string `gorm:"primarykey"`
+	RevokedBefore time.Time
+}
@@ -1,6 +1,10 @@
 package entity
 
-import "gorm.io/gorm"
+import (
+	"project/domain/money"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model `json:"-"`
@@ -10,7 +14,8 @@ type User struct {
 	Phone      string `json:"phone" validate:"required"`
 	Password   string `json:"password" validate:"required,min=8"`
 	IsBlocked  bool   `gorm:"not null;default:true" json:"-"`
-	Wallet     int    `json:"wallet"`
+	Wallet     money.Money `json:"wallet"`
+	WalletHeld money.Money `json:"walletheld" gorm:"not null;default:0"`
 	Permission bool   `gorm:"not null;default:true" json:"-"`
 	ReferalCode string `json:"referalcode"`
 }
@@ -18,12 +23,15 @@ type User struct {
 type UserAddress struct {
 	gorm.Model `json:"-"`
 	Id         int    `gorm:"primarykey" json:"id"`
-	User_id    int    `json:"-"`
+	User_id    int    `json:"-" gorm:"index:idx_user_addresses_default,unique,where:is_default AND deleted_at IS NULL"`
 	Address    string `json:"address" validate:"required"`
 	State      string `json:"state" validate:"required,alpha"`
 	Country    string `json:"country" validate:"required,alpha"`
 	Pin        string `json:"pin" validate:"required,numeric,len=6"`
-	Type       string `json:"type" validate:"required,alpha"`
+	// Type is a free label such as home or work; several addresses may
+	// share one.
+	Type      string `json:"type" validate:"omitempty,alpha"`
+	IsDefault bool   `json:"isdefault" gorm:"not null;default:false"`
 }
 
 type Login struct {
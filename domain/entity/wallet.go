@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"project/domain/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	WalletCredit = "credit"
+	WalletDebit  = "debit"
+)
+
+const (
+	WalletReasonOrderPayment = "order_payment"
+	WalletReasonCancelRefund = "cancel_refund"
+	WalletReasonReturnRefund = "return_refund"
+	WalletReasonReferral     = "referral"
+	WalletReasonAdjustment   = "admin_adjustment"
+	WalletReasonOpening      = "opening_balance"
+)
+
+// WalletTransaction is one entry of a user's wallet ledger. BalanceAfter is
+// the wallet balance right after the entry was posted.
+type WalletTransaction struct {
+	gorm.Model   `json:"-"`
+	ID           int         `gorm:"primarykey" json:"id"`
+	UserId       int         `json:"userid" gorm:"index"`
+	Type         string      `json:"type"`
+	Amount       money.Money `json:"amount"`
+	Reason       string      `json:"reason"`
+	OrderId      int         `json:"orderid"`
+	Remark       string      `json:"remark"`
+	AdminId      int         `json:"adminid"`
+	BalanceAfter money.Money `json:"balanceafter"`
+	PostedAt     time.Time   `json:"postedat"`
+}
+
+// WalletDiscrepancy is a user whose stored wallet balance does not match
+// the ledger.
+type WalletDiscrepancy struct {
+	UserId  int         `json:"userid"`
+	Stored  money.Money `json:"stored"`
+	Ledger  money.Money `json:"ledger"`
+	Entries int64       `json:"entries"`
+}
@@ -0,0 +1,267 @@
+// Package money keeps amounts in the minor unit of their currency, so sums,
+// splits and tax come out exact. The shop sells in one currency, INR, and
+// every Money is in it.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency. Exponent is the number of minor unit
+// digits, 2 for paise.
+type Currency struct {
+	Code     string
+	Exponent int
+}
+
+var INR = Currency{Code: "INR", Exponent: 2}
+
+// Shop is the currency the shop's prices, orders and payments are in.
+var Shop = INR
+
+// Money is an amount in the minor unit of the shop currency: paise.
+type Money int64
+
+func (c Currency) unit() int64 {
+	unit := int64(1)
+	for i := 0; i < c.Exponent; i++ {
+		unit *= 10
+	}
+	return unit
+}
+
+// FromMajor is an amount of whole rupees.
+func FromMajor(major int64) Money {
+	return Money(major * Shop.unit())
+}
+
+// Parse reads an amount in rupees such as "499", "499.5" or "-12.05". More
+// decimals than the currency has are refused rather than rounded away.
+func Parse(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, errors.New("invalid amount")
+	}
+	if len(frac) > Shop.Exponent {
+		return 0, errors.New("too many decimal places in amount")
+	}
+	frac += strings.Repeat("0", Shop.Exponent-len(frac))
+	if whole == "" {
+		whole = "0"
+	}
+	for _, part := range []string{whole, frac} {
+		if strings.Trim(part, "0123456789") != "" {
+			return 0, errors.New("invalid amount")
+		}
+	}
+	major, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid amount")
+	}
+	minor := int64(0)
+	if frac != "" {
+		minor, _ = strconv.ParseInt(frac, 10, 64)
+	}
+	amount := major*Shop.unit() + minor
+	if negative {
+		amount = -amount
+	}
+	return Money(amount), nil
+}
+
+// Minor is the amount in paise, as payment gateways take it.
+func (m Money) Minor() int64 {
+	return int64(m)
+}
+
+func (m Money) Currency() Currency {
+	return Shop
+}
+
+// Mul is the price of n units.
+func (m Money) Mul(n int) Money {
+	return m * Money(n)
+}
+
+// MulDiv is m * num / den rounded to the nearest paisa, halves away from
+// zero.
+func (m Money) MulDiv(num, den int64) Money {
+	p := int64(m) * num
+	q, r := p/den, p%den
+	if r < 0 {
+		r = -r
+	}
+	if 2*r >= abs(den) {
+		if (p < 0) != (den < 0) {
+			q--
+		} else {
+			q++
+		}
+	}
+	return Money(q)
+}
+
+// Percent is percent of m, rounded like MulDiv.
+func (m Money) Percent(percent int) Money {
+	return m.MulDiv(int64(percent), 100)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Min is the smaller of a and b.
+func Min(a, b Money) Money {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Allocate spreads amount over the weights in proportion to their size.
+// The last share with a weight takes the rounding remainder so the shares
+// add up to the amount exactly; zero weights get nothing.
+func Allocate(amount Money, weights []Money) []Money {
+	shares := make([]Money, len(weights))
+	var total Money
+	last := -1
+	for i, w := range weights {
+		total += w
+		if w != 0 {
+			last = i
+		}
+	}
+	if total == 0 || last < 0 {
+		return shares
+	}
+	left := amount
+	for i := range weights[:last] {
+		shares[i] = Money(int64(amount) * int64(weights[i]) / int64(total))
+		left -= shares[i]
+	}
+	shares[last] = left
+	return shares
+}
+
+// String writes the amount in rupees with both decimals, as 1234.50.
+func (m Money) String() string {
+	sign := ""
+	n := int64(m)
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	unit := Shop.unit()
+	if Shop.Exponent == 0 {
+		return sign + strconv.FormatInt(n, 10)
+	}
+	frac := strconv.FormatInt(n%unit, 10)
+	frac = strings.Repeat("0", Shop.Exponent-len(frac)) + frac
+	return sign + strconv.FormatInt(n/unit, 10) + "." + frac
+}
+
+// Grouped writes the amount with Indian digit grouping, as 1,23,456.00.
+func (m Money) Grouped() string {
+	s := m.String()
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	digits, frac, _ := strings.Cut(s, ".")
+	if len(digits) > 3 {
+		head, tail := digits[:len(digits)-3], digits[len(digits)-3:]
+		var groups []string
+		for len(head) > 2 {
+			groups = append([]string{head[len(head)-2:]}, groups...)
+			head = head[:len(head)-2]
+		}
+		if head != "" {
+			groups = append([]string{head}, groups...)
+		}
+		digits = strings.Join(groups, ",") + "," + tail
+	}
+	if frac != "" {
+		digits += "." + frac
+	}
+	return sign + digits
+}
+
+// Format writes the amount with its currency, as INR 1,23,456.00.
+func (m Money) Format() string {
+	if m < 0 {
+		return "-" + Shop.Code + " " + (-m).Grouped()
+	}
+	return Shop.Code + " " + m.Grouped()
+}
+
+// MarshalJSON writes the amount in rupees as a JSON number, 499.00, so API
+// clients keep seeing prices in rupees.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads an amount in rupees given as a number or a string.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	amount, err := Parse(strings.Trim(s, `"`))
+	if err != nil {
+		return err
+	}
+	*m = amount
+	return nil
+}
+
+// UnmarshalParam lets gin bind a form or query value in rupees.
+func (m *Money) UnmarshalParam(param string) error {
+	amount, err := Parse(param)
+	if err != nil {
+		return err
+	}
+	*m = amount
+	return nil
+}
+
+// Value stores the amount as a bigint of paise.
+func (m Money) Value() (driver.Value, error) {
+	return int64(m), nil
+}
+
+// Scan reads paise from an integer column or from an integral SUM, which
+// postgres returns as numeric.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = Money(v)
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	default:
+		return fmt.Errorf("cannot scan %T into money", src)
+	}
+	return nil
+}
+
+func (m *Money) scanString(s string) error {
+	n, err := strconv.ParseInt(strings.TrimSuffix(s, ".0"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("cannot scan %q into money", s)
+	}
+	*m = Money(n)
+	return nil
+}
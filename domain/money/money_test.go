@@ -0,0 +1,157 @@
+package money
+
+import "testing"
+
+func TestMulDiv(t *testing.T) {
+	tests := []struct {
+		m        Money
+		num, den int64
+		want     Money
+	}{
+		{1000, 18, 100, 180},
+		{0, 18, 100, 0},
+		{7, 1, 3, 2},
+		{8, 1, 3, 3},
+		{-8, 1, 3, -3},
+		{149, 1, 100, 1},
+		// Halves round away from zero, whatever carries the sign.
+		{150, 1, 100, 2},
+		{5, 1, 2, 3},
+		{-5, 1, 2, -3},
+		{5, -1, 2, -3},
+		{5, 1, -2, -3},
+		{-5, 1, -2, 3},
+	}
+	for _, tt := range tests {
+		if got := tt.m.MulDiv(tt.num, tt.den); got != tt.want {
+			t.Errorf("Money(%d).MulDiv(%d, %d) = %d, want %d", tt.m, tt.num, tt.den, got, tt.want)
+		}
+	}
+	if got := Money(999).Percent(18); got != 180 {
+		t.Errorf("18%% of 999 paise is %d, want 180", got)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  Money
+		weights []Money
+		want    []Money
+	}{
+		{"even", 90, []Money{1, 1, 1}, []Money{30, 30, 30}},
+		{"remainder to the last share", 100, []Money{1, 1, 1}, []Money{33, 33, 34}},
+		{"proportional", 1000, []Money{300, 0, 700}, []Money{300, 0, 700}},
+		{"remainder skips a zero last weight", 100, []Money{1, 1, 1, 0}, []Money{33, 33, 34, 0}},
+		{"single weight", 10, []Money{0, 5, 0}, []Money{0, 10, 0}},
+		{"negative amount", -100, []Money{1, 1, 1}, []Money{-33, -33, -34}},
+		{"no weight", 10, []Money{0, 0}, []Money{0, 0}},
+		{"no items", 10, nil, []Money{}},
+	}
+	for _, tt := range tests {
+		got := Allocate(tt.amount, tt.weights)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+	}{
+		{"499", 49900},
+		{"499.5", 49950},
+		{"499.50", 49950},
+		{"-12.05", -1205},
+		{" 7.25 ", 725},
+		{".5", 50},
+		{"5.", 500},
+		{"0", 0},
+		{"-0.01", -1},
+		{"0012", 1200},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", " ", "-", ".", "-.", "abc", "1.234", "1.2.3", "1,000", "--5", "+5", "1e3", "1.-5", "12 34", "99999999999999999999"} {
+		if got, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %d, want an error", in, got)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src  interface{}
+		want Money
+	}{
+		{nil, 0},
+		{int64(125), 125},
+		{int64(-125), -125},
+		{[]byte("12345"), 12345},
+		{"12345", 12345},
+		// postgres returns an integral SUM as numeric.
+		{"12345.0", 12345},
+		{[]byte("-50"), -50},
+	}
+	for _, tt := range tests {
+		m := Money(99)
+		if err := m.Scan(tt.src); err != nil {
+			t.Errorf("Scan(%#v): %v", tt.src, err)
+			continue
+		}
+		if m != tt.want {
+			t.Errorf("Scan(%#v) = %d, want %d", tt.src, m, tt.want)
+		}
+	}
+
+	for _, src := range []interface{}{1.5, "12.5", []byte("abc"), true} {
+		var m Money
+		if err := m.Scan(src); err == nil {
+			t.Errorf("Scan(%#v) = %d, want an error", src, m)
+		}
+	}
+}
+
+func TestGrouped(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{100, "1.00"},
+		{99999, "999.99"},
+		{100000, "1,000.00"},
+		{123456, "1,234.56"},
+		{12345600, "1,23,456.00"},
+		{1234567800, "1,23,45,678.00"},
+		{-12345600, "-1,23,456.00"},
+	}
+	for _, tt := range tests {
+		if got := tt.m.Grouped(); got != tt.want {
+			t.Errorf("Money(%d).Grouped() = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+	if got := Money(-12345600).Format(); got != "-INR 1,23,456.00" {
+		t.Errorf("Format() = %q, want %q", got, "-INR 1,23,456.00")
+	}
+}
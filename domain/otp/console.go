@@ -0,0 +1,97 @@
+package otp
+
+import (
+	"io"
+	"project/config"
+	"project/domain/entity"
+	"sync"
+	"time"
+)
+
+// NewConsole returns a provider for local development and tests. It
+// writes every code to w instead of sending it and keeps the codes in
+// memory, with the same expiry and attempt limits as the db provider.
+func NewConsole(w io.Writer, cfg *config.OTP) *DBProvider {
+	p := NewDBProvider(&memoryStore{}, cfg)
+	p.Deliver = writeTo(w)
+	return p
+}
+
+// memoryStore is a CodeStore that forgets its codes on restart.
+type memoryStore struct {
+	mu    sync.Mutex
+	seq   uint
+	codes []*entity.OtpCode
+}
+
+func (m *memoryStore) CreateCode(code *entity.OtpCode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for _, c := range m.codes {
+		if c.Phone == code.Phone && c.UsedAt == nil {
+			c.UsedAt = &now
+		}
+	}
+	m.seq++
+	code.ID = m.seq
+	code.CreatedAt = now
+	m.codes = append(m.codes, code)
+	return nil
+}
+
+func (m *memoryStore) Purge(now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.codes[:0]
+	for _, c := range m.codes {
+		if !c.ExpiresAt.Before(now) {
+			kept = append(kept, c)
+		}
+	}
+	m.codes = kept
+	return nil
+}
+
+func (m *memoryStore) GetActiveCode(phone string) (*entity.OtpCode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.codes) - 1; i >= 0; i-- {
+		if c := m.codes[i]; c.Phone == phone && c.UsedAt == nil {
+			active := *c
+			return &active, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryStore) AddAttempt(id uint, maxAttempts int) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := m.find(id)
+	if c == nil || c.UsedAt != nil || c.Attempts >= maxAttempts {
+		return false, nil
+	}
+	c.Attempts++
+	return true, nil
+}
+
+func (m *memoryStore) UseCode(id uint, now time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c := m.find(id)
+	if c == nil || c.UsedAt != nil {
+		return false, nil
+	}
+	c.UsedAt = &now
+	return true, nil
+}
+
+func (m *memoryStore) find(id uint) *entity.OtpCode {
+	for _, c := range m.codes {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package otp
+
+import (
+	"crypto/subtle"
+	"errors"
+	"log"
+	"project/config"
+	"project/domain/entity"
+	"time"
+)
+
+// DBProvider makes its own codes and keeps their hashes in a CodeStore.
+// A code lasts ttl, is good for one successful check and is retired after
+// maxAttempts checks or when a new one is sent to the phone.
+type DBProvider struct {
+	store       CodeStore
+	ttl         time.Duration
+	maxAttempts int
+	// Deliver hands every code to the user. Nil fails Send.
+	Deliver func(phone, code string) error
+}
+
+func NewDBProvider(store CodeStore, cfg *config.OTP) *DBProvider {
+	p := &DBProvider{store: store, ttl: cfg.TTL, maxAttempts: cfg.MaxAttempts}
+	if p.ttl <= 0 {
+		p.ttl = defaultTTL
+	}
+	if p.maxAttempts <= 0 {
+		p.maxAttempts = defaultMaxAttempts
+	}
+	return p
+}
+
+// StartPurger drops expired codes now and then every interval. It blocks,
+// so run it in its own goroutine.
+func (p *DBProvider) StartPurger(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := p.store.Purge(time.Now()); err != nil {
+			log.Printf("otp purger: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+func (p *DBProvider) Send(phone string) (string, error) {
+	if p.Deliver == nil {
+		return "", errors.New("no otp sender configured")
+	}
+	code, err := newCode()
+	if err != nil {
+		return "", err
+	}
+	key, err := newKey()
+	if err != nil {
+		return "", err
+	}
+	err = p.store.CreateCode(&entity.OtpCode{
+		Phone:     phone,
+		CodeHash:  hashCode(phone, code),
+		ExpiresAt: time.Now().Add(p.ttl),
+	})
+	if err != nil {
+		log.Printf("saving otp failed: %v", err)
+		return "", errors.New("failed to generate otp")
+	}
+	if err := p.Deliver(phone, code); err != nil {
+		log.Printf("delivering otp failed: %v", err)
+		return "", errors.New("failed to send otp")
+	}
+	return key, nil
+}
+
+func (p *DBProvider) Check(phone, code string) error {
+	if code == "" {
+		return errors.New("OTP code is empty")
+	}
+	now := time.Now()
+	active, err := p.store.GetActiveCode(phone)
+	if err != nil {
+		return err
+	}
+	if active == nil {
+		return ErrInvalidOtp
+	}
+	if now.After(active.ExpiresAt) {
+		return ErrOtpExpired
+	}
+	counted, err := p.store.AddAttempt(active.ID, p.maxAttempts)
+	if err != nil {
+		return err
+	}
+	if !counted {
+		return ErrTooManyAttempts
+	}
+	if subtle.ConstantTimeCompare([]byte(hashCode(phone, code)), []byte(active.CodeHash)) != 1 {
+		return ErrInvalidOtp
+	}
+	used, err := p.store.UseCode(active.ID, now)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidOtp
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Package otp sends the one time passwords users confirm their phone
+// with. Which provider sends them is picked by OTPPROVIDER: Twilio Verify,
+// codes kept in our own database, or the console for local development.
+package otp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"os"
+	"project/config"
+	"project/domain/entity"
+	"strings"
+	"time"
+)
+
+// OTPProvider sends a code to a phone and checks the code the user types.
+type OTPProvider interface {
+	// Send sends a new code to the phone and returns a key identifying the
+	// request.
+	Send(phone string) (string, error)
+	// Check verifies the code the user got on the phone.
+	Check(phone, code string) error
+}
+
+const (
+	ProviderTwilio  = "twilio"
+	ProviderDB      = "db"
+	ProviderConsole = "console"
+)
+
+const (
+	defaultCountryCode = "+91"
+	defaultTTL         = 5 * time.Minute
+	defaultMaxAttempts = 5
+	codeDigits         = 6
+)
+
+var (
+	ErrInvalidOtp      = errors.New("invalid otp")
+	ErrOtpExpired      = errors.New("otp has expired")
+	ErrTooManyAttempts = errors.New("too many wrong otp attempts, request a new one")
+)
+
+// CodeStore keeps the codes of the providers that make their own.
+type CodeStore interface {
+	CreateCode(code *entity.OtpCode) error
+	GetActiveCode(phone string) (*entity.OtpCode, error)
+	AddAttempt(id uint, maxAttempts int) (bool, error)
+	UseCode(id uint, now time.Time) (bool, error)
+	// Purge drops the codes that expired before now.
+	Purge(now time.Time) error
+}
+
+// New returns the provider OTPPROVIDER names. Without one it is Twilio when
+// its credentials are set, and the console otherwise. store keeps the codes
+// of the db provider.
+func New(cfg *config.OTP, store CodeStore) (OTPProvider, error) {
+	provider := cfg.Provider
+	if provider == "" {
+		provider = ProviderTwilio
+		if cfg.AccountSid == "" {
+			log.Println("OTPPROVIDER not set and Twilio is not configured, otps are written to the console")
+			provider = ProviderConsole
+		}
+	}
+	switch provider {
+	case ProviderTwilio:
+		return NewTwilio(cfg), nil
+	case ProviderDB:
+		w, err := codeWriter(cfg)
+		if err != nil {
+			return nil, err
+		}
+		p := NewDBProvider(store, cfg)
+		p.Deliver = writeTo(w)
+		return p, nil
+	case ProviderConsole:
+		w, err := codeWriter(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return NewConsole(w, cfg), nil
+	}
+	return nil, fmt.Errorf("unknown otp provider %q", provider)
+}
+
+// codeWriter is where the codes of the self-hosted providers are written:
+// the file OTPFILE, or else standard output.
+func codeWriter(cfg *config.OTP) (io.Writer, error) {
+	if cfg.File == "" {
+		return os.Stdout, nil
+	}
+	f, err := os.OpenFile(cfg.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening otp file: %w", err)
+	}
+	return f, nil
+}
+
+func writeTo(w io.Writer) func(phone, code string) error {
+	return func(phone, code string) error {
+		_, err := fmt.Fprintf(w, "%s otp for %s: %s\n", time.Now().Format(time.RFC3339), phone, code)
+		return err
+	}
+}
+
+// international returns the phone in E.164 form, adding the country code
+// to local numbers.
+func international(phone, countryCode string) string {
+	if strings.HasPrefix(phone, "+") {
+		return phone
+	}
+	if countryCode == "" {
+		countryCode = defaultCountryCode
+	}
+	return countryCode + phone
+}
+
+func newCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < codeDigits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", errors.New("failed to generate otp")
+	}
+	return fmt.Sprintf("%0*d", codeDigits, n), nil
+}
+
+func newKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New("failed to generate otp")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashCode(phone, code string) string {
+	sum := sha256.Sum256([]byte(phone + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
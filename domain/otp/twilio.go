@@ -0,0 +1,56 @@
+package otp
+
+import (
+	"errors"
+	"log"
+	"project/config"
+
+	"github.com/twilio/twilio-go"
+	openapi "github.com/twilio/twilio-go/rest/verify/v2"
+)
+
+// Twilio sends codes with Twilio Verify, which keeps and checks them.
+type Twilio struct {
+	client      *twilio.RestClient
+	serviceSid  string
+	countryCode string
+}
+
+func NewTwilio(cfg *config.OTP) *Twilio {
+	client := twilio.NewRestClientWithParams(twilio.ClientParams{
+		Username: cfg.AccountSid,
+		Password: cfg.AuthToken,
+	})
+	return &Twilio{client: client, serviceSid: cfg.ServiceSid, countryCode: cfg.CountryCode}
+}
+
+// Send returns the id of the Twilio verification.
+func (t *Twilio) Send(phone string) (string, error) {
+	params := &openapi.CreateVerificationParams{}
+	params.SetTo(international(phone, t.countryCode))
+	params.SetChannel("sms")
+	resp, err := t.client.VerifyV2.CreateVerification(t.serviceSid, params)
+	if err != nil {
+		log.Printf("twilio verification failed: %v", err)
+		return "", errors.New("failed to generate otp")
+	}
+	return *resp.Sid, nil
+}
+
+func (t *Twilio) Check(phone, code string) error {
+	if code == "" {
+		return errors.New("OTP code is empty")
+	}
+	params := &openapi.CreateVerificationCheckParams{}
+	params.SetTo(international(phone, t.countryCode))
+	params.SetCode(code)
+	resp, err := t.client.VerifyV2.CreateVerificationCheck(t.serviceSid, params)
+	if err != nil {
+		log.Printf("twilio verification check failed: %v", err)
+		return ErrInvalidOtp
+	}
+	if resp.Status == nil || *resp.Status != "approved" {
+		return ErrInvalidOtp
+	}
+	return nil
+}
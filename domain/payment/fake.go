@@ -0,0 +1,241 @@
+package payment
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"project/domain/money"
+	"strings"
+	"sync"
+)
+
+// fakeSecret signs the fake gateway's payments and webhooks.
+const fakeSecret = "fake_gateway_secret"
+
+type fakeIntent struct {
+	amount    money.Money
+	currency  string
+	metadata  map[string]string
+	paymentId string
+	status    string
+	refunded  money.Money
+}
+
+// FakeGateway is an in-process gateway for tests and local development. It
+// keeps intents in memory and sends its webhooks through Deliver. Pay and
+// Fail stand in for the customer at the provider's checkout.
+type FakeGateway struct {
+	name string
+	// Deliver receives every webhook the gateway sends. Nil drops them.
+	Deliver func(payload []byte, header http.Header)
+	// DeclineRefunds makes every refund fail, to exercise retries.
+	DeclineRefunds bool
+
+	mu      sync.Mutex
+	seq     int
+	intents map[string]*fakeIntent
+	// payments maps a payment id to its intent id.
+	payments map[string]string
+}
+
+func NewFakeGateway(name string) *FakeGateway {
+	return &FakeGateway{name: name, intents: map[string]*fakeIntent{}, payments: map[string]string{}}
+}
+
+func (f *FakeGateway) Name() string { return f.name }
+
+func (f *FakeGateway) nextId(prefix string) string {
+	f.seq++
+	return fmt.Sprintf("%s_fake%06d", prefix, f.seq)
+}
+
+func (f *FakeGateway) CreateIntent(amount money.Money, currency string, metadata map[string]string) (*Intent, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	f.mu.Lock()
+	id := f.nextId("intent")
+	f.intents[id] = &fakeIntent{amount: amount, currency: currency, metadata: metadata, status: "created"}
+	event := Event{Id: f.nextId("evt"), Type: EventIntentCreated, IntentId: id, Metadata: metadata}
+	f.mu.Unlock()
+	f.send(event)
+	return &Intent{Id: id, ClientSecret: id + "_secret", Amount: amount, Currency: currency}, nil
+}
+
+// Pay completes an intent as the customer would and returns the payment id
+// and signature the browser hands to VerifyPayment.
+func (f *FakeGateway) Pay(intentId string) (string, string, error) {
+	f.mu.Lock()
+	intent, ok := f.intents[intentId]
+	if !ok {
+		f.mu.Unlock()
+		return "", "", errors.New("intent not found")
+	}
+	if intent.status == "paid" {
+		f.mu.Unlock()
+		return "", "", errors.New("intent is already paid")
+	}
+	paymentId := f.nextId("pay")
+	intent.paymentId = paymentId
+	intent.status = "paid"
+	f.payments[paymentId] = intentId
+	event := Event{Id: f.nextId("evt"), Type: EventPaymentSucceeded, IntentId: intentId, PaymentId: paymentId, Metadata: intent.metadata}
+	f.mu.Unlock()
+	f.send(event)
+	return paymentId, f.Sign(intentId, paymentId), nil
+}
+
+// Fail declines the customer's payment on an intent.
+func (f *FakeGateway) Fail(intentId string) error {
+	f.mu.Lock()
+	intent, ok := f.intents[intentId]
+	if !ok || intent.status == "paid" {
+		f.mu.Unlock()
+		return errors.New("intent cannot fail")
+	}
+	intent.status = "failed"
+	event := Event{Id: f.nextId("evt"), Type: EventPaymentFailed, IntentId: intentId, Metadata: intent.metadata}
+	f.mu.Unlock()
+	f.send(event)
+	return nil
+}
+
+// Sign is the signature the fake gateway gives a payment.
+func (f *FakeGateway) Sign(intentId, paymentId string) string {
+	return sign([]byte(intentId+"|"+paymentId), fakeSecret)
+}
+
+func (f *FakeGateway) VerifyPayment(intentId, paymentId string, signature string) error {
+	f.mu.Lock()
+	intent, ok := f.intents[intentId]
+	paid := ok && intent.paymentId == paymentId
+	f.mu.Unlock()
+	if !paid || !validSignature([]byte(intentId+"|"+paymentId), signature, fakeSecret) {
+		return errors.New("Payment failed")
+	}
+	return nil
+}
+
+// Capture accepts any paid payment; fake payments are captured when paid.
+func (f *FakeGateway) Capture(paymentId string, amount money.Money) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	intent, ok := f.intents[f.payments[paymentId]]
+	if !ok || amount > intent.amount {
+		return errors.New("payment not found")
+	}
+	return nil
+}
+
+// Refund settles straight away. Both payment ids and intent ids are
+// accepted, as the stripe adapter refunds by intent id.
+func (f *FakeGateway) Refund(paymentId string, amount money.Money) (*Refund, error) {
+	f.mu.Lock()
+	if f.DeclineRefunds {
+		f.mu.Unlock()
+		return nil, errors.New("refund declined")
+	}
+	intentId, ok := f.payments[paymentId]
+	if !ok {
+		intentId = paymentId
+	}
+	intent, ok := f.intents[intentId]
+	if !ok || intent.status != "paid" {
+		f.mu.Unlock()
+		return nil, errors.New("payment not found")
+	}
+	if amount <= 0 || intent.refunded+amount > intent.amount {
+		f.mu.Unlock()
+		return nil, errors.New("refund exceeds the amount paid")
+	}
+	intent.refunded += amount
+	refund := &Refund{Id: f.nextId("rfnd"), Processed: true}
+	event := Event{Id: f.nextId("evt"), Type: EventRefundProcessed, IntentId: intentId, PaymentId: intent.paymentId, RefundId: refund.Id}
+	f.mu.Unlock()
+	f.send(event)
+	return refund, nil
+}
+
+// ParseWebhook reads the events the fake gateway sends: an Event as JSON,
+// signed in the X-Fake-Signature header.
+func (f *FakeGateway) ParseWebhook(payload []byte, header http.Header) (*Event, error) {
+	if !validSignature(payload, header.Get("X-Fake-Signature"), fakeSecret) {
+		return nil, errors.New("invalid webhook signature")
+	}
+	var event Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, errors.New("error parsing webhook JSON")
+	}
+	return &event, nil
+}
+
+func (f *FakeGateway) send(event Event) {
+	if f.Deliver == nil {
+		return
+	}
+	payload, _ := json.Marshal(event)
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("X-Fake-Signature", sign(payload, fakeSecret))
+	f.Deliver(payload, header)
+}
+
+// PostTo delivers the fake gateway's webhooks to url in the background, the
+// way a real provider would call the shop.
+func PostTo(url string) func(payload []byte, header http.Header) {
+	return func(payload []byte, header http.Header) {
+		go func() {
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+			if err != nil {
+				log.Printf("fake gateway webhook: %v", err)
+				return
+			}
+			req.Header = header
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				log.Printf("fake gateway webhook: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+}
+
+type fakePayment struct {
+	PaymentId string `json:"payment_id"`
+	Signature string `json:"signature"`
+}
+
+// Handler lets a developer act as the customer over HTTP:
+// POST /pay/{intent} and POST /fail/{intent}.
+func (f *FakeGateway) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pay/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		paymentId, signature, err := f.Pay(strings.TrimPrefix(r.URL.Path, "/pay/"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(fakePayment{PaymentId: paymentId, Signature: signature})
+	})
+	mux.HandleFunc("/fail/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if err := f.Fail(strings.TrimPrefix(r.URL.Path, "/fail/")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
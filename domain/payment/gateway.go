@@ -0,0 +1,83 @@
+// Package payment hides the payment providers behind one interface so
+// checkout, refunds and webhooks do not depend on a particular SDK.
+package payment
+
+import (
+	"errors"
+	"net/http"
+	"project/config"
+	"project/domain/money"
+)
+
+// Event types a gateway webhook is translated to. Events the shop does not
+// act on come back with an empty Type.
+const (
+	EventIntentCreated     = "intent.created"
+	EventPaymentAuthorized = "payment.authorized"
+	EventPaymentSucceeded  = "payment.succeeded"
+	EventPaymentFailed     = "payment.failed"
+	EventRefundProcessed   = "refund.processed"
+)
+
+// ErrNoWebhookSecret refuses webhooks while the provider's webhook secret is
+// not configured, as anyone could sign them with an empty key.
+var ErrNoWebhookSecret = errors.New("webhook secret is not configured")
+
+// Intent is a payment the customer has been asked to make: a razorpay order
+// or a stripe payment intent.
+type Intent struct {
+	Id string
+	// ClientSecret lets the browser confirm a stripe intent. It is empty
+	// for razorpay.
+	ClientSecret string
+	Amount       money.Money
+	Currency     string
+}
+
+// Refund is a refund the gateway accepted. Processed is false while the
+// gateway is still settling it.
+type Refund struct {
+	Id        string
+	Processed bool
+}
+
+// Event is a verified webhook event.
+type Event struct {
+	Id        string            `json:"id"`
+	Type      string            `json:"type"`
+	IntentId  string            `json:"intent_id"`
+	PaymentId string            `json:"payment_id"`
+	RefundId  string            `json:"refund_id"`
+	Metadata  map[string]string `json:"metadata"`
+}
+
+type PaymentGateway interface {
+	// Name is the provider the shop records the gateway's webhook events under.
+	Name() string
+	// CreateIntent asks for amount, which the gateway is sent in paise.
+	CreateIntent(amount money.Money, currency string, metadata map[string]string) (*Intent, error)
+	// VerifyPayment checks the payment the browser reports for an intent.
+	VerifyPayment(intentId, paymentId, signature string) error
+	Capture(paymentId string, amount money.Money) error
+	Refund(paymentId string, amount money.Money) (*Refund, error)
+	// ParseWebhook verifies the signature of a webhook request and
+	// translates its body.
+	ParseWebhook(payload []byte, header http.Header) (*Event, error)
+}
+
+const (
+	ProviderRazorpay = "razorpay"
+	ProviderStripe   = "stripe"
+)
+
+// New returns the gateway for a provider. With PAYMENTGATEWAY=fake every
+// provider is served by an in-process FakeGateway instead.
+func New(provider string, cfg *config.Config) PaymentGateway {
+	if cfg.Payment.Gateway == "fake" {
+		return NewFakeGateway(provider)
+	}
+	if provider == ProviderStripe {
+		return NewStripe(&cfg.Stripe)
+	}
+	return NewRazorpay(&cfg.Razopay)
+}
@@ -0,0 +1,147 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"project/config"
+	"project/domain/money"
+
+	razorpay "github.com/razorpay/razorpay-go"
+)
+
+type Razorpay struct {
+	client        *razorpay.Client
+	secret        string
+	webhookSecret string
+}
+
+func NewRazorpay(cfg *config.Razopay) *Razorpay {
+	client := razorpay.NewClient(cfg.RazopayKey, cfg.RazopaySecret)
+	if cfg.RazopayBaseURL != "" {
+		// The resources share one request, so this moves all of them.
+		client.Order.Request.BaseURL = cfg.RazopayBaseURL
+	}
+	return &Razorpay{client: client, secret: cfg.RazopaySecret, webhookSecret: cfg.RazopayWebhookSecret}
+}
+
+func (r *Razorpay) Name() string { return ProviderRazorpay }
+
+func (r *Razorpay) CreateIntent(amount money.Money, currency string, metadata map[string]string) (*Intent, error) {
+	notes := map[string]interface{}{}
+	for k, v := range metadata {
+		notes[k] = v
+	}
+	data := map[string]interface{}{
+		"amount":   amount.Minor(),
+		"currency": currency,
+		"notes":    notes,
+	}
+	body, err := r.client.Order.Create(data, nil)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := body["id"].(string)
+	if id == "" {
+		return nil, errors.New("razorpay returned no order id")
+	}
+	return &Intent{Id: id, Amount: amount, Currency: currency}, nil
+}
+
+// VerifyPayment checks the razorpay_signature the checkout form returns,
+// an HMAC of "order_id|payment_id" with the key secret.
+func (r *Razorpay) VerifyPayment(intentId, paymentId, signature string) error {
+	if !validSignature([]byte(intentId+"|"+paymentId), signature, r.secret) {
+		return errors.New("Payment failed")
+	}
+	return nil
+}
+
+func (r *Razorpay) Capture(paymentId string, amount money.Money) error {
+	_, err := r.client.Payment.Capture(paymentId, int(amount.Minor()), nil, nil)
+	return err
+}
+
+func (r *Razorpay) Refund(paymentId string, amount money.Money) (*Refund, error) {
+	body, err := r.client.Payment.Refund(paymentId, int(amount.Minor()), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	id, _ := body["id"].(string)
+	status, _ := body["status"].(string)
+	return &Refund{Id: id, Processed: status == "processed"}, nil
+}
+
+// razorpayWebhook is the part of a Razorpay webhook body the shop reads.
+type razorpayWebhook struct {
+	Event   string `json:"event"`
+	Payload struct {
+		Payment struct {
+			Entity struct {
+				Id      string `json:"id"`
+				OrderId string `json:"order_id"`
+				Status  string `json:"status"`
+			} `json:"entity"`
+		} `json:"payment"`
+		Refund struct {
+			Entity struct {
+				Id        string `json:"id"`
+				PaymentId string `json:"payment_id"`
+				Status    string `json:"status"`
+			} `json:"entity"`
+		} `json:"refund"`
+	} `json:"payload"`
+}
+
+var razorpayEvents = map[string]string{
+	"payment.authorized": EventPaymentAuthorized,
+	"payment.captured":   EventPaymentSucceeded,
+	"payment.failed":     EventPaymentFailed,
+	"refund.processed":   EventRefundProcessed,
+}
+
+// ParseWebhook checks X-Razorpay-Signature against the webhook secret. The
+// event id comes from X-Razorpay-Event-Id, or a hash of the body when the
+// header is missing so redeliveries still match.
+func (r *Razorpay) ParseWebhook(payload []byte, header http.Header) (*Event, error) {
+	if r.webhookSecret == "" {
+		return nil, ErrNoWebhookSecret
+	}
+	if !validSignature(payload, header.Get("X-Razorpay-Signature"), r.webhookSecret) {
+		return nil, errors.New("invalid webhook signature")
+	}
+	var body razorpayWebhook
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, errors.New("error parsing webhook JSON")
+	}
+	id := header.Get("X-Razorpay-Event-Id")
+	if id == "" {
+		sum := sha256.Sum256(payload)
+		id = hex.EncodeToString(sum[:])
+	}
+	return &Event{
+		Id:        id,
+		Type:      razorpayEvents[body.Event],
+		IntentId:  body.Payload.Payment.Entity.OrderId,
+		PaymentId: body.Payload.Payment.Entity.Id,
+		RefundId:  body.Payload.Refund.Entity.Id,
+	}, nil
+}
+
+func sign(data []byte, secret string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// validSignature never accepts a signature made with an empty secret.
+func validSignature(data []byte, signature, secret string) bool {
+	if secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(sign(data, secret)), []byte(signature)) == 1
+}
@@ -0,0 +1,49 @@
+package payment
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"project/config"
+	"project/domain/money"
+	"testing"
+)
+
+func TestRazorpayRefund(t *testing.T) {
+	var path string
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		if key, secret, ok := r.BasicAuth(); !ok || key != "rzp_key" || secret != "rzp_secret" {
+			t.Errorf("request not authenticated with the key")
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		if path == "/v1/payments/pay_declined/refund" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"code":"BAD_REQUEST_ERROR","description":"The payment could not be refunded"}}`))
+			return
+		}
+		w.Write([]byte(`{"id":"rfnd_1","entity":"refund","status":"processed"}`))
+	}))
+	defer server.Close()
+	razorpay := NewRazorpay(&config.Razopay{RazopayKey: "rzp_key", RazopaySecret: "rzp_secret", RazopayBaseURL: server.URL})
+
+	refund, err := razorpay.Refund("pay_1", money.Money(125050))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1/payments/pay_1/refund" {
+		t.Errorf("refund posted to %s", path)
+	}
+	if amount, _ := body["amount"].(float64); amount != 125050 {
+		t.Errorf("refund amount sent is %v paise, want 125050", body["amount"])
+	}
+	if refund.Id != "rfnd_1" || !refund.Processed {
+		t.Errorf("refund is %+v, want processed rfnd_1", refund)
+	}
+
+	if _, err := razorpay.Refund("pay_declined", money.FromMajor(10)); err == nil {
+		t.Error("declined refund returned no error")
+	}
+}
@@ -0,0 +1,115 @@
+package payment
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"project/config"
+	"project/domain/money"
+	"strings"
+
+	"github.com/stripe/stripe-go"
+	"github.com/stripe/stripe-go/client"
+	"github.com/stripe/stripe-go/webhook"
+)
+
+type Stripe struct {
+	api           *client.API
+	webhookSecret string
+}
+
+// NewStripe uses its own client rather than the package level stripe.Key.
+func NewStripe(cfg *config.Stripe) *Stripe {
+	return &Stripe{api: client.New(cfg.StripeSecretKey, nil), webhookSecret: cfg.StripeWebhookSecret}
+}
+
+func (s *Stripe) Name() string { return ProviderStripe }
+
+func (s *Stripe) CreateIntent(amount money.Money, currency string, metadata map[string]string) (*Intent, error) {
+	params := &stripe.PaymentIntentParams{
+		Params:   stripe.Params{Metadata: metadata},
+		Amount:   stripe.Int64(amount.Minor()),
+		Currency: stripe.String(strings.ToLower(currency)),
+	}
+	intent, err := s.api.PaymentIntents.New(params)
+	if err != nil {
+		return nil, err
+	}
+	return &Intent{Id: intent.ID, ClientSecret: intent.ClientSecret, Amount: amount, Currency: currency}, nil
+}
+
+// VerifyPayment asks stripe for the intent's status. Stripe confirms the
+// payment itself, so there is no signature to check.
+func (s *Stripe) VerifyPayment(intentId, paymentId, signature string) error {
+	intent, err := s.api.PaymentIntents.Get(intentId, nil)
+	if err != nil {
+		return err
+	}
+	if intent.Status != stripe.PaymentIntentStatusSucceeded && intent.Status != stripe.PaymentIntentStatusRequiresCapture {
+		return errors.New("Payment failed")
+	}
+	return nil
+}
+
+// Capture takes an authorised intent. Stripe payments are referred to by
+// their intent id.
+func (s *Stripe) Capture(paymentId string, amount money.Money) error {
+	_, err := s.api.PaymentIntents.Capture(paymentId, &stripe.PaymentIntentCaptureParams{
+		AmountToCapture: stripe.Int64(amount.Minor()),
+	})
+	return err
+}
+
+func (s *Stripe) Refund(paymentId string, amount money.Money) (*Refund, error) {
+	refund, err := s.api.Refunds.New(&stripe.RefundParams{
+		PaymentIntent: stripe.String(paymentId),
+		Amount:        stripe.Int64(amount.Minor()),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Refund{Id: refund.ID, Processed: refund.Status == stripe.RefundStatusSucceeded}, nil
+}
+
+var stripeEvents = map[string]string{
+	"payment_intent.created":                   EventIntentCreated,
+	"payment_intent.amount_capturable_updated": EventPaymentAuthorized,
+	"payment_intent.succeeded":                 EventPaymentSucceeded,
+	"payment_intent.payment_failed":            EventPaymentFailed,
+}
+
+// ParseWebhook checks the Stripe-Signature header against the endpoint
+// secret.
+func (s *Stripe) ParseWebhook(payload []byte, header http.Header) (*Event, error) {
+	if s.webhookSecret == "" {
+		return nil, ErrNoWebhookSecret
+	}
+	event, err := webhook.ConstructEvent(payload, header.Get("Stripe-Signature"), s.webhookSecret)
+	if err != nil {
+		return nil, err
+	}
+	result := &Event{Id: event.ID}
+	if event.Type == "charge.refund.updated" {
+		var refund stripe.Refund
+		if err := json.Unmarshal(event.Data.Raw, &refund); err != nil {
+			return nil, errors.New("error parsing webhook JSON")
+		}
+		if refund.Status == stripe.RefundStatusSucceeded {
+			result.Type = EventRefundProcessed
+			result.RefundId = refund.ID
+		}
+		return result, nil
+	}
+	result.Type = stripeEvents[event.Type]
+	if result.Type == "" {
+		return result, nil
+	}
+	var intent stripe.PaymentIntent
+	if err := json.Unmarshal(event.Data.Raw, &intent); err != nil {
+		return nil, errors.New("error parsing webhook JSON")
+	}
+	result.IntentId = intent.ID
+	result.PaymentId = intent.ID
+	result.Metadata = intent.Metadata
+	return result, nil
+}
@@ -0,0 +1,38 @@
+package payment
+
+import (
+	"errors"
+	"net/http"
+	"project/config"
+	"testing"
+)
+
+// TestWebhookNeedsSecret refuses webhooks signed with the empty key a
+// gateway without a webhook secret would otherwise accept.
+func TestWebhookNeedsSecret(t *testing.T) {
+	payload := []byte(`{"event":"payment.captured","payload":{"payment":{"entity":{"id":"pay_1","order_id":"order_1"}}}}`)
+	header := http.Header{}
+	header.Set("X-Razorpay-Signature", sign(payload, ""))
+
+	razorpay := NewRazorpay(&config.Razopay{})
+	if _, err := razorpay.ParseWebhook(payload, header); !errors.Is(err, ErrNoWebhookSecret) {
+		t.Errorf("razorpay without a webhook secret: got %v, want ErrNoWebhookSecret", err)
+	}
+	stripe := NewStripe(&config.Stripe{})
+	if _, err := stripe.ParseWebhook(payload, header); !errors.Is(err, ErrNoWebhookSecret) {
+		t.Errorf("stripe without a webhook secret: got %v, want ErrNoWebhookSecret", err)
+	}
+
+	razorpay = NewRazorpay(&config.Razopay{RazopayWebhookSecret: "whsec"})
+	if _, err := razorpay.ParseWebhook(payload, header); err == nil {
+		t.Error("razorpay accepted a webhook signed with an empty key")
+	}
+	header.Set("X-Razorpay-Signature", sign(payload, "whsec"))
+	event, err := razorpay.ParseWebhook(payload, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != EventPaymentSucceeded || event.PaymentId != "pay_1" {
+		t.Errorf("event is %+v, want payment pay_1 succeeded", event)
+	}
+}
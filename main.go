@@ -7,18 +7,27 @@ import (
 	_ "project/cmd/api/docs"
 	"project/config"
 	"project/delivery/handlers"
+	"project/delivery/middleware"
 	"project/delivery/routes"
+	"project/domain/otp"
+	"project/domain/payment"
 	adminrepository "project/repository/admin"
 	cartrepository "project/repository/cart"
 	"project/repository/infrastructure"
 	orderrepository "project/repository/order"
+	otprepository "project/repository/otp"
 	productrepository "project/repository/product"
+	tokenrepository "project/repository/token"
 	repository "project/repository/user"
+	walletrepository "project/repository/wallet"
 	adminUseCase "project/usecase/admin"
+	"project/usecase/auth"
 	cartusecase "project/usecase/cart"
 	orderusecase "project/usecase/order"
 	productusecase "project/usecase/product"
 	usecase "project/usecase/user"
+	walletusecase "project/usecase/wallet"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -46,21 +55,48 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	middleware.SetGuestSecret(config.Guest.GuestSecret)
+	sessions := auth.NewSessions(tokenrepository.NewTokenRepository(db), &config.JWT)
+	middleware.SetSessions(sessions)
+	go sessions.StartPurger(time.Hour)
 	userRepo := repository.NewUserRepository(db)
 	adminRepo := adminrepository.NewAdminRepository(db)
 	productRepo := productrepository.NewProductRepository(db)
 	cartRepo := cartrepository.NewCartRepository(db)
 	orderRepo := orderrepository.NewOrderRepository(db)
+	walletRepo := walletrepository.NewWalletRepository(db)
 
-	userusecase := usecase.NewUser(userRepo, &config.Otp)
+	otpProvider, err := otp.New(&config.Otp, otprepository.NewOtpRepository(db))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if codes, ok := otpProvider.(*otp.DBProvider); ok {
+		go codes.StartPurger(time.Hour)
+	}
+
+	userusecase := usecase.NewUser(userRepo, walletRepo, otpProvider)
 	adminUseCase := adminUseCase.NewAdmin(adminRepo)
+	middleware.SetPermissionChecker(adminUseCase)
 	productUsecase := productusecase.NewProduct(productRepo, &config.S3aws)
-	cartUsecase := cartusecase.NewCart(cartRepo, productRepo)
-	orderUsecase := orderusecase.NewOrder(orderRepo, cartRepo, userRepo, productRepo, &config.Razopay)
+	cartUsecase := cartusecase.NewCart(cartRepo, productRepo, userRepo, orderRepo, &config.Seller)
+	razorpayGateway := payment.New(payment.ProviderRazorpay, config)
+	stripeGateway := payment.New(payment.ProviderStripe, config)
+	// Fake gateways post their webhooks back to this server.
+	if fake, ok := razorpayGateway.(*payment.FakeGateway); ok {
+		fake.Deliver = payment.PostTo("http://localhost:8080/webhook/razorpay")
+	}
+	if fake, ok := stripeGateway.(*payment.FakeGateway); ok {
+		fake.Deliver = payment.PostTo("http://localhost:8080/webhook")
+	}
+	orderUsecase := orderusecase.NewOrder(orderRepo, cartRepo, userRepo, productRepo, walletRepo, razorpayGateway, stripeGateway, &config.Seller)
+	go orderUsecase.StartReservationSweeper(time.Minute)
+	go productUsecase.StartOfferScheduler(time.Minute)
+	walletUsecase := walletusecase.NewWallet(walletRepo, userRepo)
 
 	userHandler := handlers.NewUserhandler(userusecase, productUsecase, cartUsecase)
 	adminHandler := handlers.NewAdminHandler(adminUseCase, productUsecase)
-	orderHandler := handlers.NewOrderHandler(orderUsecase, config.Razopay)
+	orderHandler := handlers.NewOrderHandler(orderUsecase)
+	walletHandler := handlers.NewWalletHandler(walletUsecase)
 
 	router := gin.Default()
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -68,6 +104,8 @@ func main() {
 	routes.UserRouter(router, userHandler)
 	routes.AdminRouter(router, adminHandler)
 	routes.OrderRouter(router, orderHandler)
+	routes.WalletRouter(router, walletHandler)
+	routes.FakeGatewayRouter(router, razorpayGateway, stripeGateway)
 
 	router.LoadHTMLGlob("template/*.html")
 	fmt.Println("Templates loaded from:", "template/*.html")
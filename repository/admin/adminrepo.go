@@ -4,6 +4,7 @@ import (
 	"errors"
 	"log"
 	"project/domain/entity"
+	"project/domain/money"
 	"time"
 
 	"gorm.io/gorm"
@@ -104,35 +105,35 @@ func (ar *AdminRepository) GetProducts() (int, int, error) {
 	return int(totalproducts), int(stocklessProducts), nil
 }
 
-func (ar *AdminRepository) GetOrders() (int, int, error) {
+func (ar *AdminRepository) GetOrders() (int, money.Money, error) {
 	var totalorders int64
-	var totalamount float64
+	var averageorder money.Money
 
 	if err := ar.db.Model(&entity.Order{}).Count(&totalorders).Error; err != nil {
 		return 0, 0, err
 	}
-	if err := ar.db.Model(&entity.Order{}).Select("AVG(total)").Row().Scan(&totalamount); err != nil {
+	if err := ar.db.Model(&entity.Order{}).Select("COALESCE(ROUND(AVG(total)), 0)::bigint").Row().Scan(&averageorder); err != nil {
 		return 0, 0, err
 	}
-	return int(totalorders), int(totalamount), nil
+	return int(totalorders), averageorder, nil
 }
 func (ar *AdminRepository) GetOrderByStatus() (int, int, error) {
 	var pendingorder, returnedorder int64
-	if err := ar.db.Model(&entity.Order{}).Where("status =?", "pending").Count(&pendingorder).Error; err != nil {
+	if err := ar.db.Model(&entity.Order{}).Where("status =?", entity.OrderPending).Count(&pendingorder).Error; err != nil {
 		return 0, 0, err
 	}
-	if err := ar.db.Model(&entity.Order{}).Where("status= ?", "return").Count(&returnedorder).Error; err != nil {
+	if err := ar.db.Model(&entity.Order{}).Where("status IN ?", []string{entity.OrderReturned, entity.OrderRefunded}).Count(&returnedorder).Error; err != nil {
 		return 0, 0, err
 	}
 	return int(pendingorder), int(returnedorder), nil
 }
-func (ar *AdminRepository) GetRevenue() (int, error) {
-	var totalrevenue int64
+func (ar *AdminRepository) GetRevenue() (money.Money, error) {
+	var totalrevenue money.Money
 
-	if err := ar.db.Model(&entity.Order{}).Where("status = ?", "confirmed").Select("COALESCE(SUM(total), 0)").Row().Scan(&totalrevenue); err != nil {
+	if err := ar.db.Model(&entity.Order{}).Where("status IN ?", entity.SalesStatuses).Select("COALESCE(SUM(total), 0)::bigint").Row().Scan(&totalrevenue); err != nil {
 		return 0, err
 	}
-	return int(totalrevenue), nil
+	return totalrevenue, nil
 }
 
 func (ar *AdminRepository) GetstocklessProducts() (*[]entity.Inventory, error) {
@@ -154,3 +155,78 @@ func (ar *AdminRepository) GetUsersBySearch(offset, limit int, search string) ([
 	}
 	return users, nil
 }
+
+func (ar *AdminRepository) GetAdminById(id int) (*entity.Admin, error) {
+	var admin entity.Admin
+	if err := ar.db.First(&admin, id).Error; err != nil {
+		return nil, err
+	}
+	return &admin, nil
+}
+
+func (ar *AdminRepository) GetAdmins() ([]entity.Admin, error) {
+	var admins []entity.Admin
+	err := ar.db.Order("id").Find(&admins).Error
+	return admins, err
+}
+
+func (ar *AdminRepository) GetBySetupToken(hash string) (*entity.Admin, error) {
+	var admin entity.Admin
+	if err := ar.db.Where("setup_token_hash = ?", hash).First(&admin).Error; err != nil {
+		return nil, err
+	}
+	return &admin, nil
+}
+
+func (ar *AdminRepository) UpdateAdmin(admin *entity.Admin) error {
+	return ar.db.Save(admin).Error
+}
+
+func (ar *AdminRepository) CountActiveSuperAdmins() (int64, error) {
+	var count int64
+	err := ar.db.Model(&entity.Admin{}).Where("role = ? AND is_active = ?", entity.AdminRoleSuper, true).Count(&count).Error
+	return count, err
+}
+
+func (ar *AdminRepository) GetRolePermissions() ([]entity.RolePermission, error) {
+	var permissions []entity.RolePermission
+	err := ar.db.Order("role, permission").Find(&permissions).Error
+	return permissions, err
+}
+
+func (ar *AdminRepository) HasRolePermission(role, permission string) (bool, error) {
+	var count int64
+	err := ar.db.Model(&entity.RolePermission{}).Where("role = ? AND permission = ?", role, permission).Count(&count).Error
+	return count > 0, err
+}
+
+func (ar *AdminRepository) RoleExists(role string) (bool, error) {
+	var count int64
+	err := ar.db.Model(&entity.RolePermission{}).Where("role = ?", role).Count(&count).Error
+	return count > 0, err
+}
+
+// SetRolePermissions replaces the permissions of the role.
+func (ar *AdminRepository) SetRolePermissions(role string, permissions []string) error {
+	return ar.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role = ?", role).Delete(&entity.RolePermission{}).Error; err != nil {
+			return err
+		}
+		for _, permission := range permissions {
+			if err := tx.Create(&entity.RolePermission{Role: role, Permission: permission}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (ar *AdminRepository) DeleteRole(role string) error {
+	return ar.db.Where("role = ?", role).Delete(&entity.RolePermission{}).Error
+}
+
+func (ar *AdminRepository) CountAdminsByRole(role string) (int64, error) {
+	var count int64
+	err := ar.db.Model(&entity.Admin{}).Where("role = ?", role).Count(&count).Error
+	return count, err
+}
@@ -15,6 +15,15 @@ func NewCartRepository(db *gorm.DB) *CartRepository {
 	return &CartRepository{db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction.
+func (cr *CartRepository) WithTx(tx *gorm.DB) *CartRepository {
+	return &CartRepository{tx}
+}
+
+func (cr *CartRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return cr.db.Transaction(fn)
+}
+
 func (cr *CartRepository) Create(userid int) (*entity.Cart, error) {
 	cart := &entity.Cart{
 		UserId: userid,
@@ -41,6 +50,26 @@ func (cr *CartRepository) GetByUserid(userid int) (*entity.Cart, error) {
 	return &cart, nil
 }
 
+func (cr *CartRepository) CreateGuest(guestid string) (*entity.Cart, error) {
+	cart := &entity.Cart{GuestId: guestid}
+	if err := cr.db.Create(cart).Error; err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+func (cr *CartRepository) GetByGuestId(guestid string) (*entity.Cart, error) {
+	var cart entity.Cart
+	if err := cr.db.Where("guest_id = ? AND user_id = 0", guestid).First(&cart).Error; err != nil {
+		return nil, err
+	}
+	return &cart, nil
+}
+
+func (cr *CartRepository) RemoveCart(cart *entity.Cart) error {
+	return cr.db.Delete(cart).Error
+}
+
 func (cr *CartRepository) CreateCartItem(cartitem *entity.CartItem) error {
 	if err := cr.db.Create(cartitem).Error; err != nil {
 		return err
@@ -68,6 +97,14 @@ func (cr *CartRepository) GetByName(productName string, cartId int) (*entity.Car
 	return &cartitem, nil
 }
 
+func (cr *CartRepository) GetByProductId(productid, cartId int) (*entity.CartItem, error) {
+	var cartitem entity.CartItem
+	if err := cr.db.Where("product_id = ? AND cart_id = ?", productid, cartId).First(&cartitem).Error; err != nil {
+		return nil, err
+	}
+	return &cartitem, nil
+}
+
 func (cr *CartRepository) GetAllCartItems(cartId int) ([]entity.CartItem, error) {
 	var cartitems []entity.CartItem
 	result := cr.db.Where("cart_id=?", cartId).Find(&cartitems)
@@ -92,6 +129,28 @@ func (cr *CartRepository) RemoveCartItems(cartid int) error {
 	return nil
 }
 
+func (cr *CartRepository) GetCartCoupons(cartid int) ([]entity.CartCoupon, error) {
+	var coupons []entity.CartCoupon
+	err := cr.db.Where("cart_id = ?", cartid).Order("id").Find(&coupons).Error
+	return coupons, err
+}
+
+func (cr *CartRepository) CreateCartCoupon(coupon *entity.CartCoupon) error {
+	return cr.db.Create(coupon).Error
+}
+
+func (cr *CartRepository) UpdateCartCoupon(coupon *entity.CartCoupon) error {
+	return cr.db.Save(coupon).Error
+}
+
+func (cr *CartRepository) RemoveCartCoupon(coupon *entity.CartCoupon) error {
+	return cr.db.Delete(coupon).Error
+}
+
+func (cr *CartRepository) RemoveCartCoupons(cartid int) error {
+	return cr.db.Where("cart_id = ?", cartid).Delete(&entity.CartCoupon{}).Error
+}
+
 func (cr *CartRepository) AddProductToWishlist(product *entity.WishList) error {
 	if err := cr.db.Create(product).Error; err != nil {
 		return err
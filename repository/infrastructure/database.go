@@ -16,11 +16,20 @@ var Dbco = "host=localhost user=postgres dbname=ecom password=2589 port=5432 ssl
 
 func ConnectDb(config config.DataBase) (*gorm.DB, error) {
 	psqlInfo := fmt.Sprintf("host=%s user=%s dbname=%s port=%s password=%s", config.DBHost, config.DBUser, config.DBName, config.DBPort, config.DBPassword)
-	db, err := gorm.Open(postgres.Open(psqlInfo), &gorm.Config{})
+	// TranslateError reports unique index violations as gorm.ErrDuplicatedKey.
+	db, err := gorm.Open(postgres.Open(psqlInfo), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to db : %w", err)
 	}
 	DB = db
-	DB.AutoMigrate(&entity.Admin{}, &entity.OtpKey{}, &models.Signup{}, &entity.User{}, &entity.Category{}, &entity.Product{}, &entity.ProductDetails{}, &entity.ProductInput{}, &entity.Inventory{}, &entity.CartItem{}, &entity.Cart{}, &entity.WishList{}, &entity.Order{}, &entity.OrderItem{}, &entity.UserAddress{}, &entity.Coupon{}, &entity.UsedCoupon{}, &entity.Offer{}, &entity.Inventory{}, &entity.Invoice{})
+	if err := Migrate(DB); err != nil {
+		return nil, fmt.Errorf("failed to migrate db : %w", err)
+	}
 	return db, nil
 }
+
+// Migrate brings the schema of db up to date and runs the data migrations.
+func Migrate(db *gorm.DB) error {
+	db.AutoMigrate(&entity.Admin{}, &entity.RolePermission{}, &entity.OtpKey{}, &models.Signup{}, &entity.User{}, &entity.Category{}, &entity.Product{}, &entity.ProductDetails{}, &entity.ProductInput{}, &entity.Inventory{}, &entity.CartItem{}, &entity.Cart{}, &entity.WishList{}, &entity.Order{}, &entity.OrderItem{}, &entity.UserAddress{}, &entity.Coupon{}, &entity.UsedCoupon{}, &entity.CartCoupon{}, &entity.Offer{}, &entity.Inventory{}, &entity.Invoice{}, &entity.OrderStatusHistory{}, &entity.StockReservation{}, &entity.ReturnRequest{}, &entity.WalletTransaction{}, &entity.Refund{}, &entity.WebhookEvent{}, &entity.OrderAddress{}, &entity.TaxInvoice{}, &entity.InvoiceSequence{}, &entity.RefreshToken{}, &entity.RevokedToken{}, &entity.SessionCutoff{}, &entity.OtpCode{})
+	return migrate(db)
+}
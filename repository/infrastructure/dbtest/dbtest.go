@@ -0,0 +1,59 @@
+// Package dbtest gives tests a postgres schema of their own. Point
+// TEST_DATABASE_DSN at a database the tests may create schemas in, such as
+// "host=localhost user=postgres password=postgres dbname=ecom_test
+// sslmode=disable"; without it the tests that need a database are skipped.
+package dbtest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"project/repository/infrastructure"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Open creates a migrated schema for the test and drops it when the test
+// ends.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN not set")
+	}
+	config := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent), TranslateError: true}
+	admin, err := gorm.Open(postgres.Open(dsn), config)
+	if err != nil {
+		t.Fatalf("connecting to test database: %v", err)
+	}
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	schema := "test_" + hex.EncodeToString(b)
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("creating schema: %v", err)
+	}
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema), config)
+	if err != nil {
+		t.Fatalf("connecting to test schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		if err := admin.Exec("DROP SCHEMA " + schema + " CASCADE").Error; err != nil {
+			t.Errorf("dropping schema: %v", err)
+		}
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := infrastructure.Migrate(db); err != nil {
+		t.Fatalf("migrating test schema: %v", err)
+	}
+	return db
+}
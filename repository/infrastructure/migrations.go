@@ -0,0 +1,203 @@
+package infrastructure
+
+import (
+	"project/domain/entity"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// schemaMigration records a one-off data migration that has been applied.
+type schemaMigration struct {
+	Name      string `gorm:"primarykey"`
+	AppliedAt time.Time
+}
+
+// moneyColumns held whole rupees before amounts were kept in paise.
+var moneyColumns = map[string][]string{
+	"products":            {"price", "offer_prize"},
+	"cart_items":          {"price"},
+	"carts":               {"total_prize", "offer_prize"},
+	"wish_lists":          {"prize"},
+	"orders":              {"total", "wallet_amount", "discount", "taxable", "cgst", "sgst", "igst"},
+	"order_items":         {"prize", "unit_price", "offer_price", "discount_share", "taxable", "cgst", "sgst", "igst"},
+	"invoices":            {"price"},
+	"users":               {"wallet", "wallet_held"},
+	"wallet_transactions": {"amount", "balance_after"},
+	"return_requests":     {"refund_amount"},
+	"refunds":             {"amount"},
+	"tax_invoices":        {"taxable", "cgst", "sgst", "igst", "total"},
+	"offers":              {"min_price"},
+}
+
+// migrate runs the data migrations that have not been applied yet, each in
+// its own transaction with its record.
+func migrate(db *gorm.DB) error {
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return err
+	}
+	migrations := []struct {
+		name string
+		run  func(tx *gorm.DB) error
+	}{
+		{"money_to_paise", moneyToPaise},
+		{"scheduled_offers", scheduledOffers},
+		{"hashed_admin_passwords", hashAdminPasswords},
+		{"role_permissions", rolePermissions},
+		{"payment_status_spelling", paymentStatusSpelling},
+		{"split_discount_amounts", splitDiscountAmounts},
+		{"failed_payment_spelling", failedPaymentSpelling},
+	}
+	for _, m := range migrations {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var count int64
+			if err := tx.Model(&schemaMigration{}).Where("name = ?", m.name).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return nil
+			}
+			if err := m.run(tx); err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{Name: m.name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func moneyToPaise(tx *gorm.DB) error {
+	for table, columns := range moneyColumns {
+		for _, column := range columns {
+			sql := "UPDATE " + table + " SET " + column + " = " + column + " * 100"
+			if err := tx.Exec(sql).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// scheduledOffers gives the existing offers a status and turns the offer
+// prices set straight on products into running percentage offers, so the
+// scheduler does not clear them.
+func scheduledOffers(tx *gorm.DB) error {
+	err := tx.Exec(`UPDATE offers SET status = CASE
+		WHEN valid_until <= now() THEN ?
+		WHEN valid_from > now() THEN ?
+		ELSE ? END
+		WHERE status IS NULL OR status = ''`, entity.OfferExpired, entity.OfferScheduled, entity.OfferActive).Error
+	if err != nil {
+		return err
+	}
+	return tx.Exec(`INSERT INTO offers (created_at, updated_at, name, type, percent, min_price, valid_from, usage_limit, used_count, category, product_id, status)
+		SELECT now(), now(), 'Offer on ' || name, ?, ROUND((price - offer_prize) * 100.0 / price), 0, now(), 0, 0, category, id, ?
+		FROM products
+		WHERE deleted_at IS NULL AND offer_prize > 0 AND offer_prize < price
+		AND ROUND((price - offer_prize) * 100.0 / price) > 0`, entity.OfferPercentage, entity.OfferActive).Error
+}
+
+// paymentStatusSpelling gives the orders paid from the wallet the payment
+// status every other paid order has.
+func paymentStatusSpelling(tx *gorm.DB) error {
+	return tx.Model(&entity.Order{}).Where("payment_status = ?", "succesful").
+		Update("payment_status", entity.PaymentSucceeded).Error
+}
+
+// failedPaymentSpelling gives failed stripe payments the payment status
+// failed razorpay payments have.
+func failedPaymentSpelling(tx *gorm.DB) error {
+	return tx.Model(&entity.Order{}).Where("payment_status = ?", "Failed").
+		Update("payment_status", entity.PaymentFailed).Error
+}
+
+// splitDiscountAmounts moves the amount coupons and offers had, a percent
+// or whole rupees depending on their type, into the percent and flat
+// columns and drops it.
+func splitDiscountAmounts(tx *gorm.DB) error {
+	tables := []struct {
+		model      interface{}
+		table      string
+		percentage string
+	}{
+		{&entity.Coupon{}, "coupons", entity.CouponPercentage},
+		{&entity.Offer{}, "offers", entity.OfferPercentage},
+	}
+	for _, t := range tables {
+		if !tx.Migrator().HasColumn(t.model, "amount") {
+			continue
+		}
+		err := tx.Exec("UPDATE "+t.table+" SET percent = amount WHERE type = ? AND amount IS NOT NULL", t.percentage).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec("UPDATE "+t.table+" SET flat = amount * 100 WHERE type <> ? AND amount IS NOT NULL", t.percentage).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Migrator().DropColumn(t.model, "amount"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashAdminPasswords replaces the plaintext admin passwords with bcrypt
+// hashes. Admins from before roles were used had full access, so they
+// become active super admins.
+func hashAdminPasswords(tx *gorm.DB) error {
+	var admins []entity.Admin
+	if err := tx.Find(&admins).Error; err != nil {
+		return err
+	}
+	for _, admin := range admins {
+		updates := map[string]interface{}{}
+		if admin.Password != "" && !strings.HasPrefix(admin.Password, "$2") {
+			hash, err := bcrypt.GenerateFromPassword([]byte(admin.Password), bcrypt.DefaultCost)
+			if err != nil {
+				return err
+			}
+			updates["password"] = string(hash)
+		}
+		if admin.Role == "" {
+			updates["role"] = entity.AdminRoleSuper
+			updates["is_active"] = true
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		if err := tx.Model(&entity.Admin{}).Where("id = ?", admin.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultRoles is the permission matrix the shop starts with. Admins of the
+// plain admin role keep the access they had before roles were checked,
+// short of managing the other admins.
+var defaultRoles = map[string][]string{
+	entity.AdminRoleAdmin: {
+		entity.PermissionUsers, entity.PermissionCatalog, entity.PermissionPromotions, entity.PermissionOrders,
+		entity.PermissionReturns, entity.PermissionRefunds, entity.PermissionWallets, entity.PermissionReports,
+	},
+	entity.AdminRoleCatalogManager: {entity.PermissionCatalog, entity.PermissionPromotions},
+	entity.AdminRoleOrderOps:       {entity.PermissionOrders, entity.PermissionReturns, entity.PermissionUsers},
+	entity.AdminRoleFinance:        {entity.PermissionReports, entity.PermissionRefunds, entity.PermissionWallets},
+}
+
+func rolePermissions(tx *gorm.DB) error {
+	for role, permissions := range defaultRoles {
+		for _, permission := range permissions {
+			if err := tx.Create(&entity.RolePermission{Role: role, Permission: permission}).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"project/delivery/models"
 	"project/domain/entity"
+	"project/domain/money"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRepository struct {
@@ -18,6 +20,15 @@ func NewOrderRepository(db *gorm.DB) *OrderRepository {
 	return &OrderRepository{db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction.
+func (or *OrderRepository) WithTx(tx *gorm.DB) *OrderRepository {
+	return &OrderRepository{tx}
+}
+
+func (or *OrderRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return or.db.Transaction(fn)
+}
+
 func (or *OrderRepository) Create(order *entity.Order) (int, error) {
 	if err := or.db.Create(order).Error; err != nil {
 		return 0, err
@@ -90,13 +101,13 @@ func (or *OrderRepository) GetAllOrderList(offset, limit int) ([]entity.Order, e
 	return order, nil
 }
 
+// GetByRazorId finds the order by its razorpay order id. Orders placed
+// before GatewayOrderId existed only carry it in PaymentId until paid.
 func (or *OrderRepository) GetByRazorId(razorId string) (*entity.Order, error) {
 	var order entity.Order
-	result := or.db.Where("payment_id=?", razorId).First(&order)
+	result := or.db.Where("gateway_order_id=? OR payment_id=?", razorId, razorId).First(&order)
 	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, errors.New("order not found")
-		}
+		return nil, errors.New("order not found")
 	}
 	return &order, nil
 }
@@ -106,13 +117,13 @@ func (or *OrderRepository) GetByDate(startdate, enddate time.Time) (*entity.Sale
 	var report entity.SalesReport
 	enddate = enddate.Add(+24 * time.Hour)
 
-	if err := or.db.Model(&order).Where("created_at BETWEEN ? AND ? AND status =?", startdate, enddate, "confirmed").Select("SUM(total) as total_sales").Scan(&report).Error; err != nil {
+	if err := or.db.Model(&order).Where("created_at BETWEEN ? AND ? AND status IN ?", startdate, enddate, entity.SalesStatuses).Select("SUM(total) as total_sales, SUM(discount) as total_discount, SUM(cgst + sgst + igst) as total_tax").Scan(&report).Error; err != nil {
 		return nil, err
 	}
-	if err := or.db.Model(&order).Where("created_at BETWEEN ? AND ? AND status =?", startdate, enddate, "confirmed").Count(&report.TotalOrders).Error; err != nil {
+	if err := or.db.Model(&order).Where("created_at BETWEEN ? AND ? AND status IN ?", startdate, enddate, entity.SalesStatuses).Count(&report.TotalOrders).Error; err != nil {
 		return nil, err
 	}
-	if err := or.db.Model(&order).Where("created_at BETWEEN ? AND ? AND status =?", startdate, enddate, "confirmed").Select("AVG(total) as average_order").Scan(&report).Error; err != nil {
+	if err := or.db.Model(&order).Where("created_at BETWEEN ? AND ? AND status IN ?", startdate, enddate, entity.SalesStatuses).Select("COALESCE(ROUND(AVG(total)), 0)::bigint as average_order").Scan(&report).Error; err != nil {
 		return nil, err
 	}
 
@@ -124,13 +135,13 @@ func (or *OrderRepository) GetByPaymentMethod(startdate, enddate time.Time, paym
 	enddate = enddate.Add(+24 * time.Hour)
 	var report entity.SalesReport
 
-	if err := or.db.Model(&order).Where("created_at BETWEEN ? AND ? AND status =? AND payment_method=?", startdate, enddate, "confirmed", paymentmethod).Select("SUM(total) as total_sales").Scan(&report).Error; err != nil {
+	if err := or.db.Model(&order).Where("created_at BETWEEN ? AND ? AND status IN ? AND payment_method=?", startdate, enddate, entity.SalesStatuses, paymentmethod).Select("SUM(total) as total_sales, SUM(discount) as total_discount, SUM(cgst + sgst + igst) as total_tax").Scan(&report).Error; err != nil {
 		return nil, err
 	}
-	if err := or.db.Model(&order).Where("created_at BETWEEN ? AND ? AND status =? AND payment_method=?", startdate, enddate, "confirmed", paymentmethod).Count(&report.TotalOrders).Error; err != nil {
+	if err := or.db.Model(&order).Where("created_at BETWEEN ? AND ? AND status IN ? AND payment_method=?", startdate, enddate, entity.SalesStatuses, paymentmethod).Count(&report.TotalOrders).Error; err != nil {
 		return nil, err
 	}
-	if err := or.db.Model(&order).Where("created_at BETWEEN ? AND ? AND status =? AND payment_method=?", startdate, enddate, "confirmed", paymentmethod).Select("AVG(total) as average_order").Scan(&report).Error; err != nil {
+	if err := or.db.Model(&order).Where("created_at BETWEEN ? AND ? AND status IN ? AND payment_method=?", startdate, enddate, entity.SalesStatuses, paymentmethod).Select("COALESCE(ROUND(AVG(total)), 0)::bigint as average_order").Scan(&report).Error; err != nil {
 		return nil, err
 	}
 
@@ -148,10 +159,6 @@ func (or *OrderRepository) UpdateInvoice(invoice *entity.Invoice) error {
 	return or.db.Save(&invoice).Error
 }
 
-func (or *OrderRepository) UpdateUserWallet(user *entity.User) error {
-	return or.db.Save(&user).Error
-}
-
 func (or *OrderRepository) DetailedOrderDetails(orderid int) (models.CombinedOrderDetails, error) {
 	var body models.CombinedOrderDetails
 
@@ -188,4 +195,263 @@ func (ol *OrderRepository) GetAllOrderItems(orderid int) ([]entity.OrderItem,err
 	}
 	return orders,nil
 	
-}
\ No newline at end of file
+}
+
+func (or *OrderRepository) CreateStatusHistory(history *entity.OrderStatusHistory) error {
+	return or.db.Create(history).Error
+}
+
+func (or *OrderRepository) GetStatusHistory(orderid int) ([]entity.OrderStatusHistory, error) {
+	var history []entity.OrderStatusHistory
+	err := or.db.Where("order_id=?", orderid).Order("changed_at").Find(&history).Error
+	if err != nil {
+		return nil, errors.New("record not found")
+	}
+	return history, nil
+}
+
+// CountPlacedOrders counts the user's orders that went through: cash on
+// delivery orders and paid ones, leaving out cancelled orders.
+func (or *OrderRepository) CountPlacedOrders(userid int) (int64, error) {
+	var count int64
+	err := or.db.Model(&entity.Order{}).
+		Where("user_id = ? AND status NOT IN ?", userid, []string{entity.OrderCancelled, entity.OrderPaymentExpired}).
+		Where("payment_method = ? OR payment_status IN ?", entity.PaymentCod, []string{entity.PaymentSucceeded, entity.PaymentRefunded}).
+		Count(&count).Error
+	return count, err
+}
+
+func (or *OrderRepository) GetOrderItemById(id int) (*entity.OrderItem, error) {
+	var item entity.OrderItem
+	if err := or.db.Where("id=?", id).First(&item).Error; err != nil {
+		return nil, errors.New("order item not found")
+	}
+	return &item, nil
+}
+
+// GetOrderItemByIdForUpdate reads the order item and locks it until the
+// transaction ends.
+func (or *OrderRepository) GetOrderItemByIdForUpdate(id int) (*entity.OrderItem, error) {
+	var item entity.OrderItem
+	if err := or.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", id).First(&item).Error; err != nil {
+		return nil, errors.New("order item not found")
+	}
+	return &item, nil
+}
+
+func (or *OrderRepository) CreateReturnRequest(request *entity.ReturnRequest) error {
+	return or.db.Create(request).Error
+}
+
+func (or *OrderRepository) UpdateReturnRequest(request *entity.ReturnRequest) error {
+	return or.db.Save(request).Error
+}
+
+func (or *OrderRepository) GetReturnRequestById(id int) (*entity.ReturnRequest, error) {
+	var request entity.ReturnRequest
+	if err := or.db.Where("id=?", id).First(&request).Error; err != nil {
+		return nil, errors.New("return request not found")
+	}
+	return &request, nil
+}
+
+// GetReturnRequestByIdForUpdate reads the return request and locks it until
+// the transaction ends.
+func (or *OrderRepository) GetReturnRequestByIdForUpdate(id int) (*entity.ReturnRequest, error) {
+	var request entity.ReturnRequest
+	if err := or.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", id).First(&request).Error; err != nil {
+		return nil, errors.New("return request not found")
+	}
+	return &request, nil
+}
+
+func (or *OrderRepository) GetReturnRequestsByUser(userid int) ([]entity.ReturnRequest, error) {
+	var requests []entity.ReturnRequest
+	if err := or.db.Where("user_id=?", userid).Order("id desc").Find(&requests).Error; err != nil {
+		return nil, errors.New("record not found")
+	}
+	return requests, nil
+}
+
+// GetReturnRequests lists return requests, optionally only those in status.
+func (or *OrderRepository) GetReturnRequests(offset, limit int, status string) ([]entity.ReturnRequest, error) {
+	var requests []entity.ReturnRequest
+	query := or.db.Offset(offset).Limit(limit).Order("id")
+	if status != "" {
+		query = query.Where("status=?", status)
+	}
+	if err := query.Find(&requests).Error; err != nil {
+		return nil, errors.New("record not found")
+	}
+	return requests, nil
+}
+
+// GetReturnedQuantity is how many units of the order item are already in a
+// return that was not rejected.
+func (or *OrderRepository) GetReturnedQuantity(orderItemId int) (int, error) {
+	var quantity int
+	err := or.db.Model(&entity.ReturnRequest{}).Where("order_item_id=? AND status<>?", orderItemId, entity.ReturnRejected).Select("COALESCE(SUM(quantity), 0)").Scan(&quantity).Error
+	if err != nil {
+		return 0, err
+	}
+	return quantity, nil
+}
+
+// GetRefundedQuantity is how many units of the order have been refunded
+// through returns.
+func (or *OrderRepository) GetRefundedQuantity(orderid int) (int, error) {
+	var quantity int
+	err := or.db.Model(&entity.ReturnRequest{}).Where("order_id=? AND status=?", orderid, entity.ReturnRefunded).Select("COALESCE(SUM(quantity), 0)").Scan(&quantity).Error
+	if err != nil {
+		return 0, err
+	}
+	return quantity, nil
+}
+
+func (or *OrderRepository) CreateRefund(refund *entity.Refund) error {
+	return or.db.Create(refund).Error
+}
+
+func (or *OrderRepository) UpdateRefund(refund *entity.Refund) error {
+	return or.db.Save(refund).Error
+}
+
+func (or *OrderRepository) GetRefundById(id int) (*entity.Refund, error) {
+	var refund entity.Refund
+	if err := or.db.Where("id=?", id).First(&refund).Error; err != nil {
+		return nil, errors.New("refund not found")
+	}
+	return &refund, nil
+}
+
+func (or *OrderRepository) GetRefundsByUser(userid int) ([]entity.Refund, error) {
+	var refunds []entity.Refund
+	if err := or.db.Where("user_id=?", userid).Order("id desc").Find(&refunds).Error; err != nil {
+		return nil, errors.New("record not found")
+	}
+	return refunds, nil
+}
+
+// GetRefunds lists refunds, optionally only those in status.
+func (or *OrderRepository) GetRefunds(offset, limit int, status string) ([]entity.Refund, error) {
+	var refunds []entity.Refund
+	query := or.db.Offset(offset).Limit(limit).Order("id")
+	if status != "" {
+		query = query.Where("status=?", status)
+	}
+	if err := query.Find(&refunds).Error; err != nil {
+		return nil, errors.New("record not found")
+	}
+	return refunds, nil
+}
+
+// GetGatewayRefundedAmount is how much of the order has gone, or is on its
+// way, back to the original payment.
+func (or *OrderRepository) GetGatewayRefundedAmount(orderid int) (money.Money, error) {
+	var amount money.Money
+	err := or.db.Model(&entity.Refund{}).Where("order_id=? AND method=? AND status<>?", orderid, entity.RefundToOriginal, entity.RefundFailed).Select("COALESCE(SUM(amount), 0)").Scan(&amount).Error
+	if err != nil {
+		return 0, err
+	}
+	return amount, nil
+}
+
+func (or *OrderRepository) GetInvoiceByOrderId(orderid int) (*entity.Invoice, error) {
+	var invoice entity.Invoice
+	if err := or.db.Where("order_id=?", orderid).Last(&invoice).Error; err != nil {
+		return nil, errors.New("invoice not found")
+	}
+	return &invoice, nil
+}
+
+func (or *OrderRepository) GetRefundByGatewayId(gatewayRefundId string) (*entity.Refund, error) {
+	var refund entity.Refund
+	if err := or.db.Where("gateway_refund_id=?", gatewayRefundId).First(&refund).Error; err != nil {
+		return nil, errors.New("refund not found")
+	}
+	return &refund, nil
+}
+
+func (or *OrderRepository) WebhookEventExists(provider, eventId string) (bool, error) {
+	var count int64
+	err := or.db.Model(&entity.WebhookEvent{}).Where("provider=? AND event_id=?", provider, eventId).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (or *OrderRepository) CreateWebhookEvent(event *entity.WebhookEvent) error {
+	return or.db.Create(event).Error
+}
+
+// GetOrderByIdForUpdate reads the order and locks it until the transaction
+// ends.
+func (or *OrderRepository) GetOrderByIdForUpdate(orderid int) (*entity.Order, error) {
+	var order entity.Order
+	if err := or.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", orderid).First(&order).Error; err != nil {
+		return nil, errors.New("order not found")
+	}
+	return &order, nil
+}
+
+// SetGatewayOrderId records the gateway order created for the order, unless
+// it already has one.
+func (or *OrderRepository) SetGatewayOrderId(orderid int, gatewayOrderId string) error {
+	result := or.db.Model(&entity.Order{}).
+		Where("id = ? AND gateway_order_id = ''", orderid).
+		Updates(map[string]interface{}{"payment_id": gatewayOrderId, "gateway_order_id": gatewayOrderId})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("order already has a gateway order")
+	}
+	return nil
+}
+
+func (or *OrderRepository) GetByGatewayOrderId(gatewayOrderId string) (*entity.Order, error) {
+	var order entity.Order
+	if err := or.db.Where("gateway_order_id=?", gatewayOrderId).First(&order).Error; err != nil {
+		return nil, errors.New("order not found")
+	}
+	return &order, nil
+}
+
+func (or *OrderRepository) CreateOrderAddress(address *entity.OrderAddress) error {
+	return or.db.Create(address).Error
+}
+
+func (or *OrderRepository) GetOrderAddress(orderid int) (*entity.OrderAddress, error) {
+	var address entity.OrderAddress
+	if err := or.db.Where("order_id=?", orderid).First(&address).Error; err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// NextInvoiceSequence takes the next invoice number of the financial year.
+// The sequence row stays locked until the caller's transaction ends, so
+// numbers are handed out in order and a rollback leaves no gap.
+func (or *OrderRepository) NextInvoiceSequence(financialYear string) (int, error) {
+	var next int
+	err := or.db.Raw(`INSERT INTO invoice_sequences (financial_year, last_number) VALUES (?, 1)
+		ON CONFLICT (financial_year) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+		RETURNING last_number`, financialYear).Scan(&next).Error
+	if err != nil {
+		return 0, err
+	}
+	return next, nil
+}
+
+func (or *OrderRepository) CreateTaxInvoice(invoice *entity.TaxInvoice) error {
+	return or.db.Create(invoice).Error
+}
+
+func (or *OrderRepository) GetTaxInvoiceByOrderId(orderid int) (*entity.TaxInvoice, error) {
+	var invoice entity.TaxInvoice
+	if err := or.db.Where("order_id=?", orderid).First(&invoice).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
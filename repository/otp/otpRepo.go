@@ -0,0 +1,74 @@
+package otp
+
+import (
+	"errors"
+	"project/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OtpRepository struct {
+	db *gorm.DB
+}
+
+func NewOtpRepository(db *gorm.DB) *OtpRepository {
+	return &OtpRepository{db}
+}
+
+// CreateCode saves a new code for the phone, retiring the ones sent to it
+// before.
+func (or *OtpRepository) CreateCode(code *entity.OtpCode) error {
+	return or.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.OtpCode{}).
+			Where("phone = ? AND used_at IS NULL", code.Phone).
+			Update("used_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(code).Error
+	})
+}
+
+// GetActiveCode returns the latest unused code of the phone, or nil if
+// there is none.
+func (or *OtpRepository) GetActiveCode(phone string) (*entity.OtpCode, error) {
+	var code entity.OtpCode
+	err := or.db.Where("phone = ? AND used_at IS NULL", phone).Order("created_at DESC").First(&code).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// AddAttempt counts a check of the code. It reports false once the code
+// has been used or has run out of attempts, so concurrent guesses cannot
+// go past the limit.
+func (or *OtpRepository) AddAttempt(id uint, maxAttempts int) (bool, error) {
+	result := or.db.Model(&entity.OtpCode{}).
+		Where("id = ? AND used_at IS NULL AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// UseCode marks the code used. It reports false if it already was.
+func (or *OtpRepository) UseCode(id uint, now time.Time) (bool, error) {
+	result := or.db.Model(&entity.OtpCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Purge drops the codes that expired before now.
+func (or *OtpRepository) Purge(now time.Time) error {
+	return or.db.Unscoped().Where("expires_at < ?", now).Delete(&entity.OtpCode{}).Error
+}
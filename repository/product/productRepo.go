@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"project/delivery/models"
 	"project/domain/entity"
+	"project/domain/money"
 	"time"
 
 	"gorm.io/gorm"
@@ -18,17 +19,22 @@ func NewProductRepository(db *gorm.DB) *ProductRepository {
 	return &ProductRepository{db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction.
+func (pr *ProductRepository) WithTx(tx *gorm.DB) *ProductRepository {
+	return &ProductRepository{tx}
+}
+
 func (pr *ProductRepository) GetAllProducts(offset, limit int) (*[]models.ProductWithQuantityResponse, error) {
 	var productsWithQuantity []models.ProductWithQuantityResponse
 
 	rows, err := pr.db.
 		Table("products").
-		Select("products.id, products.name, products.price, products.offer_prize, products.size, products.category, products.image_url, inventories.quantity").
+		Select("products.id, products.name, products.price, products.offer_prize, products.size, products.category, products.image_url, inventories.quantity - inventories.reserved AS quantity").
 		Joins("JOIN inventories ON products.id = inventories.product_id").
 		Offset(offset).
 		Limit(limit).
 		Where("products.removed = ?", false).
-		Where("inventories.quantity >= ?", 1).
+		Where("inventories.quantity - inventories.reserved >= ?", 1).
 		Rows()
 
 	if err != nil {
@@ -178,7 +184,7 @@ func (pr *ProductRepository) CreateCoupon(coupon *entity.Coupon) error {
 func (pr *ProductRepository) GetAllCoupons() (*[]entity.Coupon, error) {
 	var coupon []entity.Coupon
 	currenttime := time.Now()
-	err := pr.db.Where("validuntil > ?", currenttime).Find(&coupon).Error
+	err := pr.db.Where("validuntil > ? AND valid_from <= ?", currenttime, currenttime).Find(&coupon).Error
 	if err != nil {
 		return nil, err
 	}
@@ -211,6 +217,52 @@ func (pr *ProductRepository) UpdateCouponUsage(coupon *entity.UsedCoupon) error
 	return nil
 }
 
+// CountCouponUses is how many times the user has redeemed the coupon.
+func (pr *ProductRepository) CountCouponUses(userid int, code string) (int64, error) {
+	var count int64
+	err := pr.db.Model(&entity.UsedCoupon{}).Where("user_id = ? AND coupon_code = ?", userid, code).Count(&count).Error
+	return count, err
+}
+
+// RedeemCoupon counts one more use of the coupon, failing when the usage
+// limit has been reached in the meantime.
+func (pr *ProductRepository) RedeemCoupon(code string) error {
+	result := pr.db.Model(&entity.Coupon{}).
+		Where("code = ? AND used_count < usage_limit", code).
+		Update("used_count", gorm.Expr("used_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("coupon usage exceeded")
+	}
+	return nil
+}
+
+func (pr *ProductRepository) GetUsedCouponsByOrder(orderid int) ([]entity.UsedCoupon, error) {
+	var used []entity.UsedCoupon
+	err := pr.db.Where("order_id = ?", orderid).Find(&used).Error
+	return used, err
+}
+
+// ReleaseCoupons gives back the coupon uses of an order that never went
+// through.
+func (pr *ProductRepository) ReleaseCoupons(orderid int) error {
+	used, err := pr.GetUsedCouponsByOrder(orderid)
+	if err != nil {
+		return err
+	}
+	for _, u := range used {
+		err := pr.db.Model(&entity.Coupon{}).
+			Where("code = ? AND used_count > 0", u.CouponCode).
+			Update("used_count", gorm.Expr("used_count - 1")).Error
+		if err != nil {
+			return err
+		}
+	}
+	return pr.db.Where("order_id = ?", orderid).Delete(&entity.UsedCoupon{}).Error
+}
+
 func (pr *ProductRepository) CreateOffer(offer *entity.Offer) error {
 	if err := pr.db.Create(offer).Error; err != nil {
 		return err
@@ -218,7 +270,7 @@ func (pr *ProductRepository) CreateOffer(offer *entity.Offer) error {
 	return nil
 }
 
-func (pr *ProductRepository) GetOfferByPrize(Prize int) (*[]entity.Offer, error) {
+func (pr *ProductRepository) GetOfferByPrize(Prize money.Money) (*[]entity.Offer, error) {
 	offers := &[]entity.Offer{}
 	err := pr.db.Where("min_prize=?", Prize).Find(&offers).Error
 	if err != nil {
@@ -229,28 +281,104 @@ func (pr *ProductRepository) GetOfferByPrize(Prize int) (*[]entity.Offer, error)
 	return offers, nil
 }
 
+// DecreaseProductQuantity takes the stock out of the inventory. The check and
+// the write are a single conditional update, so concurrent checkouts cannot
+// both take the last unit.
 func (pr *ProductRepository) DecreaseProductQuantity(product *entity.Inventory) error {
+	result := pr.db.Model(&entity.Inventory{}).
+		Where("product_category=? AND product_id=?", product.ProductCategory, product.ProductId).
+		Where("quantity - reserved >= ?", product.Quantity).
+		Update("quantity", gorm.Expr("quantity - ?", product.Quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return pr.stockError(product)
+	}
+	return nil
+}
+
+// stockError explains why a conditional stock update matched no row.
+func (pr *ProductRepository) stockError(product *entity.Inventory) error {
 	exisitingproduct := &entity.Inventory{}
 	err := pr.db.Where("product_category=? AND product_id=?", product.ProductCategory, product.ProductId).First(exisitingproduct).Error
 	if err != nil {
 		return err
 	}
-	if exisitingproduct.Quantity == 0 {
+	available := exisitingproduct.Quantity - exisitingproduct.Reserved
+	if available <= 0 {
 		return errors.New("out of stock")
 	}
-	newQuantity := exisitingproduct.Quantity - product.Quantity
+	return fmt.Errorf("There is only %d quantity avialable", available)
+}
 
-	if newQuantity < 0 {
-		return fmt.Errorf("There is only %d quantity avialable", exisitingproduct.Quantity)
+// ReserveProductQuantity holds stock for a pending online payment without
+// taking it out of the inventory.
+func (pr *ProductRepository) ReserveProductQuantity(product *entity.Inventory) error {
+	result := pr.db.Model(&entity.Inventory{}).
+		Where("product_category=? AND product_id=?", product.ProductCategory, product.ProductId).
+		Where("quantity - reserved >= ?", product.Quantity).
+		Update("reserved", gorm.Expr("reserved + ?", product.Quantity))
+	if result.Error != nil {
+		return result.Error
 	}
+	if result.RowsAffected == 0 {
+		return pr.stockError(product)
+	}
+	return nil
+}
 
-	err = pr.db.Model(exisitingproduct).Update("quantity", newQuantity).Error
-	if err != nil {
-		return err
+// CommitReservedQuantity turns a hold into a sale.
+func (pr *ProductRepository) CommitReservedQuantity(product *entity.Inventory) error {
+	result := pr.db.Model(&entity.Inventory{}).
+		Where("product_category=? AND product_id=?", product.ProductCategory, product.ProductId).
+		Where("reserved >= ?", product.Quantity).
+		Updates(map[string]interface{}{
+			"quantity": gorm.Expr("quantity - ?", product.Quantity),
+			"reserved": gorm.Expr("reserved - ?", product.Quantity),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("reserved stock not found")
 	}
 	return nil
 }
 
+// ReleaseReservedQuantity gives held stock back to the shelf.
+func (pr *ProductRepository) ReleaseReservedQuantity(product *entity.Inventory) error {
+	return pr.db.Model(&entity.Inventory{}).
+		Where("product_category=? AND product_id=?", product.ProductCategory, product.ProductId).
+		Update("reserved", gorm.Expr("GREATEST(reserved - ?, 0)", product.Quantity)).Error
+}
+
+func (pr *ProductRepository) CreateReservations(reservations []entity.StockReservation) error {
+	return pr.db.Create(reservations).Error
+}
+
+func (pr *ProductRepository) GetReservationsByOrder(orderid int, status string) ([]entity.StockReservation, error) {
+	var reservations []entity.StockReservation
+	err := pr.db.Where("order_id=? AND status=?", orderid, status).Find(&reservations).Error
+	if err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+func (pr *ProductRepository) GetExpiredReservationOrders(now time.Time) ([]int, error) {
+	var orderids []int
+	err := pr.db.Model(&entity.StockReservation{}).Where("status=? AND expires_at < ?", entity.ReservationHeld, now).Distinct().Pluck("order_id", &orderids).Error
+	if err != nil {
+		return nil, err
+	}
+	return orderids, nil
+}
+
+func (pr *ProductRepository) UpdateReservationStatus(reservation *entity.StockReservation, status string) error {
+	return pr.db.Model(reservation).Update("status", status).Error
+}
+
 func (pr *ProductRepository) GetCouponByCategory(category string) (*entity.Coupon, error) {
 	coupon := &entity.Coupon{}
 	err := pr.db.Where("category=?", category).First(coupon).Error
@@ -288,7 +416,7 @@ func (ar *ProductRepository) GetProductsByCategory(offset, limit, id int) ([]ent
 	return product, nil
 }
 
-func (ar *ProductRepository) GetProductsByFilter(minPrize, maxPrize, category int, size string) ([]entity.Product, error) {
+func (ar *ProductRepository) GetProductsByFilter(minPrize, maxPrize money.Money, category int, size string) ([]entity.Product, error) {
 	var products []entity.Product
 
 	query := ar.db
@@ -313,10 +441,12 @@ func (ar *ProductRepository) GetProductsByFilter(minPrize, maxPrize, category in
 	return products, nil
 }
 
+// GetAllOffers returns the offers that are running or yet to start.
 func (pr *ProductRepository) GetAllOffers() ([]entity.Offer, error) {
 	var offer []entity.Offer
 	currenttime := time.Now()
-	err := pr.db.Where("valid_until > ?", currenttime).Find(&offer).Error
+	err := pr.db.Where("status NOT IN ? AND (valid_until IS NULL OR valid_until > ?)", []string{entity.OfferExpired, entity.OfferCancelled}, currenttime).
+		Order("valid_from").Find(&offer).Error
 	if err != nil {
 		return nil, errors.New("record not found")
 	}
@@ -324,6 +454,55 @@ func (pr *ProductRepository) GetAllOffers() ([]entity.Offer, error) {
 
 }
 
+func (pr *ProductRepository) GetOffers(offset, limit int, status string) ([]entity.Offer, error) {
+	var offers []entity.Offer
+	query := pr.db.Order("id desc").Offset(offset).Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&offers).Error; err != nil {
+		return nil, errors.New("record not found")
+	}
+	return offers, nil
+}
+
+func (pr *ProductRepository) GetOfferById(id int) (*entity.Offer, error) {
+	offer := &entity.Offer{}
+	if err := pr.db.Where("id = ?", id).First(offer).Error; err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+func (pr *ProductRepository) UpdateOffer(offer *entity.Offer) error {
+	return pr.db.Save(offer).Error
+}
+
+// GetLiveOffers returns the offers running at now.
+func (pr *ProductRepository) GetLiveOffers(now time.Time) ([]entity.Offer, error) {
+	var offers []entity.Offer
+	err := pr.db.Where("status <> ? AND valid_from <= ? AND (valid_until IS NULL OR valid_until > ?)", entity.OfferCancelled, now, now).
+		Find(&offers).Error
+	return offers, err
+}
+
+// GetDueOffers returns the offers whose status is behind the clock: ones
+// that should have started or ended by now.
+func (pr *ProductRepository) GetDueOffers(now time.Time) ([]entity.Offer, error) {
+	var offers []entity.Offer
+	err := pr.db.Where("(status IN ? AND valid_from <= ?) OR (status IN ? AND valid_until <= ?)",
+		[]string{"", entity.OfferScheduled}, now,
+		[]string{"", entity.OfferScheduled, entity.OfferActive}, now).
+		Find(&offers).Error
+	return offers, err
+}
+
+// UpdateOfferPrize sets the price the product's offers give it, 0 when no
+// offer runs on it.
+func (pr *ProductRepository) UpdateOfferPrize(productid int, price money.Money) error {
+	return pr.db.Model(&entity.Product{}).Where("id = ?", productid).Update("offer_prize", price).Error
+}
+
 func (ar *ProductRepository) GetProductsByCategoryoffer(id int) ([]entity.Product, error) {
 	var product []entity.Product
 
@@ -354,3 +533,18 @@ func (dp *ProductRepository) GetInventoryByID(id int) (*entity.Inventory, error)
 func (up *ProductRepository) UpdateInventory(inventory *entity.Inventory) error {
 	return up.db.Save(inventory).Error
 }
+
+// IncreaseProductQuantity adds stock in place so it cannot overwrite a
+// checkout that ran in between.
+func (pr *ProductRepository) IncreaseProductQuantity(productId, stock int) error {
+	result := pr.db.Model(&entity.Inventory{}).
+		Where("product_id=?", productId).
+		Update("quantity", gorm.Expr("quantity + ?", stock))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("record not found")
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"project/domain/entity"
+	"project/repository/infrastructure/dbtest"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// TestLastUnitGoesOnce has parallel orders take the last unit in stock, each
+// in its own transaction as checkout does. Exactly one may get it.
+func TestLastUnitGoesOnce(t *testing.T) {
+	takes := map[string]func(*ProductRepository, *entity.Inventory) error{
+		"decrease": (*ProductRepository).DecreaseProductQuantity,
+		"reserve":  (*ProductRepository).ReserveProductQuantity,
+	}
+	for name, take := range takes {
+		t.Run(name, func(t *testing.T) {
+			db := dbtest.Open(t)
+			repo := NewProductRepository(db)
+			stock := &entity.Inventory{ProductId: 1, ProductCategory: 1, Quantity: 1}
+			if err := repo.CreateInventory(stock); err != nil {
+				t.Fatal(err)
+			}
+
+			const orders = 20
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			succeeded := 0
+			start := make(chan struct{})
+			for i := 0; i < orders; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					<-start
+					err := db.Transaction(func(tx *gorm.DB) error {
+						return take(repo.WithTx(tx), &entity.Inventory{ProductId: 1, ProductCategory: 1, Quantity: 1})
+					})
+					if err == nil {
+						mu.Lock()
+						succeeded++
+						mu.Unlock()
+					}
+				}()
+			}
+			close(start)
+			wg.Wait()
+
+			if succeeded != 1 {
+				t.Fatalf("%d orders took the last unit, want 1", succeeded)
+			}
+			var after entity.Inventory
+			if err := db.First(&after, stock.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if available := after.Quantity - after.Reserved; available != 0 {
+				t.Errorf("%d units available after the sale, want 0", available)
+			}
+			if after.Quantity < 0 || after.Reserved > after.Quantity {
+				t.Errorf("inventory went inconsistent: quantity %d, reserved %d", after.Quantity, after.Reserved)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package token
+
+import (
+	"errors"
+	"project/domain/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrTokenReused is returned when a refresh token that was already
+// replaced is used again.
+var ErrTokenReused = errors.New("refresh token reused")
+
+type TokenRepository struct {
+	db *gorm.DB
+}
+
+func NewTokenRepository(db *gorm.DB) *TokenRepository {
+	return &TokenRepository{db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction.
+func (tr *TokenRepository) WithTx(tx *gorm.DB) *TokenRepository {
+	return &TokenRepository{tx}
+}
+
+func (tr *TokenRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return tr.db.Transaction(fn)
+}
+
+func (tr *TokenRepository) CreateRefreshToken(token *entity.RefreshToken) error {
+	return tr.db.Create(token).Error
+}
+
+func (tr *TokenRepository) GetRefreshToken(hash string) (*entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	if err := tr.db.Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ReplaceRefreshToken revokes the token in favour of next. It fails with
+// ErrTokenReused when the token was revoked in the meantime.
+func (tr *TokenRepository) ReplaceRefreshToken(token *entity.RefreshToken, next uint, now time.Time) error {
+	result := tr.db.Model(&entity.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", token.ID).
+		Updates(map[string]interface{}{"revoked_at": now, "replaced_by": next})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenReused
+	}
+	return nil
+}
+
+func (tr *TokenRepository) RevokeRefreshFamily(family string, now time.Time) error {
+	return tr.db.Model(&entity.RefreshToken{}).
+		Where("family = ? AND revoked_at IS NULL", family).
+		Update("revoked_at", now).Error
+}
+
+// RevokeAll revokes the refresh tokens of the user or admin and ends the
+// sessions they started before now.
+func (tr *TokenRepository) RevokeAll(userid int, role string, now time.Time) error {
+	err := tr.db.Model(&entity.RefreshToken{}).
+		Where("user_id = ? AND role = ? AND revoked_at IS NULL", userid, role).
+		Update("revoked_at", now).Error
+	if err != nil {
+		return err
+	}
+	cutoff := entity.SessionCutoff{UserId: userid, Role: role, RevokedBefore: now}
+	return tr.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "role"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before"}),
+	}).Create(&cutoff).Error
+}
+
+// GetCutoff returns when the user or admin last logged out of all devices,
+// or nil if they never did.
+func (tr *TokenRepository) GetCutoff(userid int, role string) (*time.Time, error) {
+	var cutoff entity.SessionCutoff
+	err := tr.db.Where("user_id = ? AND role = ?", userid, role).First(&cutoff).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cutoff.RevokedBefore, nil
+}
+
+func (tr *TokenRepository) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	return tr.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&entity.RevokedToken{Jti: jti, ExpiresAt: expiresAt}).Error
+}
+
+func (tr *TokenRepository) IsAccessTokenRevoked(jti string) (bool, error) {
+	var count int64
+	err := tr.db.Model(&entity.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+// Purge drops the revocations and refresh tokens that expired before now.
+func (tr *TokenRepository) Purge(now time.Time) error {
+	if err := tr.db.Where("expires_at < ?", now).Delete(&entity.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return tr.db.Unscoped().Where("expires_at < ?", now).Delete(&entity.RefreshToken{}).Error
+}
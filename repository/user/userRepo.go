@@ -17,6 +17,11 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db}
 }
 
+// WithTx returns a copy of the repository bound to the given transaction.
+func (ur *UserRepository) WithTx(tx *gorm.DB) *UserRepository {
+	return &UserRepository{tx}
+}
+
 func (ur *UserRepository) GetById(id int) (*entity.User, error) {
 	var user entity.User
 	result := ur.db.Find(&user, id)
@@ -130,29 +135,49 @@ func (ur *UserRepository) GetAddressById(addressid int) (*entity.UserAddress, er
 	return &address, nil
 }
 
-func (ur *UserRepository) GetAddressByID(addressid int) (*entity.UserAddress, error) {
+// GetUserAddress returns the address only when it belongs to the user.
+func (ur *UserRepository) GetUserAddress(userid, addressid int) (*entity.UserAddress, error) {
 	var address entity.UserAddress
-	result := ur.db.Where("user_id=?", addressid).First(&address)
+	result := ur.db.Where("id = ? AND user_id = ?", addressid, userid).First(&address)
 	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, result.Error
-		}
 		return nil, result.Error
 	}
 	return &address, nil
 }
 
-func (ur *UserRepository) GetAddressByType(userid int, addresstype string) (*entity.UserAddress, error) {
+func (ur *UserRepository) GetAddresses(userid int) ([]entity.UserAddress, error) {
+	var addresses []entity.UserAddress
+	if err := ur.db.Where("user_id = ?", userid).Order("is_default DESC, id").Find(&addresses).Error; err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+func (ur *UserRepository) GetDefaultAddress(userid int) (*entity.UserAddress, error) {
 	var address entity.UserAddress
-	result := ur.db.Where("user_id=? AND type=?", userid, addresstype).First(&address)
+	result := ur.db.Where("user_id = ? AND is_default", userid).First(&address)
 	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, result.Error
-		}
 		return nil, result.Error
 	}
 	return &address, nil
+}
 
+// SetDefaultAddress makes addressid the user's only default address.
+func (ur *UserRepository) SetDefaultAddress(userid, addressid int) error {
+	return ur.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entity.UserAddress{}).Where("user_id = ? AND id <> ? AND is_default", userid, addressid).Update("is_default", false).Error
+		if err != nil {
+			return err
+		}
+		result := tx.Model(&entity.UserAddress{}).Where("user_id = ? AND id = ?", userid, addressid).Update("is_default", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("address not found")
+		}
+		return nil
+	})
 }
 
 func (ur *UserRepository) UpdateAddress(usaaddress *entity.UserAddress) error {
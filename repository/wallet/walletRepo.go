@@ -0,0 +1,159 @@
+package wallet
+
+import (
+	"errors"
+	"project/domain/entity"
+	"project/domain/money"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WalletRepository struct {
+	db *gorm.DB
+}
+
+func NewWalletRepository(db *gorm.DB) *WalletRepository {
+	return &WalletRepository{db}
+}
+
+// WithTx returns a copy of the repository bound to the given transaction.
+func (wr *WalletRepository) WithTx(tx *gorm.DB) *WalletRepository {
+	return &WalletRepository{tx}
+}
+
+// Credit adds txn.Amount to the user's wallet and records it in the ledger.
+func (wr *WalletRepository) Credit(txn *entity.WalletTransaction) error {
+	txn.Type = entity.WalletCredit
+	return wr.post(txn)
+}
+
+// Debit takes txn.Amount out of the user's wallet and records it in the
+// ledger. It fails when the balance is not enough.
+func (wr *WalletRepository) Debit(txn *entity.WalletTransaction) error {
+	txn.Type = entity.WalletDebit
+	return wr.post(txn)
+}
+
+// post locks the user row so concurrent entries see each other's balance.
+func (wr *WalletRepository) post(txn *entity.WalletTransaction) error {
+	if txn.Amount <= 0 {
+		return errors.New("wallet amount must be positive")
+	}
+	return wr.db.Transaction(func(tx *gorm.DB) error {
+		var user entity.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id=?", txn.UserId).First(&user).Error; err != nil {
+			return errors.New("user not found")
+		}
+		balance := user.Wallet + txn.Amount
+		if txn.Type == entity.WalletDebit {
+			balance = user.Wallet - txn.Amount
+		}
+		if balance < user.WalletHeld {
+			return errors.New("wallet have not enough money")
+		}
+		if err := tx.Model(&entity.User{}).Where("id=?", txn.UserId).Update("wallet", balance).Error; err != nil {
+			return err
+		}
+		txn.BalanceAfter = balance
+		txn.PostedAt = time.Now()
+		return tx.Create(txn).Error
+	})
+}
+
+// Hold sets amount aside for a pending gateway payment. The balance does not
+// change until the hold is committed.
+func (wr *WalletRepository) Hold(userid int, amount money.Money) error {
+	result := wr.db.Model(&entity.User{}).
+		Where("id=? AND wallet - wallet_held >= ?", userid, amount).
+		Update("wallet_held", gorm.Expr("wallet_held + ?", amount))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("wallet have not enough money")
+	}
+	return nil
+}
+
+// CommitHold turns a hold into a debit recorded in the ledger.
+func (wr *WalletRepository) CommitHold(txn *entity.WalletTransaction) error {
+	return wr.db.Transaction(func(tx *gorm.DB) error {
+		if err := releaseHold(tx, txn.UserId, txn.Amount); err != nil {
+			return err
+		}
+		return wr.WithTx(tx).Debit(txn)
+	})
+}
+
+// ReleaseHold gives held money back to the available balance.
+func (wr *WalletRepository) ReleaseHold(userid int, amount money.Money) error {
+	return releaseHold(wr.db, userid, amount)
+}
+
+func releaseHold(db *gorm.DB, userid int, amount money.Money) error {
+	result := db.Model(&entity.User{}).
+		Where("id=? AND wallet_held >= ?", userid, amount).
+		Update("wallet_held", gorm.Expr("wallet_held - ?", amount))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("wallet hold not found")
+	}
+	return nil
+}
+
+func (wr *WalletRepository) GetTransactions(userid, offset, limit int) ([]entity.WalletTransaction, error) {
+	var txns []entity.WalletTransaction
+	err := wr.db.Where("user_id=?", userid).Order("id desc").Offset(offset).Limit(limit).Find(&txns).Error
+	if err != nil {
+		return nil, errors.New("record not found")
+	}
+	return txns, nil
+}
+
+// GetDiscrepancies lists users whose stored balance differs from the sum of
+// their ledger entries.
+func (wr *WalletRepository) GetDiscrepancies() ([]entity.WalletDiscrepancy, error) {
+	var result []entity.WalletDiscrepancy
+	query := `
+		select
+		u.id as user_id,
+		u.wallet as stored,
+		coalesce(sum(case when t.type = ? then t.amount else -t.amount end), 0) as ledger,
+		count(t.id) as entries
+	from users u
+	left join wallet_transactions t on t.user_id = u.id and t.deleted_at is null
+	where u.deleted_at is null
+	group by u.id, u.wallet
+	having u.wallet <> coalesce(sum(case when t.type = ? then t.amount else -t.amount end), 0)
+	`
+	if err := wr.db.Raw(query, entity.WalletCredit, entity.WalletCredit).Scan(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CreateOpeningBalance records a balance that predates the ledger without
+// touching the stored balance.
+func (wr *WalletRepository) CreateOpeningBalance(userid int, balance money.Money) error {
+	txn := &entity.WalletTransaction{
+		UserId:       userid,
+		Type:         entity.WalletCredit,
+		Amount:       balance,
+		Reason:       entity.WalletReasonOpening,
+		BalanceAfter: balance,
+		PostedAt:     time.Now(),
+	}
+	if balance < 0 {
+		txn.Type = entity.WalletDebit
+		txn.Amount = -balance
+	}
+	return wr.db.Create(txn).Error
+}
+
+func (wr *WalletRepository) SetBalance(userid int, balance money.Money) error {
+	return wr.db.Model(&entity.User{}).Where("id=?", userid).Update("wallet", balance).Error
+}
@@ -0,0 +1,229 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"project/domain/entity"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// setupTokenAge is how long an invited or reset admin has to set their
+// password.
+const setupTokenAge = 72 * time.Hour
+
+// ExecuteSeedSuperAdmin creates the first super admin. It refuses once an
+// active super admin exists, so it cannot be used to take over the shop.
+func (au *AdminUseCase) ExecuteSeedSuperAdmin(name, email, phone, password string) (*entity.Admin, error) {
+	supers, err := au.adminRepo.CountActiveSuperAdmins()
+	if err != nil {
+		return nil, err
+	}
+	if supers > 0 {
+		return nil, errors.New("a super admin already exists")
+	}
+	if len(password) < 8 {
+		return nil, errors.New("password must be at least 8 characters")
+	}
+	if existing, err := au.adminRepo.GetByEmail(email); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, errors.New("an admin with this email already exists")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.New("hashing password failed")
+	}
+	admin := &entity.Admin{
+		AdminName: name,
+		Email:     email,
+		Phone:     phone,
+		Password:  string(hash),
+		Role:      entity.AdminRoleSuper,
+		IsActive:  true,
+	}
+	if err := au.adminRepo.Create(admin); err != nil {
+		return nil, errors.New("creating admin failed")
+	}
+	return admin, nil
+}
+
+// ExecuteAdmins lists the admins.
+func (au *AdminUseCase) ExecuteAdmins() ([]entity.AdminAccount, error) {
+	admins, err := au.adminRepo.GetAdmins()
+	if err != nil {
+		return nil, errors.New("error getting admins")
+	}
+	accounts := make([]entity.AdminAccount, len(admins))
+	for i, admin := range admins {
+		accounts[i] = entity.AdminAccount{
+			Id:          admin.ID,
+			AdminName:   admin.AdminName,
+			Email:       admin.Email,
+			Phone:       admin.Phone,
+			Role:        admin.Role,
+			IsActive:    admin.IsActive,
+			PasswordSet: admin.Password != "",
+		}
+	}
+	return accounts, nil
+}
+
+// ExecuteInviteAdmin creates an admin without a password on behalf of the
+// admin actorid. It returns the setup token the new admin sets their
+// password with. Only super admins invite super admins.
+func (au *AdminUseCase) ExecuteInviteAdmin(actorid int, invite entity.AdminInvite) (*entity.Admin, string, error) {
+	if err := validator.New().Struct(invite); err != nil {
+		return nil, "", err
+	}
+	exists, err := au.roleExists(invite.Role)
+	if err != nil {
+		return nil, "", err
+	}
+	if !exists {
+		return nil, "", errors.New("role not found")
+	}
+	if err := au.canManage(actorid, invite.Role); err != nil {
+		return nil, "", err
+	}
+	if existing, err := au.adminRepo.GetByEmail(invite.Email); err != nil {
+		return nil, "", err
+	} else if existing != nil {
+		return nil, "", errors.New("an admin with this email already exists")
+	}
+	admin := &entity.Admin{
+		AdminName: invite.AdminName,
+		Email:     invite.Email,
+		Phone:     invite.Phone,
+		Role:      invite.Role,
+		IsActive:  true,
+	}
+	token, err := issueSetupToken(admin)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := au.adminRepo.Create(admin); err != nil {
+		return nil, "", errors.New("creating admin failed")
+	}
+	return admin, token, nil
+}
+
+// ExecuteDeactivateAdmin stops the admin id from logging in. The last
+// active super admin cannot be deactivated, nor can admins deactivate
+// themselves.
+func (au *AdminUseCase) ExecuteDeactivateAdmin(actorid, id int) error {
+	if actorid == id {
+		return errors.New("admins cannot deactivate themselves")
+	}
+	admin, err := au.adminRepo.GetAdminById(id)
+	if err != nil {
+		return errors.New("admin not found")
+	}
+	if err := au.canManage(actorid, admin.Role); err != nil {
+		return err
+	}
+	if !admin.IsActive {
+		return errors.New("admin is already deactivated")
+	}
+	if admin.Role == entity.AdminRoleSuper {
+		supers, err := au.adminRepo.CountActiveSuperAdmins()
+		if err != nil {
+			return err
+		}
+		if supers <= 1 {
+			return errors.New("the last super admin cannot be deactivated")
+		}
+	}
+	admin.IsActive = false
+	if err := au.adminRepo.UpdateAdmin(admin); err != nil {
+		return errors.New("admin update failed")
+	}
+	return nil
+}
+
+// ExecuteResetAdmin clears the password of the admin id and reactivates
+// them. It returns the setup token they set a new password with.
+func (au *AdminUseCase) ExecuteResetAdmin(actorid, id int) (string, error) {
+	admin, err := au.adminRepo.GetAdminById(id)
+	if err != nil {
+		return "", errors.New("admin not found")
+	}
+	if err := au.canManage(actorid, admin.Role); err != nil {
+		return "", err
+	}
+	token, err := issueSetupToken(admin)
+	if err != nil {
+		return "", err
+	}
+	admin.Password = ""
+	admin.IsActive = true
+	if err := au.adminRepo.UpdateAdmin(admin); err != nil {
+		return "", errors.New("admin update failed")
+	}
+	return token, nil
+}
+
+// ExecuteSetupAdminPassword sets the password of the admin the setup
+// token was issued to. The token can only be used once.
+func (au *AdminUseCase) ExecuteSetupAdminPassword(setup entity.AdminPasswordSetup) error {
+	if err := validator.New().Struct(setup); err != nil {
+		return err
+	}
+	admin, err := au.adminRepo.GetBySetupToken(hashSetupToken(setup.Token))
+	if err != nil {
+		return errors.New("invalid setup token")
+	}
+	if admin.SetupTokenExpiry == nil || time.Now().After(*admin.SetupTokenExpiry) {
+		return errors.New("setup token has expired")
+	}
+	if !admin.IsActive {
+		return errors.New("admin is deactivated")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(setup.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("hashing password failed")
+	}
+	admin.Password = string(hash)
+	admin.SetupTokenHash = ""
+	admin.SetupTokenExpiry = nil
+	if err := au.adminRepo.UpdateAdmin(admin); err != nil {
+		return errors.New("admin update failed")
+	}
+	return nil
+}
+
+// canManage checks that actorid may manage admins of the role. Admins
+// holding the admins permission manage everyone but super admins.
+func (au *AdminUseCase) canManage(actorid int, role string) error {
+	if role != entity.AdminRoleSuper {
+		return nil
+	}
+	actor, err := au.adminRepo.GetAdminById(actorid)
+	if err != nil || actor.Role != entity.AdminRoleSuper {
+		return errors.New("only super admins can manage super admins")
+	}
+	return nil
+}
+
+// issueSetupToken gives the admin a new setup token, replacing any earlier
+// one, and returns it. Only its hash is kept.
+func issueSetupToken(admin *entity.Admin) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New("generating setup token failed")
+	}
+	token := hex.EncodeToString(b)
+	expiry := time.Now().Add(setupTokenAge)
+	admin.SetupTokenHash = hashSetupToken(token)
+	admin.SetupTokenExpiry = &expiry
+	return token, nil
+}
+
+func hashSetupToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -5,6 +5,8 @@ import (
 	repository "project/repository/admin"
 
 	"project/domain/entity"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 type AdminUseCase struct {
@@ -23,7 +25,13 @@ func (au *AdminUseCase) ExecuteAdminLoginWithPassword(Email, Password string) (i
 	if admin == nil {
 		return 0, errors.New("User doesnt exists")
 	}
-	if admin.Password != Password {
+	if !admin.IsActive {
+		return 0, errors.New("admin is deactivated")
+	}
+	if admin.Password == "" {
+		return 0, errors.New("password not set, use the setup token to set it")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(admin.Password), []byte(Password)); err != nil {
 		return 0, errors.New("Invalid password")
 	} else {
 		return int(admin.ID), nil
@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"errors"
+	"project/domain/entity"
+	"regexp"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+var roleName = regexp.MustCompile(`^[a-z][a-z_]{1,31}$`)
+
+// HasPermission reports whether the admin may use the permission.
+// Deactivated admins have none, super admins have all of them.
+func (au *AdminUseCase) HasPermission(adminId int, permission string) (bool, error) {
+	admin, err := au.adminRepo.GetAdminById(adminId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !admin.IsActive {
+		return false, nil
+	}
+	if admin.Role == entity.AdminRoleSuper {
+		return true, nil
+	}
+	return au.adminRepo.HasRolePermission(admin.Role, permission)
+}
+
+// ExecuteRoles returns the permission matrix, role by role.
+func (au *AdminUseCase) ExecuteRoles() (map[string][]string, error) {
+	permissions, err := au.adminRepo.GetRolePermissions()
+	if err != nil {
+		return nil, errors.New("error getting roles")
+	}
+	roles := map[string][]string{entity.AdminRoleSuper: entity.AdminPermissions}
+	for _, p := range permissions {
+		roles[p.Role] = append(roles[p.Role], p.Permission)
+	}
+	return roles, nil
+}
+
+// ExecuteSetRolePermissions gives the role exactly the permissions,
+// creating the role if it is new. Super admins always hold every
+// permission, so their role cannot be changed.
+func (au *AdminUseCase) ExecuteSetRolePermissions(role string, permissions []string) error {
+	if role == entity.AdminRoleSuper {
+		return errors.New("super admin permissions cannot be changed")
+	}
+	if !roleName.MatchString(role) {
+		return errors.New("role must be lowercase letters and underscores")
+	}
+	if len(permissions) == 0 {
+		return errors.New("a role needs at least one permission")
+	}
+	seen := map[string]bool{}
+	var granted []string
+	for _, permission := range permissions {
+		if !knownPermission(permission) {
+			return errors.New("unknown permission " + permission)
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			granted = append(granted, permission)
+		}
+	}
+	sort.Strings(granted)
+	if err := au.adminRepo.SetRolePermissions(role, granted); err != nil {
+		return errors.New("updating role failed")
+	}
+	return nil
+}
+
+// ExecuteDeleteRole removes a role no admin holds any more.
+func (au *AdminUseCase) ExecuteDeleteRole(role string) error {
+	if role == entity.AdminRoleSuper {
+		return errors.New("the super admin role cannot be deleted")
+	}
+	exists, err := au.adminRepo.RoleExists(role)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("role not found")
+	}
+	admins, err := au.adminRepo.CountAdminsByRole(role)
+	if err != nil {
+		return err
+	}
+	if admins > 0 {
+		return errors.New("role is still held by admins")
+	}
+	if err := au.adminRepo.DeleteRole(role); err != nil {
+		return errors.New("deleting role failed")
+	}
+	return nil
+}
+
+// roleExists reports whether admins can be given the role.
+func (au *AdminUseCase) roleExists(role string) (bool, error) {
+	if role == entity.AdminRoleSuper {
+		return true, nil
+	}
+	return au.adminRepo.RoleExists(role)
+}
+
+func knownPermission(permission string) bool {
+	for _, p := range entity.AdminPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,283 @@
+// Package auth issues the login tokens and keeps their server side. An
+// access token is a short lived JWT; the refresh token that comes with it
+// is stored as a hash and replaced on every use, so a stolen one stops
+// working once either party refreshes. Logging out revokes the access
+// token until it expires, and logging out of all devices ends every
+// session started before it.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"project/config"
+	"project/domain/entity"
+	tokenrepository "project/repository/token"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAccessTTL  = 15 * time.Minute
+	defaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrRevokedToken = errors.New("token has been revoked")
+)
+
+// Claims is who an access token was issued to.
+type Claims struct {
+	UserId int
+	Email  string
+	Role   string
+	Jti    string
+	Issued time.Time
+	Expiry time.Time
+}
+
+type Sessions struct {
+	tokenRepo  *tokenrepository.TokenRepository
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewSessions signs with the configured secret. Without one a random key
+// is used, so everyone has to log in again after a restart.
+func NewSessions(tokenRepo *tokenrepository.TokenRepository, cfg *config.JWT) *Sessions {
+	s := &Sessions{tokenRepo: tokenRepo, secret: []byte(cfg.JWTSecret), accessTTL: cfg.AccessTokenTTL, refreshTTL: cfg.RefreshTokenTTL}
+	if len(s.secret) == 0 {
+		log.Println("JWTSECRET not set, logins will not survive a restart")
+		s.secret = make([]byte, 32)
+		if _, err := rand.Read(s.secret); err != nil {
+			log.Fatalf("generating jwt key failed: %v", err)
+		}
+	}
+	if s.accessTTL <= 0 {
+		s.accessTTL = defaultAccessTTL
+	}
+	if s.refreshTTL <= 0 {
+		s.refreshTTL = defaultRefreshTTL
+	}
+	return s
+}
+
+func (s *Sessions) AccessTTL() time.Duration  { return s.accessTTL }
+func (s *Sessions) RefreshTTL() time.Duration { return s.refreshTTL }
+
+// Issue starts a session, returning its access and refresh tokens.
+func (s *Sessions) Issue(userId int, email, role string) (string, string, error) {
+	family, err := randomToken()
+	if err != nil {
+		return "", "", err
+	}
+	refresh, _, err := s.newRefreshToken(s.tokenRepo, userId, email, role, family)
+	if err != nil {
+		return "", "", err
+	}
+	access, err := s.newAccessToken(userId, email, role)
+	if err != nil {
+		return "", "", err
+	}
+	return access, refresh, nil
+}
+
+// Refresh replaces the refresh token of a session of the role with a new
+// one and returns it with a new access token. Using a replaced refresh
+// token again revokes its whole family: either it was stolen or the thief
+// already used it.
+func (s *Sessions) Refresh(refresh, role string) (string, string, error) {
+	now := time.Now()
+	token, err := s.tokenRepo.GetRefreshToken(hashToken(refresh))
+	if err != nil || token.Role != role {
+		return "", "", ErrInvalidToken
+	}
+	if token.RevokedAt != nil {
+		if token.ReplacedBy != 0 {
+			s.revokeFamily(token)
+		}
+		return "", "", ErrRevokedToken
+	}
+	if now.After(token.ExpiresAt) {
+		return "", "", ErrInvalidToken
+	}
+	var next string
+	err = s.tokenRepo.Transaction(func(tx *gorm.DB) error {
+		tokenRepo := s.tokenRepo.WithTx(tx)
+		var id uint
+		var err error
+		if next, id, err = s.newRefreshToken(tokenRepo, token.UserId, token.Email, token.Role, token.Family); err != nil {
+			return err
+		}
+		return tokenRepo.ReplaceRefreshToken(token, id, now)
+	})
+	if errors.Is(err, tokenrepository.ErrTokenReused) {
+		s.revokeFamily(token)
+		return "", "", ErrRevokedToken
+	}
+	if err != nil {
+		return "", "", err
+	}
+	access, err := s.newAccessToken(token.UserId, token.Email, token.Role)
+	if err != nil {
+		return "", "", err
+	}
+	return access, next, nil
+}
+
+// Validate checks the access token's signature, expiry and revocation.
+// Tokens carry their issue time in whole seconds, so the cutoff is taken
+// to the second as well: a token from the second of the cutoff is kept, as
+// it is the login that follows a logout of all devices.
+func (s *Sessions) Validate(access string) (*Claims, error) {
+	claims, err := s.parse(access)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := s.tokenRepo.IsAccessTokenRevoked(claims.Jti)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrRevokedToken
+	}
+	cutoff, err := s.tokenRepo.GetCutoff(claims.UserId, claims.Role)
+	if err != nil {
+		return nil, err
+	}
+	if cutoff != nil && claims.Issued.Before(cutoff.Truncate(time.Second)) {
+		return nil, ErrRevokedToken
+	}
+	return claims, nil
+}
+
+// Revoke ends the session of the tokens. Either may be empty.
+func (s *Sessions) Revoke(access, refresh string) error {
+	if access != "" {
+		if claims, err := s.parse(access); err == nil {
+			if err := s.tokenRepo.RevokeAccessToken(claims.Jti, claims.Expiry); err != nil {
+				return err
+			}
+		}
+	}
+	if refresh != "" {
+		if token, err := s.tokenRepo.GetRefreshToken(hashToken(refresh)); err == nil {
+			if err := s.tokenRepo.RevokeRefreshFamily(token.Family, time.Now()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RevokeAll ends every session of the user or admin.
+func (s *Sessions) RevokeAll(userId int, role string) error {
+	return s.tokenRepo.RevokeAll(userId, role, time.Now().Truncate(time.Second))
+}
+
+// StartPurger drops expired revocations and refresh tokens now and then
+// every interval. It blocks, so run it in its own goroutine.
+func (s *Sessions) StartPurger(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.tokenRepo.Purge(time.Now()); err != nil {
+			log.Printf("token purger: %v", err)
+		}
+		<-ticker.C
+	}
+}
+
+func (s *Sessions) revokeFamily(token *entity.RefreshToken) {
+	log.Printf("refresh token of %s %d reused, revoking its family", token.Role, token.UserId)
+	if err := s.tokenRepo.RevokeRefreshFamily(token.Family, time.Now()); err != nil {
+		log.Printf("revoking refresh tokens failed: %v", err)
+	}
+}
+
+func (s *Sessions) newAccessToken(userId int, email, role string) (string, error) {
+	jti, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userId": userId,
+		"email":  email,
+		"role":   role,
+		"jti":    jti,
+		"iat":    now.Unix(),
+		"exp":    now.Add(s.accessTTL).Unix(),
+	})
+	return token.SignedString(s.secret)
+}
+
+func (s *Sessions) newRefreshToken(tokenRepo *tokenrepository.TokenRepository, userId int, email, role, family string) (string, uint, error) {
+	refresh, err := randomToken()
+	if err != nil {
+		return "", 0, err
+	}
+	token := &entity.RefreshToken{
+		UserId:    userId,
+		Role:      role,
+		Email:     email,
+		Family:    family,
+		TokenHash: hashToken(refresh),
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+	if err := tokenRepo.CreateRefreshToken(token); err != nil {
+		return "", 0, errors.New("saving refresh token failed")
+	}
+	return refresh, token.ID, nil
+}
+
+// parse checks the access token's signature and expiry.
+func (s *Sessions) parse(access string) (*Claims, error) {
+	token, err := jwt.Parse(access, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return s.secret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	claims := &Claims{}
+	id, _ := mapClaims["userId"].(float64)
+	claims.UserId = int(id)
+	claims.Email, _ = mapClaims["email"].(string)
+	claims.Role, _ = mapClaims["role"].(string)
+	claims.Jti, _ = mapClaims["jti"].(string)
+	iat, _ := mapClaims["iat"].(float64)
+	exp, okExp := mapClaims["exp"].(float64)
+	if claims.Jti == "" || !okExp {
+		return nil, ErrInvalidToken
+	}
+	claims.Issued = time.Unix(int64(iat), 0)
+	claims.Expiry = time.Unix(int64(exp), 0)
+	return claims, nil
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New("generating token failed")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
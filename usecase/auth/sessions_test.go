@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"project/config"
+	"project/repository/infrastructure/dbtest"
+	tokenrepository "project/repository/token"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// TestLoginAfterRevokeAll logs in straight after logging out of all
+// devices. The new session must work while older tokens stay revoked.
+func TestLoginAfterRevokeAll(t *testing.T) {
+	db := dbtest.Open(t)
+	s := NewSessions(tokenrepository.NewTokenRepository(db), &config.JWT{JWTSecret: "secret"})
+
+	before := time.Now().Add(-time.Minute)
+	old, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"userId": 1,
+		"role":   "user",
+		"jti":    "old",
+		"iat":    before.Unix(),
+		"exp":    before.Add(time.Hour).Unix(),
+	}).SignedString(s.secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RevokeAll(1, "user"); err != nil {
+		t.Fatal(err)
+	}
+	access, _, err := s.Issue(1, "user@example.com", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Validate(access); err != nil {
+		t.Errorf("login right after revoking all sessions: %v", err)
+	}
+	if _, err := s.Validate(old); err != ErrRevokedToken {
+		t.Errorf("token from before the revocation: got %v, want ErrRevokedToken", err)
+	}
+}
@@ -3,23 +3,35 @@ package usecase
 import (
 	"errors"
 	"log"
+	"project/config"
 	"project/domain/entity"
 	repository "project/repository/cart"
+	orderrepository "project/repository/order"
 	productrepository "project/repository/product"
+	userrepository "project/repository/user"
+	"project/usecase/coupon"
+	"project/usecase/offer"
+	"project/usecase/repricing"
+	"project/usecase/tax"
+
+	"gorm.io/gorm"
 )
 
 type CartUseCase struct {
 	cartRepo    *repository.CartRepository
 	productRepo *productrepository.ProductRepository
+	userRepo    *userrepository.UserRepository
+	taxes       *tax.Calculator
+	coupons     *coupon.Engine
+	offers      *offer.Resolver
+	repricer    *repricing.Repricer
 }
 
-func NewCart(cartRepo *repository.CartRepository, productRepo *productrepository.ProductRepository) *CartUseCase {
-	return &CartUseCase{cartRepo: cartRepo, productRepo: productRepo}
+func NewCart(cartRepo *repository.CartRepository, productRepo *productrepository.ProductRepository, userRepo *userrepository.UserRepository, orderRepo *orderrepository.OrderRepository, seller *config.Seller) *CartUseCase {
+	return &CartUseCase{cartRepo: cartRepo, productRepo: productRepo, userRepo: userRepo, taxes: tax.NewCalculator(productRepo, seller), coupons: coupon.NewEngine(productRepo, cartRepo, orderRepo), offers: offer.NewResolver(productRepo), repricer: repricing.NewRepricer(productRepo, cartRepo)}
 }
 
 func (cu *CartUseCase) ExecuteAddToCart( id int, quantity int, userid int) error {
-	var usercart *entity.Cart
-	var cartid int
 	usercart, err := cu.cartRepo.GetByUserid(userid)
 	if err != nil {
 		cart, err1 := cu.cartRepo.Create(userid)
@@ -27,27 +39,43 @@ func (cu *CartUseCase) ExecuteAddToCart( id int, quantity int, userid int) error
 			return errors.New("failed to create userid")
 		}
 		usercart = cart
-		cartid = int(cart.ID)
-	} else {
-		cartid = int(usercart.ID)
 	}
+	return cu.addToCart(usercart, id, quantity)
+}
 
-	prod, err := cu.productRepo.GetProductById(id)
+// ExecuteGuestAddToCart adds the product to the cart of the anonymous
+// shopper with the guest id, creating it on their first product.
+func (cu *CartUseCase) ExecuteGuestAddToCart(id, quantity int, guestid string) error {
+	guestcart, err := cu.cartRepo.GetByGuestId(guestid)
 	if err != nil {
+		if guestcart, err = cu.cartRepo.CreateGuest(guestid); err != nil {
+			return errors.New("failed to create guest cart")
+		}
+	}
+	return cu.addToCart(guestcart, id, quantity)
+}
+
+func (cu *CartUseCase) addToCart(usercart *entity.Cart, id, quantity int) error {
+	cartid := int(usercart.ID)
+	prod, err := cu.productRepo.GetProductById(id)
+	if err != nil || prod.Removed {
 		return errors.New("product not found")
 	}
+	if err := cu.offers.Product(prod); err != nil {
+		return err
+	}
 	cartitem := &entity.CartItem{
 		CartId:      cartid,
 		ProductId:   int(prod.ID),
 		Category:    prod.Category,
 		Quantity:    quantity,
 		ProductName: prod.Name,
-		Price:       int(prod.OfferPrize),
+		Price:       prod.OfferPrize,
 	}
-	existingProduct, _ := cu.cartRepo.GetByName(prod.Name, cartid)
+	existingProduct, _ := cu.cartRepo.GetByProductId(prod.ID, cartid)
 
 	if cartitem.Price == 0 {
-		cartitem.Price = int(prod.Price)
+		cartitem.Price = prod.Price
 	}
 	if existingProduct == nil {
 		err := cu.cartRepo.CreateCartItem(cartitem)
@@ -61,13 +89,71 @@ func (cu *CartUseCase) ExecuteAddToCart( id int, quantity int, userid int) error
 			return errors.New("error updating new cart item")
 		}
 	}
-	usercart.TotalPrize += cartitem.Price * int(quantity)
-	usercart.ProductQuantity += quantity
-	err1 := cu.cartRepo.UpdateCart(usercart)
-	if err1 != nil {
-		return errors.New("cart updation failed")
+	_, err = cu.refresh(usercart)
+	return err
+}
+
+// ExecuteMergeGuestCart moves the guest's cart into the user's cart when
+// they log in or sign up. A product in both carts keeps the sum of the
+// quantities, as far as the stock goes; guest lines out of stock are
+// dropped. Without a guest cart there is nothing to merge.
+func (cu *CartUseCase) ExecuteMergeGuestCart(guestid string, userid int) error {
+	var usercart *entity.Cart
+	err := cu.cartRepo.Transaction(func(tx *gorm.DB) error {
+		cartRepo := cu.cartRepo.WithTx(tx)
+		repricer := cu.repricer.WithTx(tx)
+		guestcart, err := cartRepo.GetByGuestId(guestid)
+		if err != nil {
+			return nil
+		}
+		guestitems, err := cartRepo.GetAllCartItems(int(guestcart.ID))
+		if err != nil {
+			return errors.New("cart items not found")
+		}
+		usercart, err = cartRepo.GetByUserid(userid)
+		if err != nil {
+			if usercart, err = cartRepo.Create(userid); err != nil {
+				return errors.New("failed to create user cart")
+			}
+		}
+		for _, item := range guestitems {
+			available := repricer.Available(item.ProductId)
+			existing, _ := cartRepo.GetByProductId(item.ProductId, int(usercart.ID))
+			if existing == nil {
+				if item.Quantity = min(item.Quantity, available); item.Quantity <= 0 {
+					continue
+				}
+				item.CartId = int(usercart.ID)
+				if err := cartRepo.UpdateCartItem(&item); err != nil {
+					return errors.New("error updating cart item")
+				}
+				continue
+			}
+			quantity := existing.Quantity + item.Quantity
+			if quantity > available {
+				quantity = max(available, existing.Quantity)
+			}
+			if quantity == existing.Quantity {
+				continue
+			}
+			existing.Quantity = quantity
+			if err := cartRepo.UpdateCartItem(existing); err != nil {
+				return errors.New("error updating cart item")
+			}
+		}
+		if err := cartRepo.RemoveCartItems(int(guestcart.ID)); err != nil {
+			return errors.New("removing guest cart failed")
+		}
+		if err := cartRepo.RemoveCart(guestcart); err != nil {
+			return errors.New("removing guest cart failed")
+		}
+		return nil
+	})
+	if err != nil || usercart == nil {
+		return err
 	}
-	return nil
+	_, err = cu.refresh(usercart)
+	return err
 }
 
 func (cu *CartUseCase) ExecuteCartItems(userId int) ([]entity.CartItem, error) {
@@ -87,17 +173,23 @@ func (cu *CartUseCase) ExecuteRemoveCartItem(userid, id int) error {
 	if err != nil {
 		return errors.New("error finding user cart")
 	}
-	prod, err := cu.productRepo.GetProductById(id)
+	return cu.removeCartItem(usercart, id)
+}
+
+func (cu *CartUseCase) ExecuteGuestRemoveCartItem(guestid string, id int) error {
+	guestcart, err := cu.cartRepo.GetByGuestId(guestid)
 	if err != nil {
-		return errors.New("product not found")
+		return errors.New("error finding guest cart")
 	}
-	// existingProd, err := cu.cartRepo.GetByName(product, int(usercart.ID))
-	// if err != nil {
-	// 	return errors.New("removing product failed")
-	// }
-	existingProd, err := cu.cartRepo.GetByName(prod.Name, int(usercart.ID))
+	return cu.removeCartItem(guestcart, id)
+}
+
+func (cu *CartUseCase) removeCartItem(usercart *entity.Cart, id int) error {
+	// The product may be gone from the shop, so the line is found by its
+	// product id alone.
+	existingProd, err := cu.cartRepo.GetByProductId(id, int(usercart.ID))
 	if err != nil {
-		return errors.New("removing product failed")
+		return errors.New("product not found")
 	}
 
 
@@ -114,20 +206,54 @@ func (cu *CartUseCase) ExecuteRemoveCartItem(userid, id int) error {
 			return errors.New("error upadting user")
 		}
 	}
-	if prod.OfferPrize != 0{
-		usercart.TotalPrize -= int(prod.OfferPrize)
-	}else{
-	usercart.TotalPrize -= int(prod.Price)
+	_, err = cu.refresh(usercart)
+	return err
+}
+
+// CartView is the cart as the user reviews it: repriced, with its coupons
+// and GST.
+type CartView struct {
+	Cart  *entity.Cart      `json:"usercart"`
+	Items []entity.CartItem `json:"items"`
+	// Review is set while lines are flagged; checkout waits until the
+	// changes are acknowledged.
+	Review  bool            `json:"review"`
+	Coupons *coupon.Pricing `json:"coupons"`
+}
+
+// refresh reprices the cart lines and the coupons on the cart.
+func (cu *CartUseCase) refresh(cart *entity.Cart) (*CartView, error) {
+	items, err := cu.cartRepo.GetAllCartItems(int(cart.ID))
+	if err != nil {
+		return nil, errors.New("cart items not found")
+	}
+	review, err := cu.repricer.Reprice(cart, items)
+	if err != nil {
+		return nil, err
+	}
+	pricing, err := cu.coupons.Refresh(cart.UserId, cart, items)
+	if err != nil {
+		return nil, err
 	}
-	usercart.ProductQuantity -= 1
-	if usercart.OfferPrize > 0 {
-		usercart.OfferPrize = 0
+	return &CartView{Cart: cart, Items: items, Review: review, Coupons: pricing}, nil
+}
+
+// ExecuteAcknowledgeCart accepts the flagged changes to the user's cart:
+// new prices are taken, unavailable lines dropped and short lines cut down
+// to the stock left.
+func (cu *CartUseCase) ExecuteAcknowledgeCart(userid int) (*CartView, error) {
+	usercart, err := cu.cartRepo.GetByUserid(userid)
+	if err != nil {
+		return nil, errors.New("failed to find user cart")
 	}
-	err1 := cu.cartRepo.UpdateCart(usercart)
-	if err1 != nil {
-		return errors.New("Remove from cart failed")
+	items, err := cu.cartRepo.GetAllCartItems(int(usercart.ID))
+	if err != nil {
+		return nil, errors.New("cart items not found")
 	}
-	return nil
+	if _, err := cu.repricer.Acknowledge(usercart, items); err != nil {
+		return nil, err
+	}
+	return cu.refresh(usercart)
 }
 
 func (cu *CartUseCase) ExecuteAddWishlist(productid int, userid int) error {
@@ -147,7 +273,7 @@ func (cu *CartUseCase) ExecuteAddWishlist(productid int, userid int) error {
 			Category:    product.Category,
 			ProductId:   product.ID,
 			ProductName: product.Name,
-			Prize:       int(product.Price),
+			Prize:       product.Price,
 		}
 		err := cu.cartRepo.AddProductToWishlist(wishprod)
 		if err != nil {
@@ -181,66 +307,14 @@ func (cu *CartUseCase) ExecuteViewWishlist(userid int) ([]entity.WishList, error
 	return *wishlist, nil
 }
 
-func (c *CartUseCase) ExecuteApplyCoupon(userId int, code string) (int, error) {
-	var totaloffer, totalprize int
-	usercart, err := c.cartRepo.GetByUserid(userId)
-	if err != nil {
-		return 0, errors.New("failed to find user cart")
-	}
-	coupon, err := c.productRepo.GetCouponByCode(code)
-	if err != nil {
-		return 0, errors.New("coupon not found")
-	}
-	if coupon.UsedCount >= coupon.UsageLimit{
-		return 0,errors.New("coupon usage exeeded")
-	}else{
-	// cartitems, err := c.cartRepo.GetAllCartItems(int(usercart.ID))
-	// if err != nil {
-	// 	return 0, errors.New("user cart item not found")
-	// }
-	// for _, cartitem := range cartitems {
-	// 	if cartitem.Category == coupon.Category {
-	// 		totalprize += int(cartitem.Price) * cartitem.Quantity
-	// 	}
-	// }
-	totalprize = usercart.TotalPrize
-
-	if totalprize > 0 {
-		if coupon.Type == "percentage" {
-			totaloffer = totalprize / coupon.Amount
-		} else {
-			totaloffer = coupon.Amount
-		}
-	} else {
-		return 0, errors.New("Add more products")
-	}
-	if usercart.OfferPrize != 0 {
-		return 0, errors.New("usercart offer already applied")
-	} else {
-		usercart.OfferPrize = totaloffer
-		err := c.cartRepo.UpdateCart(usercart)
-		if err != nil {
-			return 0, errors.New("user cart update failed")
-		}
-		var UsedCoupon = entity.UsedCoupon{
-			UserId:     userId,
-			CouponCode: code,
-		}
-		err1 := c.productRepo.UpdateCouponUsage(&UsedCoupon)
-		if err1 != nil {
-			return 0, errors.New("user coupon usage updation failed")
-		}
-		coupon.UsedCount=coupon.UsedCount + 1
-		
-		err = c.productRepo.UpdateCouponCount(coupon)
-		if err != nil {
-			return 0, errors.New("user update coupon count failed")
-		}
-	}
-
-	}
-	return totaloffer, nil
+// ExecuteApplyCoupon adds the coupon to the user's cart alongside the ones
+// already on it. The coupon is only used up when the order is placed.
+func (c *CartUseCase) ExecuteApplyCoupon(userId int, code string) (*coupon.Pricing, error) {
+	return c.coupons.Apply(userId, code)
+}
 
+func (c *CartUseCase) ExecuteRemoveCoupon(userId int, code string) (*coupon.Pricing, error) {
+	return c.coupons.Remove(userId, code)
 }
 
 func (c *CartUseCase) ExecuteOfferCheck(userid int) (*[]entity.Offer, error) {
@@ -248,7 +322,7 @@ func (c *CartUseCase) ExecuteOfferCheck(userid int) (*[]entity.Offer, error) {
 	if err != nil {
 		return nil, errors.New("failed to find user cart")
 	}
-	offer, err := c.productRepo.GetOfferByPrize(int(usercart.TotalPrize))
+	offer, err := c.productRepo.GetOfferByPrize(usercart.TotalPrize)
 	if err != nil {
 		return nil, errors.New("add few more products")
 	}
@@ -264,6 +338,67 @@ func (cu *CartUseCase) ExecuteCart(userid int) (*entity.Cart, error) {
 	}
 }
 
+// ExecuteCartTax reprices the user's cart and prices it with its coupons
+// and GST for delivery to addressid, or to the default address when
+// addressid is 0. Without an address the cart is priced as a sale within
+// the seller's state. Coupons the cart no longer qualifies for are taken
+// off.
+func (cu *CartUseCase) ExecuteCartTax(userid, addressid int) (*CartView, *tax.Bill, error) {
+	userCart, err := cu.cartRepo.GetByUserid(userid)
+	if err != nil {
+		return nil, nil, errors.New("failed to find user")
+	}
+	view, err := cu.refresh(userCart)
+	if err != nil {
+		return nil, nil, err
+	}
+	state := ""
+	if addressid != 0 {
+		address, err := cu.userRepo.GetUserAddress(userid, addressid)
+		if err != nil {
+			return nil, nil, errors.New("address not found")
+		}
+		state = address.State
+	} else if address, err := cu.userRepo.GetDefaultAddress(userid); err == nil {
+		state = address.State
+	}
+	bill, err := cu.taxes.Cart(view.Items, state)
+	if err != nil {
+		return nil, nil, err
+	}
+	return view, bill, nil
+}
+
+// ExecuteGuestCartTax reprices the guest's cart and prices it with GST
+// as a sale within the seller's state; guests have no address yet.
+func (cu *CartUseCase) ExecuteGuestCartTax(guestid string) (*CartView, *tax.Bill, error) {
+	guestCart, err := cu.cartRepo.GetByGuestId(guestid)
+	if err != nil {
+		return nil, nil, errors.New("failed to find guest cart")
+	}
+	view, err := cu.refresh(guestCart)
+	if err != nil {
+		return nil, nil, err
+	}
+	bill, err := cu.taxes.Cart(view.Items, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	return view, bill, nil
+}
+
+func (cu *CartUseCase) ExecuteGuestCartitem(guestid string) (*[]entity.CartItem, error) {
+	guestCart, err := cu.cartRepo.GetByGuestId(guestid)
+	if err != nil {
+		return nil, errors.New("Failed to find guest cart")
+	}
+	cartitems, err := cu.cartRepo.GetAllCartItems(int(guestCart.ID))
+	if err != nil {
+		return nil, err
+	}
+	return &cartitems, nil
+}
+
 func (cu *CartUseCase) ExecuteCartitem(userid int) (*[]entity.CartItem, error) {
 	userCart, err := cu.cartRepo.GetByUserid(userid)
 	if err != nil {
@@ -0,0 +1,337 @@
+// Package coupon decides which coupons a cart may use and what they take
+// off. Coupons are evaluated in the order they were applied, each on what
+// the earlier ones left of the items it covers, and are only redeemed once
+// the order is placed.
+package coupon
+
+import (
+	"errors"
+	"fmt"
+	"project/domain/entity"
+	"project/domain/money"
+	cartrepository "project/repository/cart"
+	orderrepository "project/repository/order"
+	productrepository "project/repository/product"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Rejection is a coupon that no longer applies to the cart and why.
+type Rejection struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// Pricing is what the coupons on a cart take off. The per item shares are
+// set on the cart items the pricing was worked out for.
+type Pricing struct {
+	Applied  []entity.CartCoupon `json:"applied"`
+	Rejected []Rejection         `json:"rejected,omitempty"`
+	Discount money.Money         `json:"discount"`
+
+	lines   []money.Money
+	dropped []entity.CartCoupon
+}
+
+type Engine struct {
+	productRepo *productrepository.ProductRepository
+	cartRepo    *cartrepository.CartRepository
+	orderRepo   *orderrepository.OrderRepository
+}
+
+func NewEngine(productRepo *productrepository.ProductRepository, cartRepo *cartrepository.CartRepository, orderRepo *orderrepository.OrderRepository) *Engine {
+	return &Engine{productRepo: productRepo, cartRepo: cartRepo, orderRepo: orderRepo}
+}
+
+// WithTx returns a copy of the engine whose repositories are bound to the
+// given transaction.
+func (e *Engine) WithTx(tx *gorm.DB) *Engine {
+	return &Engine{productRepo: e.productRepo.WithTx(tx), cartRepo: e.cartRepo.WithTx(tx), orderRepo: e.orderRepo.WithTx(tx)}
+}
+
+// Apply adds the coupon to the user's cart. A coupon the cart is not
+// eligible for is refused with the reason.
+func (e *Engine) Apply(userid int, code string) (*Pricing, error) {
+	cart, items, err := e.cart(userid)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, errors.New("Add more products")
+	}
+	coupon, err := e.productRepo.GetCouponByCode(code)
+	if err != nil {
+		return nil, errors.New("coupon not found")
+	}
+	applied, err := e.cartRepo.GetCartCoupons(int(cart.ID))
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range applied {
+		if c.Code == coupon.Code {
+			return nil, errors.New("coupon already applied")
+		}
+	}
+	applied = append(applied, entity.CartCoupon{CartId: int(cart.ID), CouponId: coupon.Id, Code: coupon.Code})
+	pricing, err := e.evaluate(userid, items, applied, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range pricing.Rejected {
+		if r.Code == coupon.Code {
+			return nil, errors.New(r.Reason)
+		}
+	}
+	if err := e.save(cart, items, pricing); err != nil {
+		return nil, err
+	}
+	return pricing, nil
+}
+
+// Remove takes the coupon off the user's cart.
+func (e *Engine) Remove(userid int, code string) (*Pricing, error) {
+	cart, items, err := e.cart(userid)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := e.cartRepo.GetCartCoupons(int(cart.ID))
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for i := range applied {
+		if applied[i].Code == code {
+			if err := e.cartRepo.RemoveCartCoupon(&applied[i]); err != nil {
+				return nil, errors.New("removing coupon failed")
+			}
+			found = true
+		}
+	}
+	if !found {
+		return nil, errors.New("coupon not applied to the cart")
+	}
+	return e.Refresh(userid, cart, items)
+}
+
+// Refresh works the coupons on the cart out again after the cart or the
+// coupons changed, dropping the ones that no longer apply. It sets the
+// item discounts and the cart's OfferPrize.
+func (e *Engine) Refresh(userid int, cart *entity.Cart, items []entity.CartItem) (*Pricing, error) {
+	applied, err := e.cartRepo.GetCartCoupons(int(cart.ID))
+	if err != nil {
+		return nil, err
+	}
+	pricing, err := e.evaluate(userid, items, applied, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := e.save(cart, items, pricing); err != nil {
+		return nil, err
+	}
+	return pricing, nil
+}
+
+// Redeem uses up the applied coupons for the order.
+func (e *Engine) Redeem(userid, orderid int, pricing *Pricing) error {
+	for _, c := range pricing.Applied {
+		if err := e.productRepo.RedeemCoupon(c.Code); err != nil {
+			return fmt.Errorf("coupon %s: %w", c.Code, err)
+		}
+		used := &entity.UsedCoupon{UserId: userid, CouponCode: c.Code, OrderId: orderid, Discount: c.Discount}
+		if err := e.productRepo.UpdateCouponUsage(used); err != nil {
+			return errors.New("user coupon usage updation failed")
+		}
+	}
+	return nil
+}
+
+// Release gives back the coupons redeemed for an order that was cancelled
+// or never paid for.
+func (e *Engine) Release(orderid int) error {
+	if err := e.productRepo.ReleaseCoupons(orderid); err != nil {
+		return errors.New("releasing coupons failed")
+	}
+	return nil
+}
+
+func (e *Engine) cart(userid int) (*entity.Cart, []entity.CartItem, error) {
+	cart, err := e.cartRepo.GetByUserid(userid)
+	if err != nil {
+		return nil, nil, errors.New("failed to find user cart")
+	}
+	items, err := e.cartRepo.GetAllCartItems(int(cart.ID))
+	if err != nil {
+		return nil, nil, errors.New("cart items not found")
+	}
+	return cart, items, nil
+}
+
+// evaluate prices the coupons against the items, in order.
+func (e *Engine) evaluate(userid int, items []entity.CartItem, applied []entity.CartCoupon, now time.Time) (*Pricing, error) {
+	pricing := &Pricing{lines: make([]money.Money, len(items))}
+	var subtotal money.Money
+	remaining := make([]money.Money, len(items))
+	for i := range items {
+		remaining[i] = items[i].Price.Mul(items[i].Quantity)
+		subtotal += remaining[i]
+	}
+	var accepted []*entity.Coupon
+	for _, cc := range applied {
+		coupon, err := e.productRepo.GetCouponByCode(cc.Code)
+		if err != nil {
+			pricing.reject(cc, "coupon not found")
+			continue
+		}
+		reason, err := e.check(userid, coupon, subtotal, now)
+		if err != nil {
+			return nil, err
+		}
+		if reason == "" {
+			reason = stacking(coupon, accepted)
+		}
+		var shares []money.Money
+		if reason == "" {
+			if shares = discount(coupon, items, remaining); shares == nil {
+				reason = "coupon does not apply to the cart items"
+			}
+		}
+		if reason != "" {
+			pricing.reject(cc, reason)
+			continue
+		}
+		cc.Discount = 0
+		for i, share := range shares {
+			pricing.lines[i] += share
+			remaining[i] -= share
+			cc.Discount += share
+		}
+		accepted = append(accepted, coupon)
+		pricing.Applied = append(pricing.Applied, cc)
+		pricing.Discount += cc.Discount
+	}
+	return pricing, nil
+}
+
+// check is why the user cannot use the coupon on a cart of subtotal, or
+// empty when they can.
+func (e *Engine) check(userid int, coupon *entity.Coupon, subtotal money.Money, now time.Time) (string, error) {
+	if !coupon.ValidFrom.IsZero() && now.Before(coupon.ValidFrom) {
+		return "coupon is not active yet", nil
+	}
+	if now.After(coupon.Validuntil) {
+		return "coupon has expired", nil
+	}
+	if coupon.UsedCount >= coupon.UsageLimit {
+		return "coupon usage exceeded", nil
+	}
+	if subtotal < coupon.MinCartValue {
+		return "coupon needs a cart of at least " + coupon.MinCartValue.Format(), nil
+	}
+	if coupon.PerUserLimit > 0 {
+		uses, err := e.productRepo.CountCouponUses(userid, coupon.Code)
+		if err != nil {
+			return "", err
+		}
+		if uses >= int64(coupon.PerUserLimit) {
+			return "coupon already used", nil
+		}
+	}
+	if coupon.FirstOrderOnly {
+		orders, err := e.orderRepo.CountPlacedOrders(userid)
+		if err != nil {
+			return "", err
+		}
+		if orders > 0 {
+			return "coupon is only valid on the first order", nil
+		}
+	}
+	return "", nil
+}
+
+// stacking is why coupon cannot join the coupons already accepted, or
+// empty when it can.
+func stacking(coupon *entity.Coupon, accepted []*entity.Coupon) string {
+	for _, other := range accepted {
+		if !coupon.Stackable || !other.Stackable {
+			return "coupon cannot be combined with " + other.Code
+		}
+	}
+	return ""
+}
+
+func covers(coupon *entity.Coupon, item entity.CartItem) bool {
+	if coupon.ProductId != 0 && coupon.ProductId != item.ProductId {
+		return false
+	}
+	return coupon.Category == 0 || coupon.Category == item.Category
+}
+
+// discount is what the coupon takes off each item, given what is left of
+// them, or nil when it covers none of them.
+func discount(coupon *entity.Coupon, items []entity.CartItem, remaining []money.Money) []money.Money {
+	weights := make([]money.Money, len(items))
+	var base money.Money
+	for i, item := range items {
+		if covers(coupon, item) && remaining[i] > 0 {
+			weights[i] = remaining[i]
+			base += remaining[i]
+		}
+	}
+	if base <= 0 {
+		return nil
+	}
+	var off money.Money
+	if coupon.Type == entity.CouponPercentage {
+		off = base.Percent(coupon.Percent)
+	} else {
+		off = coupon.Flat
+	}
+	if coupon.MaxDiscount > 0 {
+		off = money.Min(off, coupon.MaxDiscount)
+	}
+	return money.Allocate(money.Min(off, base), weights)
+}
+
+func (p *Pricing) reject(coupon entity.CartCoupon, reason string) {
+	p.Rejected = append(p.Rejected, Rejection{Code: coupon.Code, Reason: reason})
+	p.dropped = append(p.dropped, coupon)
+}
+
+// save stores the pricing on the cart: rejected coupons are removed and
+// the discounts of the rest, of the items and of the cart updated.
+func (e *Engine) save(cart *entity.Cart, items []entity.CartItem, pricing *Pricing) error {
+	for i := range pricing.dropped {
+		if pricing.dropped[i].ID == 0 {
+			continue
+		}
+		if err := e.cartRepo.RemoveCartCoupon(&pricing.dropped[i]); err != nil {
+			return errors.New("removing coupon failed")
+		}
+	}
+	for i := range pricing.Applied {
+		save := e.cartRepo.UpdateCartCoupon
+		if pricing.Applied[i].ID == 0 {
+			save = e.cartRepo.CreateCartCoupon
+		}
+		if err := save(&pricing.Applied[i]); err != nil {
+			return errors.New("saving coupon failed")
+		}
+	}
+	for i := range items {
+		if items[i].Discount == pricing.lines[i] {
+			continue
+		}
+		items[i].Discount = pricing.lines[i]
+		if err := e.cartRepo.UpdateCartItem(&items[i]); err != nil {
+			return errors.New("error updating cart item")
+		}
+	}
+	if cart.OfferPrize != pricing.Discount {
+		cart.OfferPrize = pricing.Discount
+		if err := e.cartRepo.UpdateCart(cart); err != nil {
+			return errors.New("user cart update failed")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+// Package offer prices products from their scheduled offers. Prices are
+// worked out from the offers running when they are asked for; the
+// scheduler moves offers through their statuses and keeps the stored
+// Product.OfferPrize in step for listings and filters.
+package offer
+
+import (
+	"log"
+	"project/domain/entity"
+	"project/domain/money"
+	productrepository "project/repository/product"
+	"time"
+)
+
+// Live is the set of offers running at some moment.
+type Live []entity.Offer
+
+// Covers reports whether the offer runs on the product.
+func Covers(offer *entity.Offer, productid, category int) bool {
+	if offer.ProductId != 0 {
+		return offer.ProductId == productid
+	}
+	return offer.Category == category
+}
+
+// Cut is what the offer takes off price. Offers that would give the
+// product away take nothing off.
+func Cut(offer *entity.Offer, price money.Money) money.Money {
+	var cut money.Money
+	if offer.Type == entity.OfferPercentage {
+		cut = price.Percent(offer.Percent)
+	} else {
+		cut = offer.Flat
+	}
+	if cut <= 0 || cut >= price {
+		return 0
+	}
+	return cut
+}
+
+// Price is the lowest price the offers give a product listed at price, or
+// 0 when none of them runs on it.
+func (l Live) Price(productid, category int, price money.Money) money.Money {
+	var best money.Money
+	for i := range l {
+		if !Covers(&l[i], productid, category) {
+			continue
+		}
+		if cut := Cut(&l[i], price); cut > best {
+			best = cut
+		}
+	}
+	if best == 0 {
+		return 0
+	}
+	return price - best
+}
+
+type Resolver struct {
+	productRepo *productrepository.ProductRepository
+}
+
+func NewResolver(productRepo *productrepository.ProductRepository) *Resolver {
+	return &Resolver{productRepo: productRepo}
+}
+
+// Live returns the offers running at now.
+func (r *Resolver) Live(now time.Time) (Live, error) {
+	offers, err := r.productRepo.GetLiveOffers(now)
+	if err != nil {
+		return nil, err
+	}
+	return Live(offers), nil
+}
+
+// Products sets the OfferPrize of the products from the offers running now.
+func (r *Resolver) Products(products []entity.Product) error {
+	live, err := r.Live(time.Now())
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		products[i].OfferPrize = live.Price(products[i].ID, products[i].Category, products[i].Price)
+	}
+	return nil
+}
+
+// Product sets the product's OfferPrize from the offers running now.
+func (r *Resolver) Product(product *entity.Product) error {
+	products := []entity.Product{*product}
+	if err := r.Products(products); err != nil {
+		return err
+	}
+	product.OfferPrize = products[0].OfferPrize
+	return nil
+}
+
+// Reprice stores the current offer price of the products the offer runs on.
+func (r *Resolver) Reprice(offer *entity.Offer) error {
+	var products []entity.Product
+	if offer.ProductId != 0 {
+		product, err := r.productRepo.GetProductById(offer.ProductId)
+		if err != nil {
+			return err
+		}
+		products = append(products, *product)
+	} else {
+		var err error
+		if products, err = r.productRepo.GetProductsByCategoryoffer(offer.Category); err != nil {
+			return err
+		}
+	}
+	return r.RepriceProducts(products)
+}
+
+// RepriceProducts stores the current offer price of the products.
+func (r *Resolver) RepriceProducts(products []entity.Product) error {
+	stored := make([]money.Money, len(products))
+	for i := range products {
+		stored[i] = products[i].OfferPrize
+	}
+	if err := r.Products(products); err != nil {
+		return err
+	}
+	for i := range products {
+		if products[i].OfferPrize == stored[i] {
+			continue
+		}
+		if err := r.productRepo.UpdateOfferPrize(products[i].ID, products[i].OfferPrize); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Status is where the offer stands at now.
+func Status(offer *entity.Offer, now time.Time) string {
+	switch {
+	case offer.Status == entity.OfferCancelled:
+		return entity.OfferCancelled
+	case offer.ValidUntil != nil && !now.Before(*offer.ValidUntil):
+		return entity.OfferExpired
+	case now.Before(offer.ValidFrom):
+		return entity.OfferScheduled
+	}
+	return entity.OfferActive
+}
+
+// Sync starts the offers that are due to start and ends the ones due to
+// end, repricing their products.
+func (r *Resolver) Sync(now time.Time) error {
+	offers, err := r.productRepo.GetDueOffers(now)
+	if err != nil {
+		return err
+	}
+	for i := range offers {
+		offers[i].Status = Status(&offers[i], now)
+		if err := r.productRepo.UpdateOffer(&offers[i]); err != nil {
+			return err
+		}
+		if err := r.Reprice(&offers[i]); err != nil {
+			log.Printf("repricing products of offer %d failed: %v", offers[i].Id, err)
+		}
+	}
+	return nil
+}
+
+// StartScheduler syncs the offers now and then every interval. It blocks,
+// so run it in its own goroutine.
+func (r *Resolver) StartScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := r.Sync(time.Now()); err != nil {
+			log.Printf("offer scheduler: %v", err)
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,291 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"project/domain/entity"
+	cartrepository "project/repository/cart"
+	repository "project/repository/order"
+	productrepository "project/repository/product"
+	userrepository "project/repository/user"
+	walletrepository "project/repository/wallet"
+	"project/usecase/tax"
+
+	"gorm.io/gorm"
+)
+
+// PaymentStrategy plugs a payment method into the checkout pipeline.
+// Prepare runs before the order row is written and may set payment fields
+// or reject the order, Complete runs once the order items and stock have
+// been written. Both run inside the checkout transaction, so returning an
+// error rolls back the whole order.
+type PaymentStrategy interface {
+	Method() string
+	Prepare(c *Checkout) error
+	Complete(c *Checkout) error
+	// Invoice reports whether the invoice is issued when the order is placed.
+	Invoice() bool
+	// ClearCart reports whether the cart is emptied when the order is placed.
+	ClearCart() bool
+	// Stock reports how the checkout treats the inventory of the ordered items.
+	Stock() stockAction
+}
+
+// Checkout carries the state of a single order placement. The repositories
+// are bound to the checkout transaction.
+type Checkout struct {
+	orderRepo   *repository.OrderRepository
+	cartRepo    *cartrepository.CartRepository
+	userRepo    *userrepository.UserRepository
+	productRepo *productrepository.ProductRepository
+	walletRepo  *walletrepository.WalletRepository
+
+	UserId    int
+	Cart      *entity.Cart
+	CartItems []entity.CartItem
+	Address   *entity.UserAddress
+	ShipTo    *entity.OrderAddress
+	Order     *entity.Order
+	Invoice   *entity.Invoice
+	// Bill is the cart priced for the delivery address, one line per
+	// cart item.
+	Bill *tax.Bill
+}
+
+// placeOrder runs cart -> order -> order items -> stock -> invoice -> cart
+// clear in one transaction using the given payment strategy.
+func (co *OrderUseCase) placeOrder(userid, addressid int, strategy PaymentStrategy) (*Checkout, error) {
+	var result *Checkout
+	err := co.orderRepo.Transaction(func(tx *gorm.DB) error {
+		c := &Checkout{
+			orderRepo:   co.orderRepo.WithTx(tx),
+			cartRepo:    co.cartRepo.WithTx(tx),
+			userRepo:    co.userRepo.WithTx(tx),
+			productRepo: co.productRepo.WithTx(tx),
+			walletRepo:  co.walletRepo.WithTx(tx),
+			UserId:      userid,
+		}
+		if err := c.load(addressid); err != nil {
+			return err
+		}
+		coupons := co.coupons.WithTx(tx)
+		pricing, err := coupons.Refresh(userid, c.Cart, c.CartItems)
+		if err != nil {
+			return err
+		}
+		if len(pricing.Rejected) > 0 {
+			r := pricing.Rejected[0]
+			return fmt.Errorf("coupon %s can no longer be used: %s", r.Code, r.Reason)
+		}
+		bill, err := co.taxes.Cart(c.CartItems, c.Address.State)
+		if err != nil {
+			return err
+		}
+		c.Bill = bill
+		c.Order = &entity.Order{
+			UserId:        c.Cart.UserId,
+			Addressid:     c.Address.Id,
+			Total:         bill.Total,
+			Discount:      c.Cart.OfferPrize,
+			Taxable:       bill.Taxable,
+			CGST:          bill.CGST,
+			SGST:          bill.SGST,
+			IGST:          bill.IGST,
+			Status:        entity.OrderPending,
+			PaymentMethod: strategy.Method(),
+			PaymentStatus: entity.PaymentPending,
+		}
+		if err := strategy.Prepare(c); err != nil {
+			return err
+		}
+		if _, err := c.orderRepo.Create(c.Order); err != nil {
+			// The gateway order already has an order.
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return err
+			}
+			return errors.New("order placing failed")
+		}
+		if err := recordStatus(c.orderRepo, c.Order.ID, "", c.Order.Status, ActorUser, userid, "order placed"); err != nil {
+			return err
+		}
+		c.ShipTo = snapshotAddress(c.Order.ID, c.Address)
+		if err := c.orderRepo.CreateOrderAddress(c.ShipTo); err != nil {
+			return errors.New("failed to save order address")
+		}
+		if err := c.createItems(strategy.Stock()); err != nil {
+			return err
+		}
+		// A failed stripe payment records the order without using up its
+		// coupons.
+		if c.Order.PaymentStatus != entity.PaymentFailed {
+			if err := coupons.Redeem(userid, c.Order.ID, pricing); err != nil {
+				return err
+			}
+		}
+		if err := strategy.Complete(c); err != nil {
+			return err
+		}
+		if strategy.Invoice() {
+			invoice, err := c.orderRepo.CreateInvoice(newInvoice(c.Order, c.ShipTo, c.Cart.ProductQuantity))
+			if err != nil {
+				return errors.New("error creating invoice")
+			}
+			c.Invoice = invoice
+		}
+		if invoiceable(c.Order) {
+			if _, err := co.issueTaxInvoice(c.orderRepo, c.userRepo, c.Order); err != nil {
+				return err
+			}
+		}
+		if strategy.ClearCart() {
+			if err := clearCart(c.cartRepo, c.Cart); err != nil {
+				return err
+			}
+		}
+		result = c
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Checkout) load(addressid int) error {
+	cart, err := c.cartRepo.GetByUserid(c.UserId)
+	if err != nil {
+		return errors.New("cart not found")
+	}
+	cartitems, err := c.cartRepo.GetAllCartItems(int(cart.ID))
+	if err != nil {
+		return errors.New("cart items not found")
+	}
+	if len(cartitems) == 0 {
+		return errors.New("cart is empty")
+	}
+	address, err := c.userRepo.GetUserAddress(c.UserId, addressid)
+	if err != nil {
+		return errors.New("address not found")
+	}
+	c.Cart = cart
+	c.CartItems = cartitems
+	c.Address = address
+	return nil
+}
+
+func (c *Checkout) createItems(stock stockAction) error {
+	var orderitems []entity.OrderItem
+	for i, cartitem := range c.CartItems {
+		line := c.Bill.Lines[i]
+		product, err := c.productRepo.GetProductById(cartitem.ProductId)
+		if err != nil {
+			return errors.New("product not found")
+		}
+		orderitems = append(orderitems, entity.OrderItem{
+			OrderId:       c.Order.ID,
+			ProductId:     cartitem.ProductId,
+			ProductName:   product.Name,
+			Category:      cartitem.Category,
+			Quantity:      cartitem.Quantity,
+			Prize:         cartitem.Price,
+			UnitPrice:     product.Price,
+			OfferPrice:    product.OfferPrize,
+			DiscountShare: line.Discount,
+			HSNCode:       line.HSNCode,
+			GSTRate:       line.Rate,
+			Taxable:       line.Taxable,
+			CGST:          line.CGST,
+			SGST:          line.SGST,
+			IGST:          line.IGST,
+		})
+		if stock != stockDecrement {
+			continue
+		}
+		inventory := entity.Inventory{
+			ProductId:       cartitem.ProductId,
+			ProductCategory: cartitem.Category,
+			Quantity:        cartitem.Quantity,
+		}
+		if err := c.productRepo.DecreaseProductQuantity(&inventory); err != nil {
+			return err
+		}
+	}
+	if err := c.orderRepo.CreateOrderItems(orderitems); err != nil {
+		return errors.New("failed to create order items")
+	}
+	if stock == stockReserve {
+		return reserveStock(c.productRepo, c.Order.ID, c.CartItems)
+	}
+	return nil
+}
+
+// itemName is the product name the item was sold under. Items from before
+// names were copied onto them fall back to the product.
+func itemName(productRepo *productrepository.ProductRepository, item *entity.OrderItem) string {
+	if item.ProductName != "" {
+		return item.ProductName
+	}
+	if product, err := productRepo.GetProductById(item.ProductId); err == nil {
+		return product.Name
+	}
+	return ""
+}
+
+func snapshotAddress(orderid int, address *entity.UserAddress) *entity.OrderAddress {
+	return &entity.OrderAddress{
+		OrderId:       orderid,
+		UserAddressId: address.Id,
+		Address:       address.Address,
+		State:         address.State,
+		Country:       address.Country,
+		Pin:           address.Pin,
+		Type:          address.Type,
+	}
+}
+
+// shippingAddress is the address an order was placed with. Orders from
+// before addresses were copied onto them fall back to the address book.
+func shippingAddress(orderRepo *repository.OrderRepository, userRepo *userrepository.UserRepository, order *entity.Order) (*entity.OrderAddress, error) {
+	if address, err := orderRepo.GetOrderAddress(order.ID); err == nil {
+		return address, nil
+	}
+	address, err := userRepo.GetAddressById(order.Addressid)
+	if err != nil {
+		return nil, errors.New("useraddress not found")
+	}
+	return snapshotAddress(order.ID, address), nil
+}
+
+func newInvoice(order *entity.Order, address *entity.OrderAddress, quantity int) *entity.Invoice {
+	paymentId := order.PaymentId
+	if paymentId == "" {
+		paymentId = "nil"
+	}
+	return &entity.Invoice{
+		OrderId:     order.ID,
+		UserId:      order.UserId,
+		AddressType: address.Type,
+		Quantity:    quantity,
+		Price:       order.Total,
+		Payment:     order.PaymentMethod,
+		Status:      order.PaymentStatus,
+		PaymentId:   paymentId,
+		Remark:      "nil",
+	}
+}
+
+func clearCart(cartRepo *cartrepository.CartRepository, cart *entity.Cart) error {
+	if err := cartRepo.RemoveCartItems(int(cart.ID)); err != nil {
+		return errors.New("removing cart failed")
+	}
+	if err := cartRepo.RemoveCartCoupons(int(cart.ID)); err != nil {
+		return errors.New("removing cart coupons failed")
+	}
+	cart.ProductQuantity = 0
+	cart.TotalPrize = 0
+	cart.OfferPrize = 0
+	if err := cartRepo.UpdateCart(cart); err != nil {
+		return errors.New("error upadting cart")
+	}
+	return nil
+}
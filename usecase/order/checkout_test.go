@@ -0,0 +1,46 @@
+package order
+
+import (
+	"project/domain/money"
+	"sync"
+	"testing"
+)
+
+// TestParallelOrdersForLastUnit has shoppers check out the last unit at
+// once. Exactly one order may be placed and the stock must not go negative.
+func TestParallelOrdersForLastUnit(t *testing.T) {
+	s := newShop(t)
+	product := s.product(t, money.FromMajor(50000), 1)
+	type buyer struct{ user, address int }
+	var buyers []buyer
+	for i := 0; i < 10; i++ {
+		user, address := s.shopper(t, product, 1, 0)
+		buyers = append(buyers, buyer{user, address})
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	placed := 0
+	start := make(chan struct{})
+	for _, b := range buyers {
+		wg.Add(1)
+		go func(b buyer) {
+			defer wg.Done()
+			<-start
+			if _, err := s.orders.ExecuteOrderCod(b.user, b.address); err == nil {
+				mu.Lock()
+				placed++
+				mu.Unlock()
+			}
+		}(b)
+	}
+	close(start)
+	wg.Wait()
+
+	if placed != 1 {
+		t.Fatalf("%d orders were placed for the last unit, want 1", placed)
+	}
+	if inventory := s.inventory(t, product.ID); inventory.Quantity != 0 || inventory.Reserved != 0 {
+		t.Errorf("inventory after the sale: quantity %d, reserved %d, want 0 and 0", inventory.Quantity, inventory.Reserved)
+	}
+}
@@ -0,0 +1,113 @@
+package order
+
+import (
+	"fmt"
+	"project/config"
+	"project/domain/entity"
+	"project/domain/money"
+	"project/domain/payment"
+	cartrepository "project/repository/cart"
+	"project/repository/infrastructure/dbtest"
+	repository "project/repository/order"
+	productrepository "project/repository/product"
+	userrepository "project/repository/user"
+	walletrepository "project/repository/wallet"
+	"sync/atomic"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// shop is an order use case over a test database, paying through fake
+// gateways.
+type shop struct {
+	db       *gorm.DB
+	orders   *OrderUseCase
+	razorpay *payment.FakeGateway
+	stripe   *payment.FakeGateway
+}
+
+var shoppers int64
+
+func newShop(t *testing.T) *shop {
+	db := dbtest.Open(t)
+	s := &shop{
+		db:       db,
+		razorpay: payment.NewFakeGateway(payment.ProviderRazorpay),
+		stripe:   payment.NewFakeGateway(payment.ProviderStripe),
+	}
+	s.orders = NewOrder(
+		repository.NewOrderRepository(db),
+		cartrepository.NewCartRepository(db),
+		userrepository.NewUserRepository(db),
+		productrepository.NewProductRepository(db),
+		walletrepository.NewWalletRepository(db),
+		s.razorpay, s.stripe,
+		&config.Seller{SellerName: "Test Shop", SellerState: "Kerala", GSTRate: 18},
+	)
+	return s
+}
+
+func (s *shop) create(t *testing.T, value interface{}) {
+	t.Helper()
+	if err := s.db.Create(value).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// product puts a product with the given stock on sale.
+func (s *shop) product(t *testing.T, price money.Money, stock int) *entity.Product {
+	t.Helper()
+	category := &entity.Category{Name: "Laptops", Description: "laptops", HSNCode: "8471", GSTRate: 18}
+	s.create(t, category)
+	product := &entity.Product{Name: "Laptop", Price: price, Size: "15", Category: category.ID}
+	s.create(t, product)
+	s.create(t, &entity.Inventory{ProductId: product.ID, ProductCategory: category.ID, Quantity: stock})
+	return product
+}
+
+// shopper is a new user with quantity of the product in their cart and a
+// delivery address. It returns the user and address ids.
+func (s *shop) shopper(t *testing.T, product *entity.Product, quantity int, wallet money.Money) (int, int) {
+	t.Helper()
+	n := atomic.AddInt64(&shoppers, 1)
+	user := &entity.User{
+		Name:     "Shopper",
+		Email:    fmt.Sprintf("shopper%d@example.com", n),
+		Phone:    fmt.Sprintf("90000%05d", n),
+		Password: "password",
+		Wallet:   wallet,
+	}
+	s.create(t, user)
+	address := &entity.UserAddress{User_id: user.Id, Address: "1 Main Road", State: "Kerala", Country: "India", Pin: "682001", Type: "home", IsDefault: true}
+	s.create(t, address)
+	cart := &entity.Cart{UserId: user.Id, ProductQuantity: quantity, TotalPrize: product.Price.Mul(quantity)}
+	s.create(t, cart)
+	s.create(t, &entity.CartItem{
+		CartId:      int(cart.ID),
+		Category:    product.Category,
+		ProductId:   product.ID,
+		ProductName: product.Name,
+		Quantity:    quantity,
+		Price:       product.Price,
+	})
+	return user.Id, address.Id
+}
+
+func (s *shop) order(t *testing.T, id int) *entity.Order {
+	t.Helper()
+	order, err := s.orders.orderRepo.GetOrderById(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
+
+func (s *shop) inventory(t *testing.T, productid int) *entity.Inventory {
+	t.Helper()
+	inventory, err := s.orders.productRepo.GetInventoryByID(productid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inventory
+}
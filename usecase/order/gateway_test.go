@@ -0,0 +1,279 @@
+package order
+
+import (
+	"net/http"
+	"project/domain/entity"
+	"project/domain/money"
+	"project/domain/payment"
+	"sync"
+	"testing"
+)
+
+// webhooks queues what the fake razorpay gateway sends, so the test decides
+// when they arrive, as they would from the real provider.
+type webhooks struct {
+	mu      sync.Mutex
+	pending [][]byte
+	headers []http.Header
+}
+
+func (w *webhooks) receive(payload []byte, header http.Header) {
+	w.mu.Lock()
+	w.pending = append(w.pending, payload)
+	w.headers = append(w.headers, header)
+	w.mu.Unlock()
+}
+
+// deliver hands every queued webhook to the shop, including the ones sent
+// while handling them.
+func (w *webhooks) deliver(t *testing.T, s *shop) {
+	t.Helper()
+	for {
+		w.mu.Lock()
+		if len(w.pending) == 0 {
+			w.mu.Unlock()
+			return
+		}
+		payload, header := w.pending[0], w.headers[0]
+		w.pending, w.headers = w.pending[1:], w.headers[1:]
+		w.mu.Unlock()
+
+		event, err := s.orders.ParseWebhook(payment.ProviderRazorpay, payload, header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.orders.ExecuteWebhook(payment.ProviderRazorpay, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func (s *shop) wallet(t *testing.T, userid int) money.Money {
+	t.Helper()
+	user, err := s.orders.userRepo.GetById(userid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user.Wallet - user.WalletHeld
+}
+
+// TestRazorpayCheckoutWebhookRefund pays a split wallet and razorpay order
+// through the fake gateway, confirms it from the webhook alone and cancels
+// it, refunding both shares.
+func TestRazorpayCheckoutWebhookRefund(t *testing.T) {
+	s := newShop(t)
+	hooks := &webhooks{}
+	s.razorpay.Deliver = hooks.receive
+	product := s.product(t, money.FromMajor(2000), 3)
+	user, address := s.shopper(t, product, 1, money.FromMajor(500))
+
+	razorId, orderid, err := s.orders.ExecuteRazorPay(user, address, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks.deliver(t, s)
+	order := s.order(t, orderid)
+	if order.GatewayOrderId != razorId || order.WalletAmount != money.FromMajor(500) {
+		t.Fatalf("order has razorpay order %q and wallet share %s, want %q and 500", order.GatewayOrderId, order.WalletAmount.Format(), razorId)
+	}
+	if inventory := s.inventory(t, product.ID); inventory.Reserved != 1 {
+		t.Fatalf("%d reserved before payment, want 1", inventory.Reserved)
+	}
+
+	if _, _, err := s.razorpay.Pay(razorId); err != nil {
+		t.Fatal(err)
+	}
+	hooks.deliver(t, s)
+	order = s.order(t, orderid)
+	if order.PaymentStatus != entity.PaymentSucceeded || order.PaymentId == "" {
+		t.Fatalf("order payment is %s (%q) after the webhook, want paid", order.PaymentStatus, order.PaymentId)
+	}
+	if inventory := s.inventory(t, product.ID); inventory.Quantity != 2 || inventory.Reserved != 0 {
+		t.Errorf("stock is %d with %d reserved after payment, want 2 and 0", inventory.Quantity, inventory.Reserved)
+	}
+	if got := s.wallet(t, user); got != 0 {
+		t.Errorf("wallet is %s after payment, want 0", got.Format())
+	}
+	if _, err := s.orders.orderRepo.GetTaxInvoiceByOrderId(orderid); err != nil {
+		t.Errorf("no tax invoice for the paid order: %v", err)
+	}
+
+	refunds, err := s.orders.ExecuteCancelOrder(user, orderid, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks.deliver(t, s)
+	var total money.Money
+	for _, refund := range refunds {
+		stored, err := s.orders.orderRepo.GetRefundById(refund.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status != entity.RefundProcessed {
+			t.Errorf("%s refund of %s is %s, want processed", stored.Method, stored.Amount.Format(), stored.Status)
+		}
+		total += stored.Amount
+	}
+	if len(refunds) != 2 || total != order.Total {
+		t.Errorf("%d refunds of %s in all, want 2 of %s", len(refunds), total.Format(), order.Total.Format())
+	}
+	if got := s.wallet(t, user); got != money.FromMajor(500) {
+		t.Errorf("wallet is %s after the cancel, want 500", got.Format())
+	}
+}
+
+// TestRazorpayPaymentAfterExpiry pays an order whose reservation has already
+// lapsed. The webhook refunds the payment instead of confirming the order.
+func TestRazorpayPaymentAfterExpiry(t *testing.T) {
+	s := newShop(t)
+	hooks := &webhooks{}
+	s.razorpay.Deliver = hooks.receive
+	product := s.product(t, money.FromMajor(2000), 3)
+	user, address := s.shopper(t, product, 1, 0)
+
+	razorId, orderid, err := s.orders.ExecuteRazorPay(user, address, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.orders.expireOrder(orderid, "payment not received in time"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.razorpay.Pay(razorId); err != nil {
+		t.Fatal(err)
+	}
+	hooks.deliver(t, s)
+
+	order := s.order(t, orderid)
+	if order.Status != entity.OrderPaymentExpired || order.PaymentStatus != entity.PaymentRefunded {
+		t.Fatalf("late payment left the order %s with payment %s, want it refunded", order.Status, order.PaymentStatus)
+	}
+	refunds, err := s.orders.ExecuteUserRefunds(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refunds) != 1 || refunds[0].Status != entity.RefundProcessed || refunds[0].Amount != order.Total {
+		t.Errorf("refunds are %+v, want one processed refund of %s", refunds, order.Total.Format())
+	}
+	if inventory := s.inventory(t, product.ID); inventory.Quantity != 3 || inventory.Reserved != 0 {
+		t.Errorf("stock is %d with %d reserved, want 3 and 0", inventory.Quantity, inventory.Reserved)
+	}
+}
+
+// TestConfirmWaitsForPayment keeps an admin from confirming a razorpay order
+// until its payment has come in.
+func TestConfirmWaitsForPayment(t *testing.T) {
+	s := newShop(t)
+	hooks := &webhooks{}
+	s.razorpay.Deliver = hooks.receive
+	product := s.product(t, money.FromMajor(2000), 3)
+	user, address := s.shopper(t, product, 1, 0)
+
+	razorId, orderid, err := s.orders.ExecuteRazorPay(user, address, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.orders.ExecuteOrderUpdate(orderid, entity.OrderConfirmed, 1, ""); err == nil {
+		t.Fatal("unpaid razorpay order was confirmed")
+	}
+	if order := s.order(t, orderid); order.Status != entity.OrderPending {
+		t.Fatalf("unpaid order is %s, want pending", order.Status)
+	}
+
+	if _, _, err := s.razorpay.Pay(razorId); err != nil {
+		t.Fatal(err)
+	}
+	hooks.deliver(t, s)
+	if err := s.orders.ExecuteOrderUpdate(orderid, entity.OrderConfirmed, 1, ""); err != nil {
+		t.Fatalf("paid order could not be confirmed: %v", err)
+	}
+	if order := s.order(t, orderid); order.Status != entity.OrderConfirmed {
+		t.Errorf("paid order is %s, want confirmed", order.Status)
+	}
+}
+
+// TestFailedPaymentIsNotPlaced keeps online orders that were never paid out
+// of the count first-order coupons go by.
+func TestFailedPaymentIsNotPlaced(t *testing.T) {
+	s := newShop(t)
+	hooks := &webhooks{}
+	s.razorpay.Deliver = hooks.receive
+	product := s.product(t, money.FromMajor(2000), 3)
+	user, address := s.shopper(t, product, 1, 0)
+
+	razorId, orderid, err := s.orders.ExecuteRazorPay(user, address, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, err := s.orders.orderRepo.CountPlacedOrders(user); err != nil || count != 0 {
+		t.Fatalf("unpaid order counts as %d placed orders (%v), want 0", count, err)
+	}
+	if err := s.razorpay.Fail(razorId); err != nil {
+		t.Fatal(err)
+	}
+	hooks.deliver(t, s)
+	if order := s.order(t, orderid); order.PaymentStatus != entity.PaymentFailed {
+		t.Fatalf("payment is %s, want failed", order.PaymentStatus)
+	}
+	if count, err := s.orders.orderRepo.CountPlacedOrders(user); err != nil || count != 0 {
+		t.Fatalf("failed order counts as %d placed orders (%v), want 0", count, err)
+	}
+
+	if _, err := s.orders.ExecuteOrderCod(user, address); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := s.orders.orderRepo.CountPlacedOrders(user); err != nil || count != 1 {
+		t.Errorf("%d placed orders after a cash on delivery order (%v), want 1", count, err)
+	}
+}
+
+// TestParallelStripeDeliveries delivers the event that places a stripe
+// order several times at once. One order may be placed and no delivery
+// fails.
+func TestParallelStripeDeliveries(t *testing.T) {
+	s := newShop(t)
+	hooks := &webhooks{}
+	s.stripe.Deliver = hooks.receive
+	product := s.product(t, money.FromMajor(2000), 3)
+	user, address := s.shopper(t, product, 1, 0)
+
+	if _, err := s.orders.ExecuteStripeIntent(user, address, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(hooks.pending) != 1 {
+		t.Fatalf("%d webhooks sent for the intent, want 1", len(hooks.pending))
+	}
+	event, err := s.orders.ParseWebhook(payment.ProviderStripe, hooks.pending[0], hooks.headers[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	start := make(chan struct{})
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = s.orders.ExecuteWebhook(payment.ProviderStripe, event)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	var orders int64
+	if err := s.db.Model(&entity.Order{}).Where("gateway_order_id = ?", event.IntentId).Count(&orders).Error; err != nil {
+		t.Fatal(err)
+	}
+	if orders != 1 {
+		t.Fatalf("%d orders placed for one intent, want 1", orders)
+	}
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("delivery %d: %v", i, err)
+		}
+	}
+	if inventory := s.inventory(t, product.ID); inventory.Reserved != 1 {
+		t.Errorf("%d units reserved, want 1", inventory.Reserved)
+	}
+}
@@ -0,0 +1,78 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"project/domain/entity"
+	repository "project/repository/order"
+	"time"
+)
+
+const (
+	ActorUser   = "user"
+	ActorAdmin  = "admin"
+	ActorSystem = "system"
+)
+
+// orderTransitions lists the statuses an order may move to from each status.
+// Statuses without an entry are terminal.
+var orderTransitions = map[string][]string{
+	entity.OrderPending:        {entity.OrderConfirmed, entity.OrderCancelled, entity.OrderPaymentExpired},
+	entity.OrderConfirmed:      {entity.OrderPacked, entity.OrderCancelled},
+	entity.OrderPacked:         {entity.OrderShipped, entity.OrderCancelled},
+	entity.OrderShipped:        {entity.OrderOutForDelivery},
+	entity.OrderOutForDelivery: {entity.OrderDelivered},
+	entity.OrderDelivered:      {entity.OrderReturned},
+	entity.OrderReturned:       {entity.OrderRefunded},
+}
+
+// userCancellable are the statuses a customer may still cancel from.
+var userCancellable = []string{entity.OrderPending, entity.OrderConfirmed}
+
+func CanTransition(from, to string) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func isStatus(status string, statuses []string) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// transition moves the order to the given status and records it in the
+// status history. The caller owns the transaction the repository is bound to.
+func transition(orderRepo *repository.OrderRepository, order *entity.Order, to, actor string, actorId int, remark string) error {
+	if !CanTransition(order.Status, to) {
+		return fmt.Errorf("order cannot move from %s to %s", order.Status, to)
+	}
+	from := order.Status
+	order.Status = to
+	if err := orderRepo.Update(order); err != nil {
+		return errors.New("error updating  order status")
+	}
+	return recordStatus(orderRepo, order.ID, from, to, actor, actorId, remark)
+}
+
+func recordStatus(orderRepo *repository.OrderRepository, orderid int, from, to, actor string, actorId int, remark string) error {
+	history := &entity.OrderStatusHistory{
+		OrderId:     orderid,
+		FromStatus:  from,
+		ToStatus:    to,
+		ChangedBy:   actor,
+		ChangedById: actorId,
+		Remark:      remark,
+		ChangedAt:   time.Now(),
+	}
+	if err := orderRepo.CreateStatusHistory(history); err != nil {
+		return errors.New("error recording order status")
+	}
+	return nil
+}
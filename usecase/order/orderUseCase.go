@@ -2,19 +2,24 @@ package order
 
 import (
 	"errors"
-	"fmt"
+	"log"
 	"project/config"
 	"project/domain/entity"
+	"project/domain/money"
+	"project/domain/payment"
 	"project/domain/utils"
 	cartrepository "project/repository/cart"
 	repository "project/repository/order"
 	productrepository "project/repository/product"
 	userrepository "project/repository/user"
+	walletrepository "project/repository/wallet"
+	"project/usecase/coupon"
+	"project/usecase/repricing"
+	"project/usecase/tax"
 	"strconv"
 	"time"
 
-	"github.com/jung-kurt/gofpdf"
-	razorpay "github.com/razorpay/razorpay-go"
+	"gorm.io/gorm"
 )
 
 type OrderUseCase struct {
@@ -22,127 +27,105 @@ type OrderUseCase struct {
 	cartRepo    *cartrepository.CartRepository
 	userRepo    *userrepository.UserRepository
 	productRepo *productrepository.ProductRepository
-	razopay     *config.Razopay
+	walletRepo  *walletrepository.WalletRepository
+	razorpay    payment.PaymentGateway
+	stripe      payment.PaymentGateway
+	seller      *config.Seller
+	taxes       *tax.Calculator
+	coupons     *coupon.Engine
+	repricer    *repricing.Repricer
 }
 
-func NewOrder(orderRepo *repository.OrderRepository, cartRepo *cartrepository.CartRepository, userRepo *userrepository.UserRepository, productRepo *productrepository.ProductRepository, razopay *config.Razopay) *OrderUseCase {
-	return &OrderUseCase{orderRepo: orderRepo, cartRepo: cartRepo, userRepo: userRepo, productRepo: productRepo, razopay: razopay}
+func NewOrder(orderRepo *repository.OrderRepository, cartRepo *cartrepository.CartRepository, userRepo *userrepository.UserRepository, productRepo *productrepository.ProductRepository, walletRepo *walletrepository.WalletRepository, razorpay, stripe payment.PaymentGateway, seller *config.Seller) *OrderUseCase {
+	return &OrderUseCase{orderRepo: orderRepo, cartRepo: cartRepo, userRepo: userRepo, productRepo: productRepo, walletRepo: walletRepo, razorpay: razorpay, stripe: stripe, seller: seller, taxes: tax.NewCalculator(productRepo, seller), coupons: coupon.NewEngine(productRepo, cartRepo, orderRepo), repricer: repricing.NewRepricer(productRepo, cartRepo)}
 }
 
-func (or *OrderUseCase) ExecuteOrderCod(userid int, address int) (*entity.Invoice, error) {
-	var orderitems []entity.OrderItem
-	cart, err := or.cartRepo.GetByUserid(userid)
+// reviewCart reprices the user's cart before an order is placed from it and
+// refuses while lines wait to be acknowledged.
+func (co *OrderUseCase) reviewCart(userid int) error {
+	cart, err := co.cartRepo.GetByUserid(userid)
 	if err != nil {
-		return nil, errors.New("cart not found")
+		return errors.New("cart not found")
 	}
-
-	cartitems, err := or.cartRepo.GetAllCartItems(int(cart.ID))
+	items, err := co.cartRepo.GetAllCartItems(int(cart.ID))
 	if err != nil {
-		return nil, errors.New("cart items not found")
-	}
-
-	for _, cartitem := range cartitems {
-		fmt.Printf("ProductID: %d, Category: %d, Quantity: %d, Price: %d\n",
-			cartitem.ProductId, cartitem.Category, cartitem.Quantity, cartitem.Price)
+		return errors.New("cart items not found")
 	}
-
-	useraddress, err := or.userRepo.GetAddressByID(userid)
+	review, err := co.repricer.Reprice(cart, items)
 	if err != nil {
-		return nil, errors.New("address not found")
-	}
-	Total := cart.TotalPrize - int(cart.OfferPrize)
-	order := &entity.Order{
-		UserId:        cart.UserId,
-		Addressid:     useraddress.Id,
-		Total:         Total,
-		Status:        "pending",
-		PaymentMethod: "cod",
-		PaymentStatus: "pending",
-	}
-	orderID, err := or.orderRepo.Create(order)
-	if err != nil {
-		return nil, errors.New("order placing failed")
-	}
-	InvoiceData := &entity.Invoice{
-		OrderId:     orderID,
-		UserId:      userid,
-		AddressType: useraddress.Type,
-		Quantity:    cart.ProductQuantity,
-		Price:       float64(order.Total),
-		Payment:     order.PaymentMethod,
-		Status:      order.PaymentStatus,
-		PaymentId:   "nil",
-		Remark:      "nil",
-	}
-	invoice, err := or.orderRepo.CreateInvoice(InvoiceData)
-	if err != nil {
-		return nil, errors.New("error creating invoice")
-	}
-	for _, cartitem := range cartitems {
-		orderitem := entity.OrderItem{
-			OrderId:   orderID,
-			ProductId: cartitem.ProductId,
-			Category:  cartitem.Category,
-			Quantity:  cartitem.Quantity,
-			Prize:     cartitem.Price,
-		}
-		orderitems = append(orderitems, orderitem)
-		inventory := entity.Inventory{
-			ProductId:       cartitem.ProductId,
-			ProductCategory: cartitem.Category,
-			Quantity:        cartitem.Quantity,
-		}
-		// fmt.Println("prod:", cartitem.ProductId)
-		err := or.productRepo.DecreaseProductQuantity(&inventory)
-		if err != nil {
-			return nil, err
-		}
+		return err
 	}
-	err1 := or.orderRepo.CreateOrderItems(orderitems)
-	if err1 != nil {
-		return nil, errors.New("failed to create order items")
+	if review {
+		return repricing.ErrReview
 	}
-	err = or.cartRepo.RemoveCartItems(int(cart.ID))
-	if err != nil {
-		return nil, errors.New("removing cart failed")
+	return nil
+}
+
+func (or *OrderUseCase) ExecuteOrderCod(userid int, address int) (*entity.Invoice, error) {
+	if err := or.reviewCart(userid); err != nil {
+		return nil, err
 	}
-	cart.ProductQuantity = 0
-	cart.TotalPrize = 0
-	cart.OfferPrize = 0
-	cart.ProductQuantity = 0
-	err = or.cartRepo.UpdateCart(cart)
+	result, err := or.placeOrder(userid, address, codPayment{})
 	if err != nil {
-		return nil, errors.New("error upadting cart")
+		return nil, err
 	}
-	return invoice, nil
+	return result.Invoice, nil
 }
 
-func (co *OrderUseCase) ExecuteCancelOrder(orderid int) error {
+func (co *OrderUseCase) ExecuteCancelOrder(userid, orderid int, refund string) ([]entity.Refund, error) {
 	result, err := co.orderRepo.GetOrderById(orderid)
 	if err != nil {
-		return errors.New("erroro getting orderid")
+		return nil, errors.New("erroro getting orderid")
 	}
-	userid, err := co.userRepo.GetById(result.UserId)
-	if err != nil {
-		return errors.New("error getting user")
+	if result.UserId != userid {
+		return nil, errors.New("order not found")
 	}
-	if result.Status != "pending" && result.Status != "confirmed" {
-		return errors.New("order cancel time exceeded")
+	if !isStatus(result.Status, userCancellable) {
+		return nil, errors.New("order cancel time exceeded")
 	}
-	if result.PaymentStatus == "succesfull" {
-		result.PaymentStatus = "refund"
-		userid.Wallet = userid.Wallet + int(result.Total)
-		err := co.orderRepo.UpdateUserWallet(userid)
+	return co.cancelOrder(orderid, ActorUser, userid, "", refund)
+}
+
+// cancelOrder cancels the order and refunds prepaid orders, to the original
+// payment or the wallet depending on refund. Gateway refunds are sent once
+// the cancellation has been saved.
+func (co *OrderUseCase) cancelOrder(orderid int, actor string, actorId int, remark, refund string) ([]entity.Refund, error) {
+	var refunds []entity.Refund
+	err := co.orderRepo.Transaction(func(tx *gorm.DB) error {
+		orderRepo := co.orderRepo.WithTx(tx)
+		walletRepo := co.walletRepo.WithTx(tx)
+		// The lock keeps a second cancel from refunding the order again.
+		result, err := orderRepo.GetOrderByIdForUpdate(orderid)
 		if err != nil {
 			return err
 		}
+		if !CanTransition(result.Status, entity.OrderCancelled) {
+			return errors.New("order cancel time exceeded")
+		}
+		if err := releaseWallet(walletRepo, result); err != nil {
+			return err
+		}
+		if err := co.coupons.WithTx(tx).Release(orderid); err != nil {
+			return err
+		}
+		if isPaid(result) {
+			method, err := refundMethod(result, refund)
+			if err != nil {
+				return err
+			}
+			result.PaymentStatus = entity.PaymentRefunded
+			refunds, err = issueRefunds(orderRepo, walletRepo, result, 0, result.Total, method, entity.WalletReasonCancelRefund)
+			if err != nil {
+				return err
+			}
+		}
+		return transition(orderRepo, result, entity.OrderCancelled, actor, actorId, remark)
+	})
+	if err != nil {
+		return nil, err
 	}
-	result.Status = "cancelled"
-	err1 := co.orderRepo.Update(result)
-	if err1 != nil {
-		return errors.New("order cancellation failed")
-	}
-	return nil
+	co.processRefunds(refunds)
+	return refunds, nil
 }
 
 func (co *OrderUseCase) ExecuteOrderHistory(userid, page, limit int) ([]entity.Order, error) {
@@ -154,26 +137,39 @@ func (co *OrderUseCase) ExecuteOrderHistory(userid, page, limit int) ([]entity.O
 	return orderList, nil
 }
 
-func (co *OrderUseCase) ExecuteOrderUpdate(OrderId int, status string) error {
-	result, err := co.orderRepo.GetOrderById(OrderId)
+func (co *OrderUseCase) ExecuteOrderUpdate(OrderId int, status string, adminId int, remark string) error {
+	if status == entity.OrderCancelled {
+		_, err := co.cancelOrder(OrderId, ActorAdmin, adminId, remark, "")
+		return err
+	}
+	return co.orderRepo.Transaction(func(tx *gorm.DB) error {
+		orderRepo := co.orderRepo.WithTx(tx)
+		result, err := orderRepo.GetOrderByIdForUpdate(OrderId)
+		if err != nil {
+			return errors.New("error finding order")
+		}
+		// Online orders are confirmed once their payment is in, whatever
+		// the gateway's callbacks are doing at the time.
+		if status == entity.OrderConfirmed && paidThroughGateway(result) && !isPaid(result) {
+			return errors.New("order is still awaiting payment")
+		}
+		return transition(orderRepo, result, status, ActorAdmin, adminId, remark)
+	})
+}
+
+func (co *OrderUseCase) ExecuteOrderTimeline(userid, orderid int) ([]entity.OrderStatusHistory, error) {
+	order, err := co.orderRepo.GetOrderById(orderid)
 	if err != nil {
-		return errors.New("error finding order")
+		return nil, err
 	}
-	result.Status = status
-	switch status {
-	case "confirmed":
-		break
-	case "cancelled":
-		break
-	default:
-		return errors.New("status should be confirmed or cancelled")
-		
+	if order.UserId != userid {
+		return nil, errors.New("order not found")
 	}
-	err1 := co.orderRepo.Update(result)
-	if err1 != nil {
-		return errors.New("error updating  order status")
+	history, err := co.orderRepo.GetStatusHistory(orderid)
+	if err != nil {
+		return nil, errors.New("failed to get order timeline")
 	}
-	return nil
+	return history, nil
 }
 
 func (co *OrderUseCase) UpdatedUser(orderid int) (*entity.Order, error) {
@@ -194,103 +190,47 @@ func (co *OrderUseCase) ExecuteAdminOrder(page, limit int) ([]entity.Order, erro
 	return result, nil
 }
 
-func (co *OrderUseCase) ExecuteAdminCancelOrder(orderid int) error {
-	result, err := co.orderRepo.GetOrderById(orderid)
-	if err != nil {
-		return err
-	}
-
-	userid, err := co.userRepo.GetById(result.UserId)
-	if err != nil {
-		return errors.New("error getting user")
-	}
-	if result.Status != "pending" && result.Status != "confirmed" {
-		return errors.New("order cancel time exceeded")
-	}
-	if result.PaymentStatus == "succesfull" {
-		result.PaymentStatus = "refund"
-		userid.Wallet = userid.Wallet + int(result.Total)
-		err := co.orderRepo.UpdateUserWallet(userid)
-		if err != nil {
-			return err
-		}
-	}
-	result.Status = "cancelled"
-	err1 := co.orderRepo.Update(result)
-	if err1 != nil {
-		return errors.New("updation failed")
-	}
-	return nil
+func (co *OrderUseCase) ExecuteAdminCancelOrder(orderid, adminId int, refund string) ([]entity.Refund, error) {
+	return co.cancelOrder(orderid, ActorAdmin, adminId, "", refund)
 }
 
-func (rp *OrderUseCase) ExecuteRazorPay(userId, address int) (string, int, error) {
-	var orderItems []entity.OrderItem
-	cart, err := rp.cartRepo.GetByUserid(userId)
-	if err != nil {
-		return "", 0, errors.New("Cart not Found")
-	}
-	cartitems, err1 := rp.cartRepo.GetAllCartItems(int(cart.ID))
-	if err1 != nil {
-		return "", 0, errors.New("cartitems ")
-	}
-	usraddress, err2 := rp.userRepo.GetAddressByID(userId)
-	if err2 != nil {
-		return "", 0, errors.New("address not found")
+func (rp *OrderUseCase) ExecuteRazorPay(userId, address int, useWallet bool) (string, int, error) {
+	if err := rp.reviewCart(userId); err != nil {
+		return "", 0, err
 	}
-	
-	client := razorpay.NewClient(rp.razopay.RazopayKey, rp.razopay.RazopaySecret)
-
-	data := map[string]interface{}{
-		"amount":   int(cart.TotalPrize) - int(cart.OfferPrize),
-		"currency": "INR",
-		"receipt":  "101",
-	}
-
-	body, err := client.Order.Create(data, nil)
+	result, err := rp.placeOrder(userId, address, razorpayPayment{useWallet: useWallet})
 	if err != nil {
-		return "", 0, errors.New("Errro creating order")
+		return "", 0, err
 	}
-
-	razorId, _ := body["id"].(string)
-	Total := cart.TotalPrize
-	order := &entity.Order{
-		UserId:        cart.UserId,
-		Addressid:     usraddress.Id,
-		Total:         Total,
-		Status:        "pending",
-		PaymentMethod: "razorpay",
-		PaymentStatus: "pending",
-		PaymentId:     razorId,
-	}
-	orderId, err := rp.orderRepo.Create(order)
+	razorId, err := rp.startRazorpayPayment(result.Order)
 	if err != nil {
-		return "", 0, errors.New("Order placing failed")
+		return "", 0, err
 	}
-	for _, cartItem := range cartitems {
-		orderitem := entity.OrderItem{
-			OrderId:   orderId,
-			ProductId: cartItem.ProductId,
-			Category:  cartItem.Category,
-			Quantity:  cartItem.Quantity,
-			Prize:     cartItem.Price,
-		}
-		orderItems = append(orderItems, orderitem)
-		inventory := entity.Inventory{
-			ProductId:       cartItem.ProductId,
-			ProductCategory: cartItem.Category,
-			Quantity:        cartItem.Quantity,
-		}
+	return razorId, result.Order.ID, nil
+}
 
-		err := rp.productRepo.DecreaseProductQuantity(&inventory)
-		if err != nil {
-			return "", 0, err
+// startRazorpayPayment creates the razorpay order for a placed order. It
+// runs after the checkout has committed, so no rows stay locked during the
+// call and a rolled back checkout leaves no razorpay order behind. If the
+// razorpay order cannot be created the placed order is given up.
+func (rp *OrderUseCase) startRazorpayPayment(order *entity.Order) (string, error) {
+	intent, err := rp.razorpay.CreateIntent(gatewayAmount(order), money.Shop.Code, map[string]string{
+		"user_id":  strconv.Itoa(order.UserId),
+		"order_id": strconv.Itoa(order.ID),
+	})
+	if err == nil {
+		err = rp.orderRepo.SetGatewayOrderId(order.ID, intent.Id)
+	}
+	if err != nil {
+		log.Printf("starting razorpay payment of order %d failed: %v", order.ID, err)
+		if err := rp.expireOrder(order.ID, "payment could not be started"); err != nil {
+			log.Printf("releasing order %d failed: %v", order.ID, err)
 		}
+		return "", errors.New("Errro creating order")
 	}
-	err3 := rp.orderRepo.CreateOrderItems(orderItems)
-	if err3 != nil {
-		return "", 0, errors.New("Errro creating orderitems")
-	}
-	return razorId, orderId, nil
+	order.PaymentId = intent.Id
+	order.GatewayOrderId = intent.Id
+	return intent.Id, nil
 }
 
 func (rv *OrderUseCase) ExecuteRazorPayVerification(signature, razorid, PaymentId string) (*entity.Invoice, error) {
@@ -298,60 +238,82 @@ func (rv *OrderUseCase) ExecuteRazorPayVerification(signature, razorid, PaymentI
 	if err != nil {
 		return nil, errors.New("order not found")
 	}
-	err1 := utils.RazorPaymentVerification(signature, razorid, PaymentId)
+	err1 := rv.razorpay.VerifyPayment(razorid, PaymentId, signature)
 	if err1 != nil {
-		result.PaymentStatus = "failed"
-		result.PaymentId = PaymentId
-		err2 := rv.orderRepo.Update(result)
-		if err2 != nil {
-			return nil, errors.New("payment updation failed")
+		if !isPaid(result) && awaitingPayment(result) {
+			result.PaymentStatus = entity.PaymentFailed
+			result.PaymentId = PaymentId
+			err2 := rv.orderRepo.Update(result)
+			if err2 != nil {
+				return nil, errors.New("payment updation failed")
+			}
 		}
+		return nil, err1
 	}
-	result.PaymentStatus = "succesfull"
-	result.PaymentId = PaymentId
-	err3 := rv.orderRepo.Update(result)
-	if err3 != nil {
-		return nil, errors.New("payment updation failed")
-	}
-	userCart, err := rv.cartRepo.GetByUserid(result.UserId)
-	if err != nil {
-		return nil, errors.New("usercart not found")
-	}
-	useraddress, err := rv.userRepo.GetAddressById(result.Addressid)
-	if err != nil {
-		return nil, errors.New("useraddress not found")
-	}
-	Total := userCart.TotalPrize
-	InvoiceData := &entity.Invoice{
-		OrderId:     result.ID,
-		UserId:      result.UserId,
-		AddressType: useraddress.Type,
-		Quantity:    userCart.ProductQuantity,
-		Price:       float64(Total),
-		Payment:     "razorpay",
-		Status:      result.PaymentStatus,
-		PaymentId:   PaymentId,
-		Remark:      "",
-	}
+	return rv.confirmRazorpayPayment(result.ID, PaymentId)
+}
 
-	Invoice, err := rv.orderRepo.CreateInvoice(InvoiceData)
+// confirmRazorpayPayment marks the order paid, issues the invoice and clears
+// the cart. The browser callback and the webhook may both confirm the same
+// payment, so an order that is already paid just returns its invoice. A
+// payment for an order that was cancelled or expired meanwhile is refunded
+// and ErrLatePayment returned.
+func (rv *OrderUseCase) confirmRazorpayPayment(orderid int, PaymentId string) (*entity.Invoice, error) {
+	var Invoice *entity.Invoice
+	var refunds []entity.Refund
+	late := false
+	err := rv.orderRepo.Transaction(func(tx *gorm.DB) error {
+		orderRepo := rv.orderRepo.WithTx(tx)
+		cartRepo := rv.cartRepo.WithTx(tx)
+		result, err := orderRepo.GetOrderByIdForUpdate(orderid)
+		if err != nil {
+			return err
+		}
+		if isPaid(result) {
+			Invoice, err = orderRepo.GetInvoiceByOrderId(result.ID)
+			return err
+		}
+		if !awaitingPayment(result) {
+			late = true
+			refunds, err = refundLatePayment(orderRepo, rv.walletRepo.WithTx(tx), result, PaymentId)
+			return err
+		}
+		if err := commitReservations(rv.productRepo.WithTx(tx), result); err != nil {
+			return err
+		}
+		if err := commitWallet(rv.walletRepo.WithTx(tx), result); err != nil {
+			return err
+		}
+		result.PaymentStatus = entity.PaymentSucceeded
+		result.PaymentId = PaymentId
+		if err := orderRepo.Update(result); err != nil {
+			return errors.New("payment updation failed")
+		}
+		userCart, err := cartRepo.GetByUserid(result.UserId)
+		if err != nil {
+			return errors.New("usercart not found")
+		}
+		useraddress, err := shippingAddress(orderRepo, rv.userRepo.WithTx(tx), result)
+		if err != nil {
+			return err
+		}
+		Invoice, err = orderRepo.CreateInvoice(newInvoice(result, useraddress, userCart.ProductQuantity))
+		if err != nil {
+			return errors.New("Invoice creating failed")
+		}
+		if _, err := rv.issueTaxInvoice(orderRepo, rv.userRepo.WithTx(tx), result); err != nil {
+			return err
+		}
+		return clearCart(cartRepo, userCart)
+	})
 	if err != nil {
-		return nil, errors.New("Invoice creating failed")
-	}
-	err4 := rv.cartRepo.RemoveCartItems(int(userCart.ID))
-	if err4 != nil {
-		return nil, errors.New("removing cart items failed")
+		return nil, err
 	}
-	userCart.ProductQuantity = 0
-	userCart.TotalPrize = 0
-	userCart.OfferPrize = 0
-	userCart.ProductQuantity = 0
-	err = rv.cartRepo.UpdateCart(userCart)
-	if err != nil {
-		return nil, errors.New("error upadting cart")
+	if late {
+		rv.processRefunds(refunds)
+		return nil, ErrLatePayment
 	}
 	return Invoice, nil
-
 }
 func (sr *OrderUseCase) ExecuteSalesReportByPeriod(period string) (*entity.SalesReport, error) {
 	startdate, enddate := utils.CalcualtePeriodDate(period)
@@ -387,186 +349,163 @@ func (cu *OrderUseCase) ExecuteCartit(userid int) (*entity.Cart, error) {
 	}
 }
 
-func (or *OrderUseCase) ExecuteInvoiceStripe(userid int, address int) (*entity.Invoice, error) {
-	var orderitems []entity.OrderItem
+// ExecuteStripeAmount is what the stripe intent should charge for the
+// user's cart, and the wallet share left out of it when useWallet is set.
+func (or *OrderUseCase) ExecuteStripeAmount(userid int, useWallet bool) (money.Money, money.Money, error) {
 	cart, err := or.cartRepo.GetByUserid(userid)
 	if err != nil {
-		return nil, errors.New("cart not found")
+		return 0, 0, errors.New("failed to find user")
 	}
-
-	cartitems, err := or.cartRepo.GetAllCartItems(int(cart.ID))
+	items, err := or.cartRepo.GetAllCartItems(int(cart.ID))
 	if err != nil {
-		return nil, errors.New("cart items not found")
-	}
-
-	for _, cartitem := range cartitems {
-		fmt.Printf("ProductID: %d, Category: %d, Quantity: %d, Price: %d\n",
-			cartitem.ProductId, cartitem.Category, cartitem.Quantity, cartitem.Price)
+		return 0, 0, errors.New("cart items not found")
 	}
-
-	useraddress, err := or.userRepo.GetAddressByID(userid)
+	review, err := or.repricer.Reprice(cart, items)
 	if err != nil {
-		return nil, errors.New("address not found")
+		return 0, 0, err
 	}
-	Total := cart.TotalPrize - int(cart.OfferPrize)
-	order := &entity.Order{
-		UserId:        cart.UserId,
-		Addressid:     useraddress.Id,
-		Total:         Total,
-		Status:        "pending",
-		PaymentMethod: "Stripe",
-		PaymentStatus: "pending",
-	}
-	orderID, err := or.orderRepo.Create(order)
-	if err != nil {
-		return nil, errors.New("order placing failed")
+	if review {
+		return 0, 0, repricing.ErrReview
 	}
-	InvoiceData := &entity.Invoice{
-		OrderId:     orderID,
-		UserId:      userid,
-		AddressType: useraddress.Type,
-		Quantity:    cart.ProductQuantity,
-		Price:       float64(order.Total),
-		Payment:     order.PaymentMethod,
-		Status:      order.PaymentStatus,
-		PaymentId:   "nil",
-		Remark:      "nil",
+	if _, err := or.coupons.Refresh(userid, cart, items); err != nil {
+		return 0, 0, err
 	}
-	invoice, err := or.orderRepo.CreateInvoice(InvoiceData)
+	// The total does not depend on where the order ships, only its split
+	// into CGST, SGST and IGST does.
+	bill, err := or.taxes.Cart(items, "")
 	if err != nil {
-		return nil, errors.New("error creating invoice")
-	}
-
-	for _, cartitem := range cartitems {
-		orderitem := entity.OrderItem{
-			OrderId:   orderID,
-			ProductId: cartitem.ProductId,
-			Category:  cartitem.Category,
-			Quantity:  cartitem.Quantity,
-			Prize:     cartitem.Price,
-		}
-		orderitems = append(orderitems, orderitem)
-		inventory := entity.Inventory{
-			ProductId:       cartitem.ProductId,
-			ProductCategory: cartitem.Category,
-			Quantity:        cartitem.Quantity,
-		}
-		err := or.productRepo.DecreaseProductQuantity(&inventory)
-		if err != nil {
-			return nil, err
-		}
+		return 0, 0, err
 	}
-	err1 := or.orderRepo.CreateOrderItems(orderitems)
-	if err1 != nil {
-		return nil, errors.New("failed to create order items")
+	total := bill.Total
+	if !useWallet {
+		return total, 0, nil
 	}
-
-	err = or.cartRepo.RemoveCartItems(int(cart.ID))
-	if err != nil {
-		return nil, errors.New("removing cart failed")
+	user, err := or.userRepo.GetById(userid)
+	if err != nil || user == nil {
+		return 0, 0, errors.New("error getting user")
 	}
-	cart.ProductQuantity = 0
-	cart.TotalPrize = 0
-	cart.OfferPrize = 0
-	cart.ProductQuantity = 0
-	err = or.cartRepo.UpdateCart(cart)
+	walletAmount, err := walletShare(user, total)
 	if err != nil {
-		return nil, errors.New("error upadting cart")
-
+		return 0, 0, err
 	}
-	return invoice, nil
+	return total - walletAmount, walletAmount, nil
 }
 
-func (or *OrderUseCase) CreateInvoiceForFailedPayment(userid int, address int) (*entity.Invoice, error) {
-	var orderitems []entity.OrderItem
-	cart, err := or.cartRepo.GetByUserid(userid)
-	if err != nil {
-		return nil, errors.New("cart not found")
+// ExecuteStripeIntent creates the payment intent for the user's cart and
+// returns its client secret. The order itself is placed when the intent's
+// created event arrives.
+func (or *OrderUseCase) ExecuteStripeIntent(userid, address int, useWallet bool) (string, error) {
+	if _, err := or.userRepo.GetUserAddress(userid, address); err != nil {
+		return "", errors.New("address not found")
 	}
-
-	cartitems, err := or.cartRepo.GetAllCartItems(int(cart.ID))
+	amount, walletAmount, err := or.ExecuteStripeAmount(userid, useWallet)
 	if err != nil {
-		return nil, errors.New("cart items not found")
+		return "", err
 	}
-
-	for _, cartitem := range cartitems {
-		fmt.Printf("ProductID: %d, Category: %d, Quantity: %d, Price: %d\n",
-			cartitem.ProductId, cartitem.Category, cartitem.Quantity, cartitem.Price)
-	}
-
-	useraddress, err := or.userRepo.GetAddressById(userid)
+	intent, err := or.stripe.CreateIntent(amount, money.Shop.Code, map[string]string{
+		"user_id":      strconv.Itoa(userid),
+		"address_id":   strconv.Itoa(address),
+		"wallet_paise": strconv.FormatInt(walletAmount.Minor(), 10),
+	})
 	if err != nil {
-		return nil, errors.New("address not found")
+		return "", err
 	}
-	Total := cart.TotalPrize - int(cart.OfferPrize)
-	order := &entity.Order{
-		UserId:        cart.UserId,
-		Addressid:     useraddress.Id,
-		Total:         Total,
-		Status:        "pending",
-		PaymentMethod: "Stripe",
-		PaymentStatus: "Failed",
-	}
-	orderID, err := or.orderRepo.Create(order)
-	if err != nil {
-		return nil, errors.New("order placing failed")
-	}
-	InvoiceData := &entity.Invoice{
-		OrderId:     orderID,
-		UserId:      userid,
-		AddressType: useraddress.Type,
-		Quantity:    cart.ProductQuantity,
-		Price:       float64(order.Total),
-		Payment:     order.PaymentMethod,
-		Status:      order.PaymentStatus,
-		PaymentId:   "nil",
-		Remark:      "nil",
+	return intent.ClientSecret, nil
+}
+
+// ExecuteInvoiceStripe places the order for a new payment intent, saving
+// event with it when given. When the intent already has an order, from an
+// earlier or parallel delivery of the same event, its invoice is returned
+// instead.
+func (or *OrderUseCase) ExecuteInvoiceStripe(intentId string, userid, address int, walletAmount money.Money, event *entity.WebhookEvent) (*entity.Invoice, error) {
+	if order, err := or.orderRepo.GetByGatewayOrderId(intentId); err == nil {
+		return or.orderRepo.GetInvoiceByOrderId(order.ID)
+	}
+	result, err := or.placeOrder(userid, address, stripePayment{intentId: intentId, walletAmount: walletAmount, event: event})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		order, err := or.orderRepo.GetByGatewayOrderId(intentId)
+		if err != nil {
+			return nil, err
+		}
+		return or.orderRepo.GetInvoiceByOrderId(order.ID)
 	}
-	invoice, err := or.orderRepo.CreateInvoice(InvoiceData)
 	if err != nil {
-		return nil, errors.New("error creating invoice")
+		return nil, err
 	}
+	return result.Invoice, nil
+}
 
-	for _, cartitem := range cartitems {
-		orderitem := entity.OrderItem{
-			OrderId:   orderID,
-			ProductId: cartitem.ProductId,
-			Category:  cartitem.Category,
-			Quantity:  cartitem.Quantity,
-			Prize:     cartitem.Price,
-		}
-		orderitems = append(orderitems, orderitem)
-		inventory := entity.Inventory{
-			ProductId:       cartitem.ProductId,
-			ProductCategory: cartitem.Category,
-			Quantity:        cartitem.Quantity,
+// CreateInvoiceForFailedPayment marks the intent's order failed. An intent
+// that never got an order is recorded as a failed order, saving event with
+// it when given. The holds of an existing order stay, the customer may
+// retry the intent until they expire.
+func (or *OrderUseCase) CreateInvoiceForFailedPayment(intentId string, userid, address int, event *entity.WebhookEvent) (*entity.Invoice, error) {
+	order, err := or.orderRepo.GetByGatewayOrderId(intentId)
+	if err != nil {
+		result, err := or.placeOrder(userid, address, stripePayment{intentId: intentId, failed: true, event: event})
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			if err != nil {
+				return nil, err
+			}
+			return result.Invoice, nil
 		}
-
-		err := or.productRepo.DecreaseProductQuantity(&inventory)
+		// A parallel delivery placed the order first.
+		order, err = or.orderRepo.GetByGatewayOrderId(intentId)
 		if err != nil {
 			return nil, err
 		}
 	}
-	err1 := or.orderRepo.CreateOrderItems(orderitems)
-	if err1 != nil {
-		return nil, errors.New("failed to create order items")
+	if !isPaid(order) {
+		order.PaymentStatus = entity.PaymentFailed
+		if err := or.orderRepo.Update(order); err != nil {
+			return nil, errors.New("payment updation failed")
+		}
+	}
+	return or.orderRepo.GetInvoiceByOrderId(order.ID)
+}
+
+// ExecuteStripePaymentSucceeded marks the intent's order paid and takes its
+// held stock and wallet share. An order that is already paid is left alone,
+// one cancelled or expired meanwhile has the payment refunded.
+func (uc *OrderUseCase) ExecuteStripePaymentSucceeded(intentId string) error {
+	order, err := uc.orderRepo.GetByGatewayOrderId(intentId)
+	if err != nil {
+		return err
 	}
-	if order.PaymentStatus == "succesful" {
-		err = or.cartRepo.RemoveCartItems(int(cart.ID))
+	var refunds []entity.Refund
+	err = uc.orderRepo.Transaction(func(tx *gorm.DB) error {
+		orderRepo := uc.orderRepo.WithTx(tx)
+		order, err := orderRepo.GetOrderByIdForUpdate(order.ID)
 		if err != nil {
-			return nil, errors.New("removing cart failed")
+			return err
 		}
-		cart.ProductQuantity = 0
-		cart.TotalPrize = 0
-		cart.OfferPrize = 0
-		cart.ProductQuantity = 0
-		err = or.cartRepo.UpdateCart(cart)
-		if err != nil {
-			return nil, errors.New("error upadting cart")
+		if isPaid(order) {
+			return nil
+		}
+		if !awaitingPayment(order) {
+			refunds, err = refundLatePayment(orderRepo, uc.walletRepo.WithTx(tx), order, "")
+			return err
+		}
+		if err := commitReservations(uc.productRepo.WithTx(tx), order); err != nil {
+			return err
+		}
+		if err := commitWallet(uc.walletRepo.WithTx(tx), order); err != nil {
+			return err
+		}
+		order.PaymentStatus = entity.PaymentSucceeded
+		if err := orderRepo.Update(order); err != nil {
+			return err
 		}
+		_, err = uc.issueTaxInvoice(orderRepo, uc.userRepo.WithTx(tx), order)
+		return err
+	})
+	if err != nil {
+		return err
 	}
-	return invoice, nil
+	uc.processRefunds(refunds)
+	return nil
 }
+
 func (uc *OrderUseCase) UpdateInvoiceStatus(orderID int, status string) error {
 
 	invoice, err := uc.orderRepo.GetOrderById(orderID)
@@ -591,155 +530,13 @@ func (co *OrderUseCase) ExecuteOrderid(OrderId int) (*entity.Order, error) {
 
 }
 
-func (co *OrderUseCase) ExecutPrintInvoice(orderId, userid int) (*gofpdf.Fpdf, error) {
-	// order, err := co.orderRepo.DetailedOrderDetails(orderId)
-	// if err != nil {
-	// 	return nil, err
-	// }
-	orde, err := co.orderRepo.GetOrderById(orderId)
-	if err != nil {
-		return nil, err
-	}
-
-	usr, err := co.userRepo.GetById(userid)
-	if err != nil {
-		return nil, err
-	}
-
-	usadres, err := co.userRepo.GetAddressById(orde.Addressid)
-	if err != nil {
-		return nil, err
-	}
-
-	items, err := co.orderRepo.GetAllOrderItems(orderId)
-	if err != nil {
-		return nil, err
-	}
-
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.AddPage()
-
-	pdf.SetFont("Arial", "B", 16)
-	pdf.Cell(40, 10, "Invoice")
-	pdf.Ln(10)
-
-	pdf.Cell(0, 10, "Customer Name: "+usr.Name)
-	pdf.Ln(10)
-	pdf.Cell(0, 10, "House Name: "+usadres.Address)
-	pdf.Ln(10)
-	pdf.Cell(0, 10, "State: "+usadres.State)
-	pdf.Ln(10)
-	pdf.Cell(0, 10, "Country: "+usadres.Country)
-	pdf.Ln(10)
-
-	for _, item := range items {
-		pro, err := co.productRepo.GetProductById(item.ProductId)
-		if err != nil {
-			return nil, err
-		}
-		pdf.Cell(0, 10, "Item: "+pro.Name)
-		pdf.Ln(10)
-		pdf.Cell(0, 10, "Price: $"+strconv.Itoa(item.Prize))
-		pdf.Ln(10)
-		pdf.Cell(0, 10, "Quantity: "+strconv.Itoa(item.Quantity))
-		pdf.Ln(10)
-	}
-	pdf.Ln(10)
-	pdf.Cell(0, 10, "Total Amount: $"+strconv.FormatFloat(float64(orde.Total), 'f', 2, 64))
-
-	return pdf, nil
-}
-
 func (ou *OrderUseCase) ExecutePaymentWallet(userId, addressId int) (*entity.Invoice, error) {
-	var orderItems []entity.OrderItem
-	user, err := ou.userRepo.GetById(userId)
-	if err != nil {
-		return nil, err
-	}
-	cart, err := ou.cartRepo.GetByUserid(userId)
-	if err != nil {
-		return nil, err
-	}
-	if user.Wallet < int(cart.TotalPrize) {
-		return nil, errors.New("wallet have not enough money, add moer money or use another payment method ")
-	}
-	cartitems, err1 := ou.cartRepo.GetAllCartItems(int(cart.ID))
-	if err1 != nil {
+	if err := ou.reviewCart(userId); err != nil {
 		return nil, err
 	}
-	userAddres, err := ou.userRepo.GetAddressById(addressId)
+	result, err := ou.placeOrder(userId, addressId, walletPayment{})
 	if err != nil {
 		return nil, err
 	}
-	Total := cart.TotalPrize - cart.OfferPrize
-
-	order := &entity.Order{
-		UserId:        cart.UserId,
-		Addressid:     int(userAddres.ID),
-		Total:         Total,
-		Status:        "pending",
-		PaymentMethod: "wallet",
-		PaymentStatus: "succesful",
-	}
-
-	orderId, err := ou.orderRepo.Create(order)
-	if err != nil {
-		return nil, errors.New("eroor creating user")
-	}
-	user.Wallet -= int(order.Total)
-	err = ou.orderRepo.UpdateUserWallet(user)
-	if err != nil {
-		return nil, errors.New("wallet upadtion failed")
-	}
-	invoiceData := &entity.Invoice{
-		OrderId:     orderId,
-		UserId:      userId,
-		AddressType: userAddres.Type,
-		Quantity:    cart.ProductQuantity,
-		Price:       float64(order.Total),
-		Payment:     order.PaymentMethod,
-		Status:      order.PaymentStatus,
-		PaymentId:   "nil",
-		Remark:      "",
-	}
-	Invoice, err := ou.orderRepo.CreateInvoice(invoiceData)
-	if err != nil {
-		return nil, errors.New("failed to create invoice")
-	}
-	for _, cartItem := range cartitems {
-		orderitem := entity.OrderItem{
-			OrderId:   orderId,
-			ProductId: cartItem.ProductId,
-			Category:  cartItem.Category,
-			Quantity:  cartItem.Quantity,
-			Prize:     cartItem.Price,
-		}
-		orderItems = append(orderItems, orderitem)
-		inventory := entity.Inventory{
-			ProductId:       cartItem.ProductId,
-			ProductCategory: cartItem.Category,
-			Quantity:        cartItem.Quantity,
-		}
-
-		err := ou.productRepo.DecreaseProductQuantity(&inventory)
-		if err != nil {
-			return nil, err
-		}
-	}
-	err3 := ou.orderRepo.CreateOrderItems(orderItems)
-	if err3 != nil {
-		return nil, errors.New("Errro creating orderitems")
-	}
-	err = ou.cartRepo.RemoveCartItems(int(cart.ID))
-	if err != nil {
-		return nil, errors.New("Delete cart items failed")
-	}
-	cart.TotalPrize = 0
-	cart.OfferPrize = 0
-	cart.ProductQuantity = 0
-	err = ou.cartRepo.UpdateCart(cart)
-	if err != nil {
-		return nil, errors.New("Updating cart failed")
-	}
-	return Invoice, nil
+	return result.Invoice, nil
 }
@@ -0,0 +1,121 @@
+package order
+
+import (
+	"errors"
+	"project/domain/entity"
+	"project/domain/money"
+)
+
+type codPayment struct{}
+
+func (codPayment) Method() string             { return entity.PaymentCod }
+func (codPayment) Prepare(c *Checkout) error  { return nil }
+func (codPayment) Complete(c *Checkout) error { return nil }
+func (codPayment) Invoice() bool              { return true }
+func (codPayment) ClearCart() bool            { return true }
+func (codPayment) Stock() stockAction         { return stockDecrement }
+
+// razorpayPayment holds the stock for a razorpay payment. The razorpay
+// order is created by startRazorpayPayment once the checkout has
+// committed, and the invoice and cart clear happen once the payment is
+// verified. With useWallet the available wallet balance is held and
+// razorpay charges the rest.
+type razorpayPayment struct {
+	useWallet bool
+}
+
+func (razorpayPayment) Method() string { return "razorpay" }
+
+func (rp razorpayPayment) Prepare(c *Checkout) error {
+	if rp.useWallet {
+		user, err := c.userRepo.GetById(c.UserId)
+		if err != nil {
+			return err
+		}
+		amount, err := walletShare(user, c.Order.Total)
+		if err != nil {
+			return err
+		}
+		return holdWallet(c, amount)
+	}
+	return nil
+}
+
+func (razorpayPayment) Complete(c *Checkout) error { return nil }
+func (razorpayPayment) Invoice() bool              { return false }
+func (razorpayPayment) ClearCart() bool            { return false }
+func (razorpayPayment) Stock() stockAction         { return stockReserve }
+
+// stripePayment places the order when stripe reports the payment intent and
+// holds the stock until the intent succeeds. A failed intent still records
+// the order but keeps the cart and the stock. walletAmount is the wallet
+// share the intent was created without.
+type stripePayment struct {
+	intentId     string
+	failed       bool
+	walletAmount money.Money
+	// event, when set, is the webhook event placing the order. It is saved
+	// with the order so a parallel delivery cannot place it again.
+	event *entity.WebhookEvent
+}
+
+func (stripePayment) Method() string { return "Stripe" }
+
+func (sp stripePayment) Prepare(c *Checkout) error {
+	c.Order.GatewayOrderId = sp.intentId
+	if sp.failed {
+		c.Order.PaymentStatus = entity.PaymentFailed
+		return nil
+	}
+	return holdWallet(c, sp.walletAmount)
+}
+
+func (sp stripePayment) Complete(c *Checkout) error {
+	if sp.event == nil {
+		return nil
+	}
+	return c.orderRepo.CreateWebhookEvent(sp.event)
+}
+
+func (stripePayment) Invoice() bool      { return true }
+func (sp stripePayment) ClearCart() bool { return !sp.failed }
+
+func (sp stripePayment) Stock() stockAction {
+	if sp.failed {
+		return stockUntouched
+	}
+	return stockReserve
+}
+
+type walletPayment struct{}
+
+func (walletPayment) Method() string { return "wallet" }
+
+func (walletPayment) Prepare(c *Checkout) error {
+	user, err := c.userRepo.GetById(c.UserId)
+	if err != nil {
+		return err
+	}
+	if user.Wallet-user.WalletHeld < c.Order.Total {
+		return errors.New("wallet have not enough money, add moer money or use another payment method ")
+	}
+	c.Order.PaymentStatus = entity.PaymentSucceeded
+	return nil
+}
+
+func (walletPayment) Complete(c *Checkout) error {
+	err := c.walletRepo.Debit(&entity.WalletTransaction{
+		UserId:  c.UserId,
+		Amount:  c.Order.Total,
+		Reason:  entity.WalletReasonOrderPayment,
+		OrderId: c.Order.ID,
+	})
+	if err != nil {
+		return errors.New("wallet upadtion failed")
+	}
+	return nil
+}
+
+func (walletPayment) Invoice() bool      { return true }
+func (walletPayment) ClearCart() bool    { return true }
+func (walletPayment) Stock() stockAction { return stockDecrement }
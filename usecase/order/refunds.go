@@ -0,0 +1,210 @@
+package order
+
+import (
+	"errors"
+	"log"
+	"project/domain/entity"
+	"project/domain/money"
+	"project/domain/payment"
+	repository "project/repository/order"
+	walletrepository "project/repository/wallet"
+	"time"
+)
+
+// gatewayFor is the gateway an order was paid through and the id it knows
+// the payment by. Stripe payments are referred to by their intent.
+func (co *OrderUseCase) gatewayFor(order *entity.Order) (payment.PaymentGateway, string, error) {
+	switch order.PaymentMethod {
+	case "razorpay":
+		return co.razorpay, order.PaymentId, nil
+	case "Stripe":
+		return co.stripe, order.GatewayOrderId, nil
+	}
+	return nil, "", errors.New("order was not paid through a gateway")
+}
+
+func paidThroughGateway(order *entity.Order) bool {
+	return order.PaymentMethod == "razorpay" || order.PaymentMethod == "Stripe"
+}
+
+// isPaid reports whether the customer has already paid for the order.
+func isPaid(order *entity.Order) bool {
+	return order.PaymentStatus == entity.PaymentSucceeded
+}
+
+// ErrLatePayment is returned for a payment that arrived after its order was
+// cancelled or its payment window closed. The payment is refunded.
+var ErrLatePayment = errors.New("order is no longer awaiting payment, the payment will be refunded")
+
+// awaitingPayment reports whether a payment may still be taken for the
+// order.
+func awaitingPayment(order *entity.Order) bool {
+	return CanTransition(order.Status, entity.OrderConfirmed)
+}
+
+// refundLatePayment gives back a payment that arrived after the order was
+// cancelled or expired. Its stock and wallet share were released then, so
+// only what the gateway took goes back, to the original payment. A late
+// payment is refunded once; the gateway refund is sent by processRefunds
+// after the caller's transaction has committed.
+func refundLatePayment(orderRepo *repository.OrderRepository, walletRepo *walletrepository.WalletRepository, order *entity.Order, paymentId string) ([]entity.Refund, error) {
+	if order.PaymentStatus == entity.PaymentRefunded {
+		return nil, nil
+	}
+	log.Printf("payment for %s order %d arrived late, refunding it", order.Status, order.ID)
+	if paymentId != "" {
+		order.PaymentId = paymentId
+	}
+	refunds, err := issueRefunds(orderRepo, walletRepo, order, 0, gatewayAmount(order), entity.RefundToOriginal, entity.WalletReasonCancelRefund)
+	if err != nil {
+		return nil, err
+	}
+	order.PaymentStatus = entity.PaymentRefunded
+	if err := orderRepo.Update(order); err != nil {
+		return nil, errors.New("payment updation failed")
+	}
+	return refunds, nil
+}
+
+// refundMethod checks the requested refund method, defaulting to the
+// original payment for gateway orders and to the wallet otherwise.
+func refundMethod(order *entity.Order, method string) (string, error) {
+	if method == "" {
+		if paidThroughGateway(order) {
+			return entity.RefundToOriginal, nil
+		}
+		return entity.RefundToWallet, nil
+	}
+	if method != entity.RefundToWallet && method != entity.RefundToOriginal {
+		return "", errors.New("invalid refund method")
+	}
+	if method == entity.RefundToOriginal && !paidThroughGateway(order) {
+		return "", errors.New("refund to original payment is not available for this order")
+	}
+	return method, nil
+}
+
+// issueRefunds pays amount back to the customer. With the original method
+// as much as the gateway took goes back to it and the rest, the wallet
+// share of a split payment, is credited to the wallet. Wallet credits are
+// posted right away, gateway refunds are recorded as pending and sent by
+// processRefunds once the caller's transaction has committed.
+func issueRefunds(orderRepo *repository.OrderRepository, walletRepo *walletrepository.WalletRepository, order *entity.Order, returnRequestId int, amount money.Money, method, reason string) ([]entity.Refund, error) {
+	var refunds []entity.Refund
+	if method == entity.RefundToOriginal {
+		refunded, err := orderRepo.GetGatewayRefundedAmount(order.ID)
+		if err != nil {
+			return nil, err
+		}
+		gateway := gatewayAmount(order) - refunded
+		if gateway > amount {
+			gateway = amount
+		}
+		if gateway > 0 {
+			refund := entity.Refund{
+				OrderId:         order.ID,
+				ReturnRequestId: returnRequestId,
+				UserId:          order.UserId,
+				Method:          entity.RefundToOriginal,
+				Amount:          gateway,
+				Status:          entity.RefundPending,
+			}
+			if err := orderRepo.CreateRefund(&refund); err != nil {
+				return nil, errors.New("failed to create refund")
+			}
+			refunds = append(refunds, refund)
+			amount -= gateway
+		}
+	}
+	if amount > 0 {
+		err := walletRepo.Credit(&entity.WalletTransaction{
+			UserId:  order.UserId,
+			Amount:  amount,
+			Reason:  reason,
+			OrderId: order.ID,
+		})
+		if err != nil {
+			return nil, errors.New("wallet upadtion failed")
+		}
+		refund := entity.Refund{
+			OrderId:         order.ID,
+			ReturnRequestId: returnRequestId,
+			UserId:          order.UserId,
+			Method:          entity.RefundToWallet,
+			Amount:          amount,
+			Status:          entity.RefundProcessed,
+			ProcessedAt:     time.Now(),
+		}
+		if err := orderRepo.CreateRefund(&refund); err != nil {
+			return nil, errors.New("failed to create refund")
+		}
+		refunds = append(refunds, refund)
+	}
+	return refunds, nil
+}
+
+// processRefunds sends the pending gateway refunds. A failed refund is kept
+// as failed so an admin can retry it.
+func (co *OrderUseCase) processRefunds(refunds []entity.Refund) {
+	for i := range refunds {
+		if refunds[i].Status != entity.RefundPending {
+			continue
+		}
+		if err := co.processRefund(&refunds[i]); err != nil {
+			log.Printf("refund %d of order %d failed: %v", refunds[i].ID, refunds[i].OrderId, err)
+		}
+	}
+}
+
+func (co *OrderUseCase) processRefund(refund *entity.Refund) error {
+	order, err := co.orderRepo.GetOrderById(refund.OrderId)
+	if err != nil {
+		return err
+	}
+	gateway, paymentId, err := co.gatewayFor(order)
+	if err != nil {
+		return err
+	}
+	result, err := gateway.Refund(paymentId, refund.Amount)
+	if err != nil {
+		refund.Status = entity.RefundFailed
+		refund.FailureReason = err.Error()
+	} else {
+		// The gateway may accept a refund before settling it, in which case
+		// it stays pending until the refund webhook arrives.
+		refund.FailureReason = ""
+		refund.GatewayRefundId = result.Id
+		if result.Processed {
+			refund.Status = entity.RefundProcessed
+			refund.ProcessedAt = time.Now()
+		}
+	}
+	if err2 := co.orderRepo.UpdateRefund(refund); err2 != nil {
+		return errors.New("failed to update refund")
+	}
+	return err
+}
+
+func (co *OrderUseCase) ExecuteUserRefunds(userid int) ([]entity.Refund, error) {
+	return co.orderRepo.GetRefundsByUser(userid)
+}
+
+func (co *OrderUseCase) ExecuteAdminRefunds(page, limit int, status string) ([]entity.Refund, error) {
+	offset := (page - 1) * limit
+	return co.orderRepo.GetRefunds(offset, limit, status)
+}
+
+// ExecuteRetryRefund sends a failed gateway refund again.
+func (co *OrderUseCase) ExecuteRetryRefund(id int) (*entity.Refund, error) {
+	refund, err := co.orderRepo.GetRefundById(id)
+	if err != nil {
+		return nil, err
+	}
+	if refund.Status != entity.RefundFailed {
+		return nil, errors.New("only failed refunds can be retried")
+	}
+	if err := co.processRefund(refund); err != nil {
+		return refund, errors.New("refund to original payment failed")
+	}
+	return refund, nil
+}
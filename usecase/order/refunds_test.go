@@ -0,0 +1,115 @@
+package order
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"project/config"
+	"project/domain/entity"
+	"project/domain/money"
+	"project/domain/payment"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// razorpayAPI stands in for the Razorpay orders and refunds API.
+type razorpayAPI struct {
+	*httptest.Server
+	mu      sync.Mutex
+	decline bool
+	orders  int
+	// refunds are the paise refunded, by payment id.
+	refunds map[string]int64
+}
+
+func newRazorpayAPI(t *testing.T) *razorpayAPI {
+	api := &razorpayAPI{refunds: map[string]int64{}}
+	api.Server = httptest.NewServer(http.HandlerFunc(api.serve))
+	t.Cleanup(api.Close)
+	return api
+}
+
+func (api *razorpayAPI) serve(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	var body struct {
+		Amount int64 `json:"amount"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/v1/orders":
+		api.orders++
+		fmt.Fprintf(w, `{"id":"order_%d","entity":"order","amount":%d,"status":"created"}`, api.orders, body.Amount)
+	case strings.HasPrefix(r.URL.Path, "/v1/payments/") && strings.HasSuffix(r.URL.Path, "/refund"):
+		if api.decline {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"code":"BAD_REQUEST_ERROR","description":"The payment could not be refunded"}}`)
+			return
+		}
+		paymentId := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/payments/"), "/refund")
+		api.refunds[paymentId] += body.Amount
+		fmt.Fprintf(w, `{"id":"rfnd_%s","entity":"refund","amount":%d,"status":"processed"}`, paymentId, body.Amount)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (api *razorpayAPI) setDecline(decline bool) {
+	api.mu.Lock()
+	api.decline = decline
+	api.mu.Unlock()
+}
+
+// TestCancelRefundsThroughRazorpay cancels a paid razorpay order against a
+// stand-in Razorpay API. The refund is recorded, fails while Razorpay
+// declines it and goes through on retry.
+func TestCancelRefundsThroughRazorpay(t *testing.T) {
+	s := newShop(t)
+	api := newRazorpayAPI(t)
+	s.orders.razorpay = payment.NewRazorpay(&config.Razopay{RazopayKey: "rzp_key", RazopaySecret: "rzp_secret", RazopayBaseURL: api.URL})
+	product := s.product(t, money.FromMajor(2000), 3)
+	user, address := s.shopper(t, product, 1, 0)
+
+	_, orderid, err := s.orders.ExecuteRazorPay(user, address, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.orders.confirmRazorpayPayment(orderid, "pay_1"); err != nil {
+		t.Fatal(err)
+	}
+	order := s.order(t, orderid)
+
+	api.setDecline(true)
+	refunds, err := s.orders.ExecuteAdminCancelOrder(orderid, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refunds) != 1 || refunds[0].Method != entity.RefundToOriginal || refunds[0].Amount != order.Total {
+		t.Fatalf("refunds are %+v, want one of %s to the original payment", refunds, order.Total.Format())
+	}
+	stored, err := s.orders.orderRepo.GetRefundById(refunds[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != entity.RefundFailed || stored.FailureReason == "" {
+		t.Fatalf("declined refund is %s (%q), want failed with a reason", stored.Status, stored.FailureReason)
+	}
+
+	api.setDecline(false)
+	if _, err := s.orders.ExecuteRetryRefund(stored.ID); err != nil {
+		t.Fatal(err)
+	}
+	stored, err = s.orders.orderRepo.GetRefundById(stored.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != entity.RefundProcessed || stored.GatewayRefundId != "rfnd_pay_1" {
+		t.Errorf("retried refund is %s with gateway id %q, want processed rfnd_pay_1", stored.Status, stored.GatewayRefundId)
+	}
+	if got := api.refunds["pay_1"]; got != order.Total.Minor() {
+		t.Errorf("razorpay refunded %d paise, want %d", got, order.Total.Minor())
+	}
+}
@@ -0,0 +1,154 @@
+package order
+
+import (
+	"errors"
+	"log"
+	"project/domain/entity"
+	productrepository "project/repository/product"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// reservationTTL is how long stock stays held for an unpaid online order.
+const reservationTTL = 15 * time.Minute
+
+type stockAction int
+
+const (
+	// stockDecrement takes the stock out of the inventory at checkout.
+	stockDecrement stockAction = iota
+	// stockReserve holds the stock until the payment is confirmed.
+	stockReserve
+	// stockUntouched leaves the inventory alone.
+	stockUntouched
+)
+
+func reserveStock(productRepo *productrepository.ProductRepository, orderid int, items []entity.CartItem) error {
+	var reservations []entity.StockReservation
+	expiresAt := time.Now().Add(reservationTTL)
+	for _, item := range items {
+		inventory := entity.Inventory{
+			ProductId:       item.ProductId,
+			ProductCategory: item.Category,
+			Quantity:        item.Quantity,
+		}
+		if err := productRepo.ReserveProductQuantity(&inventory); err != nil {
+			return err
+		}
+		reservations = append(reservations, entity.StockReservation{
+			OrderId:         orderid,
+			ProductId:       item.ProductId,
+			ProductCategory: item.Category,
+			Quantity:        item.Quantity,
+			Status:          entity.ReservationHeld,
+			ExpiresAt:       expiresAt,
+		})
+	}
+	if err := productRepo.CreateReservations(reservations); err != nil {
+		return errors.New("failed to reserve stock")
+	}
+	return nil
+}
+
+// commitReservations takes the held stock of a paid order out of the
+// inventory. Orders without holds are left alone.
+func commitReservations(productRepo *productrepository.ProductRepository, order *entity.Order) error {
+	if order.Status == entity.OrderPaymentExpired {
+		return errors.New("payment window for this order has expired")
+	}
+	reservations, err := productRepo.GetReservationsByOrder(order.ID, entity.ReservationHeld)
+	if err != nil {
+		return err
+	}
+	for i := range reservations {
+		inventory := entity.Inventory{
+			ProductId:       reservations[i].ProductId,
+			ProductCategory: reservations[i].ProductCategory,
+			Quantity:        reservations[i].Quantity,
+		}
+		if err := productRepo.CommitReservedQuantity(&inventory); err != nil {
+			return err
+		}
+		if err := productRepo.UpdateReservationStatus(&reservations[i], entity.ReservationCommitted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func releaseReservations(productRepo *productrepository.ProductRepository, orderid int) error {
+	reservations, err := productRepo.GetReservationsByOrder(orderid, entity.ReservationHeld)
+	if err != nil {
+		return err
+	}
+	for i := range reservations {
+		inventory := entity.Inventory{
+			ProductId:       reservations[i].ProductId,
+			ProductCategory: reservations[i].ProductCategory,
+			Quantity:        reservations[i].Quantity,
+		}
+		if err := productRepo.ReleaseReservedQuantity(&inventory); err != nil {
+			return err
+		}
+		if err := productRepo.UpdateReservationStatus(&reservations[i], entity.ReservationReleased); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseExpiredReservations gives back the stock, held wallet share and
+// coupons of online orders whose payment never arrived and marks those orders
+// payment_expired.
+func (co *OrderUseCase) ReleaseExpiredReservations() error {
+	orderids, err := co.productRepo.GetExpiredReservationOrders(time.Now())
+	if err != nil {
+		return err
+	}
+	for _, orderid := range orderids {
+		if err := co.expireOrder(orderid, "payment not received in time"); err != nil {
+			log.Printf("releasing reservations of order %d failed: %v", orderid, err)
+		}
+	}
+	return nil
+}
+
+// expireOrder gives back the stock, held wallet share and coupons of an
+// unpaid online order and marks it payment_expired.
+func (co *OrderUseCase) expireOrder(orderid int, remark string) error {
+	return co.orderRepo.Transaction(func(tx *gorm.DB) error {
+		orderRepo := co.orderRepo.WithTx(tx)
+		order, err := orderRepo.GetOrderByIdForUpdate(orderid)
+		if err != nil {
+			return err
+		}
+		// An order that has moved on keeps its stock, held or not.
+		if isPaid(order) || !CanTransition(order.Status, entity.OrderPaymentExpired) {
+			return nil
+		}
+		if err := releaseReservations(co.productRepo.WithTx(tx), orderid); err != nil {
+			return err
+		}
+		if err := releaseWallet(co.walletRepo.WithTx(tx), order); err != nil {
+			return err
+		}
+		if err := co.coupons.WithTx(tx).Release(orderid); err != nil {
+			return err
+		}
+		order.PaymentStatus = entity.PaymentExpired
+		return transition(orderRepo, order, entity.OrderPaymentExpired, ActorSystem, 0, remark)
+	})
+}
+
+// StartReservationSweeper releases expired holds every interval. It blocks,
+// so run it in its own goroutine.
+func (co *OrderUseCase) StartReservationSweeper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := co.ReleaseExpiredReservations(); err != nil {
+			log.Printf("reservation sweeper: %v", err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package order
+
+import (
+	"project/domain/entity"
+	"project/domain/money"
+	"testing"
+	"time"
+)
+
+// TestSweepSkipsConfirmedOrder runs the sweeper over a confirmed order that
+// still has an expired hold. The order keeps its stock and its status.
+func TestSweepSkipsConfirmedOrder(t *testing.T) {
+	s := newShop(t)
+	product := s.product(t, money.FromMajor(2000), 3)
+	user, address := s.shopper(t, product, 1, 0)
+
+	_, orderid, err := s.orders.ExecuteRazorPay(user, address, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.Model(&entity.Order{}).Where("id = ?", orderid).Update("status", entity.OrderConfirmed).Error; err != nil {
+		t.Fatal(err)
+	}
+	err = s.db.Model(&entity.StockReservation{}).Where("order_id = ?", orderid).
+		Update("expires_at", time.Now().Add(-time.Hour)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.orders.ReleaseExpiredReservations(); err != nil {
+		t.Fatal(err)
+	}
+	if order := s.order(t, orderid); order.Status != entity.OrderConfirmed {
+		t.Errorf("swept order is %s, want confirmed", order.Status)
+	}
+	if inventory := s.inventory(t, product.ID); inventory.Quantity != 3 || inventory.Reserved != 1 {
+		t.Errorf("stock is %d with %d reserved after the sweep, want 3 and 1", inventory.Quantity, inventory.Reserved)
+	}
+}
@@ -0,0 +1,250 @@
+package order
+
+import (
+	"errors"
+	"project/domain/entity"
+	"project/domain/money"
+	repository "project/repository/order"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// returnWindow is how long after delivery a customer may ask for a return.
+const returnWindow = 7 * 24 * time.Hour
+
+func (co *OrderUseCase) ExecuteOrderItems(userid, orderid int) ([]entity.OrderItem, error) {
+	order, err := co.orderRepo.GetOrderById(orderid)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserId != userid {
+		return nil, errors.New("order not found")
+	}
+	return co.orderRepo.GetAllOrderItems(orderid)
+}
+
+// ExecuteReturnRequest opens a return for some or all units of a delivered
+// order item. The refund goes back to the gateway by default for gateway
+// orders and to the wallet otherwise. The order item stays locked from the
+// quantity check until the request is saved, so parallel requests cannot
+// return more units than were bought.
+func (co *OrderUseCase) ExecuteReturnRequest(userid, orderItemId, quantity int, reason, comment, refund string) (*entity.ReturnRequest, error) {
+	var request *entity.ReturnRequest
+	err := co.orderRepo.Transaction(func(tx *gorm.DB) error {
+		orderRepo := co.orderRepo.WithTx(tx)
+		item, err := orderRepo.GetOrderItemByIdForUpdate(orderItemId)
+		if err != nil {
+			return err
+		}
+		order, err := orderRepo.GetOrderById(item.OrderId)
+		if err != nil {
+			return err
+		}
+		if order.UserId != userid {
+			return errors.New("order not found")
+		}
+		if order.Status != entity.OrderDelivered {
+			return errors.New("only delivered orders can be returned")
+		}
+		if err := co.checkReturnWindow(order.ID); err != nil {
+			return err
+		}
+		if !isStatus(reason, entity.ReturnReasons) {
+			return errors.New("invalid return reason")
+		}
+		returned, err := orderRepo.GetReturnedQuantity(item.ID)
+		if err != nil {
+			return err
+		}
+		if quantity < 1 || quantity > item.Quantity-returned {
+			return errors.New("invalid return quantity")
+		}
+		method, err := refundMethod(order, refund)
+		if err != nil {
+			return err
+		}
+		amount, err := co.refundAmount(order, item, quantity)
+		if err != nil {
+			return err
+		}
+		request = &entity.ReturnRequest{
+			OrderId:      order.ID,
+			OrderItemId:  item.ID,
+			UserId:       userid,
+			Quantity:     quantity,
+			Reason:       reason,
+			Comment:      comment,
+			Status:       entity.ReturnRequested,
+			RefundMethod: method,
+			RefundAmount: amount,
+		}
+		if err := orderRepo.CreateReturnRequest(request); err != nil {
+			return errors.New("failed to create return request")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+func (co *OrderUseCase) checkReturnWindow(orderid int) error {
+	history, err := co.orderRepo.GetStatusHistory(orderid)
+	if err != nil {
+		return err
+	}
+	for _, h := range history {
+		if h.ToStatus == entity.OrderDelivered {
+			if time.Since(h.ChangedAt) > returnWindow {
+				return errors.New("return window has closed")
+			}
+			return nil
+		}
+	}
+	return errors.New("delivery date not found")
+}
+
+// refundAmount is the returned units' share of what was paid for the item,
+// so the order discount is taken back with them and any GST charged on top
+// is returned. Items from before the discount was recorded per item get a
+// proportional share of the total.
+func (co *OrderUseCase) refundAmount(order *entity.Order, item *entity.OrderItem, quantity int) (money.Money, error) {
+	if paid := item.Taxable + item.CGST + item.SGST + item.IGST; paid > 0 {
+		return paid.MulDiv(int64(quantity), int64(item.Quantity)), nil
+	}
+	if item.ProductName != "" {
+		paid := item.Prize.Mul(item.Quantity) - item.DiscountShare
+		return paid.MulDiv(int64(quantity), int64(item.Quantity)), nil
+	}
+	items, err := co.orderRepo.GetAllOrderItems(order.ID)
+	if err != nil {
+		return 0, err
+	}
+	var itemsTotal money.Money
+	for _, i := range items {
+		itemsTotal += i.Prize.Mul(i.Quantity)
+	}
+	if itemsTotal == 0 {
+		return 0, nil
+	}
+	return order.Total.MulDiv(int64(item.Prize.Mul(quantity)), int64(itemsTotal)), nil
+}
+
+func (co *OrderUseCase) ExecuteUserReturns(userid int) ([]entity.ReturnRequest, error) {
+	return co.orderRepo.GetReturnRequestsByUser(userid)
+}
+
+func (co *OrderUseCase) ExecuteAdminReturns(page, limit int, status string) ([]entity.ReturnRequest, error) {
+	offset := (page - 1) * limit
+	return co.orderRepo.GetReturnRequests(offset, limit, status)
+}
+
+func (co *OrderUseCase) ExecuteApproveReturn(id, adminId int, remark string) (*entity.ReturnRequest, error) {
+	return co.reviewReturn(id, adminId, entity.ReturnApproved, remark)
+}
+
+func (co *OrderUseCase) ExecuteRejectReturn(id, adminId int, remark string) (*entity.ReturnRequest, error) {
+	return co.reviewReturn(id, adminId, entity.ReturnRejected, remark)
+}
+
+func (co *OrderUseCase) reviewReturn(id, adminId int, status, remark string) (*entity.ReturnRequest, error) {
+	var request *entity.ReturnRequest
+	err := co.orderRepo.Transaction(func(tx *gorm.DB) error {
+		orderRepo := co.orderRepo.WithTx(tx)
+		var err error
+		request, err = orderRepo.GetReturnRequestByIdForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if request.Status != entity.ReturnRequested {
+			return errors.New("return request is already " + request.Status)
+		}
+		request.Status = status
+		request.AdminRemark = remark
+		request.ReviewedBy = adminId
+		if err := orderRepo.UpdateReturnRequest(request); err != nil {
+			return errors.New("failed to update return request")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// ExecuteReceiveReturn puts the returned units back in stock and refunds
+// them. Once every unit of the order has been refunded the order moves to
+// returned and then refunded.
+func (co *OrderUseCase) ExecuteReceiveReturn(id, adminId int) (*entity.ReturnRequest, []entity.Refund, error) {
+	var request *entity.ReturnRequest
+	var refunds []entity.Refund
+	err := co.orderRepo.Transaction(func(tx *gorm.DB) error {
+		orderRepo := co.orderRepo.WithTx(tx)
+		var err error
+		// The lock keeps a second receive from restocking and refunding
+		// the return again.
+		request, err = orderRepo.GetReturnRequestByIdForUpdate(id)
+		if err != nil {
+			return err
+		}
+		if request.Status != entity.ReturnApproved {
+			return errors.New("return request is not approved")
+		}
+		item, err := orderRepo.GetOrderItemById(request.OrderItemId)
+		if err != nil {
+			return err
+		}
+		order, err := orderRepo.GetOrderByIdForUpdate(request.OrderId)
+		if err != nil {
+			return err
+		}
+		if err := co.productRepo.WithTx(tx).IncreaseProductQuantity(item.ProductId, request.Quantity); err != nil {
+			return errors.New("error updating inventory")
+		}
+		refunds, err = issueRefunds(orderRepo, co.walletRepo.WithTx(tx), order, request.ID, request.RefundAmount, request.RefundMethod, entity.WalletReasonReturnRefund)
+		if err != nil {
+			return err
+		}
+		request.Status = entity.ReturnRefunded
+		request.ReviewedBy = adminId
+		if err := orderRepo.UpdateReturnRequest(request); err != nil {
+			return errors.New("failed to update return request")
+		}
+		return closeReturnedOrder(orderRepo, order, adminId)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	co.processRefunds(refunds)
+	return request, refunds, nil
+}
+
+func closeReturnedOrder(orderRepo *repository.OrderRepository, order *entity.Order, adminId int) error {
+	items, err := orderRepo.GetAllOrderItems(order.ID)
+	if err != nil {
+		return err
+	}
+	ordered := 0
+	for _, i := range items {
+		ordered += i.Quantity
+	}
+	refunded, err := orderRepo.GetRefundedQuantity(order.ID)
+	if err != nil {
+		return err
+	}
+	if refunded < ordered {
+		return nil
+	}
+	if order.Status == entity.OrderDelivered {
+		if err := transition(orderRepo, order, entity.OrderReturned, ActorAdmin, adminId, "all items returned"); err != nil {
+			return err
+		}
+	}
+	if order.Status != entity.OrderReturned {
+		return nil
+	}
+	return transition(orderRepo, order, entity.OrderRefunded, ActorAdmin, adminId, "all items refunded")
+}
@@ -0,0 +1,108 @@
+package order
+
+import (
+	"project/domain/entity"
+	"project/domain/money"
+	"sync"
+	"testing"
+)
+
+// delivered is a new shopper's cash on delivery order of quantity units of
+// the product, delivered. It returns the shopper and the order item.
+func (s *shop) delivered(t *testing.T, product *entity.Product, quantity int) (int, *entity.OrderItem) {
+	t.Helper()
+	user, address := s.shopper(t, product, quantity, 0)
+	invoice, err := s.orders.ExecuteOrderCod(user, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range []string{entity.OrderConfirmed, entity.OrderPacked, entity.OrderShipped, entity.OrderOutForDelivery, entity.OrderDelivered} {
+		if err := s.orders.ExecuteOrderUpdate(invoice.OrderId, status, 1, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	items, err := s.orders.ExecuteOrderItems(user, invoice.OrderId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user, &items[0]
+}
+
+// TestParallelReturnRequests asks to return one unit many times at once.
+// Only as many requests as units were bought may go through.
+func TestParallelReturnRequests(t *testing.T) {
+	s := newShop(t)
+	product := s.product(t, money.FromMajor(1000), 5)
+	user, item := s.delivered(t, product, 2)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	requested := 0
+	start := make(chan struct{})
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if _, err := s.orders.ExecuteReturnRequest(user, item.ID, 1, entity.ReturnReasons[0], "", ""); err == nil {
+				mu.Lock()
+				requested++
+				mu.Unlock()
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if requested != 2 {
+		t.Errorf("%d return requests went through for 2 units, want 2", requested)
+	}
+}
+
+// TestReturnReceivedOnce has admins mark the same return received at once.
+// The units must go back in stock and be refunded only once.
+func TestReturnReceivedOnce(t *testing.T) {
+	s := newShop(t)
+	product := s.product(t, money.FromMajor(1000), 5)
+	user, item := s.delivered(t, product, 1)
+	request, err := s.orders.ExecuteReturnRequest(user, item.ID, 1, entity.ReturnReasons[0], "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.orders.ExecuteApproveReturn(request.ID, 1, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	received := 0
+	start := make(chan struct{})
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if _, _, err := s.orders.ExecuteReceiveReturn(request.ID, 1); err == nil {
+				mu.Lock()
+				received++
+				mu.Unlock()
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if received != 1 {
+		t.Fatalf("return was received %d times, want 1", received)
+	}
+	if inventory := s.inventory(t, product.ID); inventory.Quantity != 5 {
+		t.Errorf("stock after the return is %d, want 5", inventory.Quantity)
+	}
+	shopper, err := s.orders.userRepo.GetById(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shopper.Wallet != request.RefundAmount {
+		t.Errorf("wallet after the refund is %s, want %s", shopper.Wallet.Format(), request.RefundAmount.Format())
+	}
+}
@@ -0,0 +1,74 @@
+package order
+
+import (
+	"errors"
+	"project/domain/entity"
+	"project/domain/money"
+	walletrepository "project/repository/wallet"
+)
+
+// walletShare is how much of total the wallet can pay when the rest goes
+// through a gateway. A wallet that covers the whole order should be used on
+// its own.
+func walletShare(user *entity.User, total money.Money) (money.Money, error) {
+	available := user.Wallet - user.WalletHeld
+	if available <= 0 {
+		return 0, nil
+	}
+	if available >= total {
+		return 0, errors.New("wallet covers the whole order, use wallet payment")
+	}
+	return available, nil
+}
+
+// holdWallet sets the wallet share of a gateway order aside. It is only
+// debited once the gateway payment is verified.
+func holdWallet(c *Checkout, amount money.Money) error {
+	if amount <= 0 {
+		return nil
+	}
+	if amount >= c.Order.Total {
+		return errors.New("wallet covers the whole order, use wallet payment")
+	}
+	if err := c.walletRepo.Hold(c.UserId, amount); err != nil {
+		return err
+	}
+	c.Order.WalletAmount = amount
+	c.Order.WalletStatus = entity.WalletHeld
+	return nil
+}
+
+// gatewayAmount is what is left for the gateway to charge.
+func gatewayAmount(order *entity.Order) money.Money {
+	return order.Total - order.WalletAmount
+}
+
+// commitWallet debits the held wallet share. The caller saves the order.
+func commitWallet(walletRepo *walletrepository.WalletRepository, order *entity.Order) error {
+	if order.WalletStatus != entity.WalletHeld {
+		return nil
+	}
+	err := walletRepo.CommitHold(&entity.WalletTransaction{
+		UserId:  order.UserId,
+		Amount:  order.WalletAmount,
+		Reason:  entity.WalletReasonOrderPayment,
+		OrderId: order.ID,
+	})
+	if err != nil {
+		return errors.New("wallet upadtion failed")
+	}
+	order.WalletStatus = entity.WalletCommitted
+	return nil
+}
+
+// releaseWallet gives the held wallet share back. The caller saves the order.
+func releaseWallet(walletRepo *walletrepository.WalletRepository, order *entity.Order) error {
+	if order.WalletStatus != entity.WalletHeld {
+		return nil
+	}
+	if err := walletRepo.ReleaseHold(order.UserId, order.WalletAmount); err != nil {
+		return errors.New("wallet upadtion failed")
+	}
+	order.WalletStatus = entity.WalletReleased
+	return nil
+}
@@ -0,0 +1,291 @@
+package order
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"project/domain/entity"
+	"project/domain/money"
+	repository "project/repository/order"
+	userrepository "project/repository/user"
+	"project/usecase/tax"
+	"strconv"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"gorm.io/gorm"
+)
+
+// invoiceLine is one row of the tax invoice. Amounts splits what the
+// customer paid for the line into its taxable value and GST.
+type invoiceLine struct {
+	Name      string
+	HSNCode   string
+	Quantity  int
+	UnitPrice money.Money
+	Price     money.Money
+	Discount  money.Money
+	Rate      int
+	Exclusive bool
+	tax.Amounts
+}
+
+// invoiceable reports whether the order is a completed sale that gets a
+// tax invoice: cash on delivery orders when placed, others once paid.
+func invoiceable(order *entity.Order) bool {
+	if order.Status == entity.OrderCancelled || order.Status == entity.OrderPaymentExpired {
+		return false
+	}
+	return order.PaymentMethod == entity.PaymentCod || isPaid(order)
+}
+
+// financialYear is the Indian financial year, April to March, as "2026-27".
+func financialYear(t time.Time) string {
+	year := t.Year()
+	if t.Month() < time.April {
+		year--
+	}
+	return fmt.Sprintf("%d-%02d", year, (year+1)%100)
+}
+
+// itemTax is the GST charged on an order item. Items from before tax was
+// recorded on them were sold at tax inclusive prices, so their tax is taken
+// out of what was paid at the category's rate.
+func (co *OrderUseCase) itemTax(item *entity.OrderItem, intraState bool) (tax.Rate, tax.Amounts) {
+	if item.Taxable+item.CGST+item.SGST+item.IGST > 0 {
+		rate := tax.Rate{HSNCode: item.HSNCode, Percent: item.GSTRate}
+		amounts := tax.Amounts{Taxable: item.Taxable, CGST: item.CGST, SGST: item.SGST, IGST: item.IGST}
+		amounts.Total = amounts.Taxable + amounts.Tax()
+		rate.Exclusive = amounts.Total > item.Prize.Mul(item.Quantity)-item.DiscountShare
+		return rate, amounts
+	}
+	rate, err := co.taxes.Rate(item.Category)
+	if err != nil {
+		rate = tax.Rate{Percent: tax.DefaultRate}
+	}
+	rate.Exclusive = false
+	return rate, tax.Compute(item.Prize.Mul(item.Quantity)-item.DiscountShare, rate, intraState)
+}
+
+// issueTaxInvoice numbers, renders and stores the order's tax invoice. It
+// must run in the transaction that completes the sale, so the invoice
+// number is only used if the sale commits. An order that already has an
+// invoice keeps it.
+func (co *OrderUseCase) issueTaxInvoice(orderRepo *repository.OrderRepository, userRepo *userrepository.UserRepository, order *entity.Order) (*entity.TaxInvoice, error) {
+	if invoice, err := orderRepo.GetTaxInvoiceByOrderId(order.ID); err == nil {
+		return invoice, nil
+	}
+	items, err := orderRepo.GetAllOrderItems(order.ID)
+	if err != nil {
+		return nil, err
+	}
+	address, err := shippingAddress(orderRepo, userRepo, order)
+	if err != nil {
+		return nil, err
+	}
+	user, err := userRepo.GetById(order.UserId)
+	if err != nil || user == nil {
+		return nil, errors.New("error getting user")
+	}
+	intraState := co.taxes.IntraState(address.State)
+	invoice := &entity.TaxInvoice{
+		OrderId:       order.ID,
+		IssuedAt:      time.Now(),
+		PlaceOfSupply: address.State,
+	}
+	lines := make([]invoiceLine, len(items))
+	for i := range items {
+		line := &lines[i]
+		line.Name = itemName(co.productRepo, &items[i])
+		line.Quantity = items[i].Quantity
+		line.Price = items[i].Prize
+		// Older items have no list price recorded.
+		line.UnitPrice = items[i].UnitPrice
+		if line.UnitPrice < line.Price {
+			line.UnitPrice = line.Price
+		}
+		line.Discount = items[i].DiscountShare
+		rate, amounts := co.itemTax(&items[i], intraState)
+		line.HSNCode = rate.HSNCode
+		line.Rate = rate.Percent
+		line.Exclusive = rate.Exclusive
+		line.Amounts = amounts
+		invoice.Taxable += line.Taxable
+		invoice.CGST += line.CGST
+		invoice.SGST += line.SGST
+		invoice.IGST += line.IGST
+		invoice.Total += line.Total
+	}
+	invoice.FinancialYear = financialYear(invoice.IssuedAt)
+	invoice.Sequence, err = orderRepo.NextInvoiceSequence(invoice.FinancialYear)
+	if err != nil {
+		return nil, errors.New("error numbering invoice")
+	}
+	invoice.Number = fmt.Sprintf("INV/%s/%06d", invoice.FinancialYear, invoice.Sequence)
+	invoice.Pdf, err = co.renderTaxInvoice(invoice, order, user, address, lines, intraState)
+	if err != nil {
+		return nil, err
+	}
+	if err := orderRepo.CreateTaxInvoice(invoice); err != nil {
+		return nil, errors.New("error saving invoice")
+	}
+	return invoice, nil
+}
+
+// ExecutPrintInvoice returns the order's tax invoice. Orders placed before
+// invoices were issued at checkout get theirs on the first download.
+func (co *OrderUseCase) ExecutPrintInvoice(orderId, userid int) (*entity.TaxInvoice, error) {
+	order, err := co.orderRepo.GetOrderById(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserId != userid {
+		return nil, errors.New("order not found")
+	}
+	if invoice, err := co.orderRepo.GetTaxInvoiceByOrderId(orderId); err == nil {
+		return invoice, nil
+	}
+	if !invoiceable(order) {
+		return nil, errors.New("the invoice is issued once the order is paid")
+	}
+	var invoice *entity.TaxInvoice
+	err = co.orderRepo.Transaction(func(tx *gorm.DB) error {
+		var err error
+		invoice, err = co.issueTaxInvoice(co.orderRepo.WithTx(tx), co.userRepo.WithTx(tx), order)
+		return err
+	})
+	if err != nil {
+		// A concurrent download may have issued it first.
+		if existing, err2 := co.orderRepo.GetTaxInvoiceByOrderId(orderId); err2 == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return invoice, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
+
+func (co *OrderUseCase) renderTaxInvoice(invoice *entity.TaxInvoice, order *entity.Order, user *entity.User, address *entity.OrderAddress, lines []invoiceLine, intraState bool) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetCreationDate(invoice.IssuedAt)
+	pdf.SetModificationDate(invoice.IssuedAt)
+	pdf.SetTitle("Tax Invoice "+invoice.Number, false)
+	pdf.AddPage()
+
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(0, 10, "TAX INVOICE", "", 1, "C", false, 0, "")
+
+	pdf.SetFont("Arial", "B", 11)
+	pdf.CellFormat(0, 6, co.seller.SellerName, "", 1, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 9)
+	pdf.MultiCell(0, 5, co.seller.SellerAddress, "", "L", false)
+	pdf.CellFormat(0, 5, "State: "+co.seller.SellerState, "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 5, "GSTIN: "+co.seller.SellerGSTIN, "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	pdf.CellFormat(95, 5, "Invoice No: "+invoice.Number, "", 0, "L", false, 0, "")
+	pdf.CellFormat(95, 5, "Invoice Date: "+invoice.IssuedAt.Format("02-01-2006"), "", 1, "R", false, 0, "")
+	pdf.CellFormat(95, 5, "Order ID: "+strconv.Itoa(order.ID), "", 0, "L", false, 0, "")
+	pdf.CellFormat(95, 5, "Payment: "+order.PaymentMethod, "", 1, "R", false, 0, "")
+	pdf.Ln(3)
+
+	pdf.SetFont("Arial", "B", 9)
+	pdf.CellFormat(0, 5, "Bill / Ship To", "", 1, "L", false, 0, "")
+	pdf.SetFont("Arial", "", 9)
+	pdf.CellFormat(0, 5, user.Name, "", 1, "L", false, 0, "")
+	pdf.MultiCell(0, 5, address.Address, "", "L", false)
+	pdf.CellFormat(0, 5, address.State+", "+address.Country+" - "+address.Pin, "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 5, "Place of Supply: "+invoice.PlaceOfSupply, "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	taxHeader := "IGST"
+	if intraState {
+		taxHeader = "CGST+SGST"
+	}
+	widths := []float64{8, 38, 14, 10, 22, 22, 18, 24, 14}
+	headers := []string{"#", "Item", "HSN", "Qty", "MRP", "Price", "Discount", "Taxable", "GST %"}
+	pdf.SetFont("Arial", "", 8)
+	pdf.CellFormat(0, 5, "Amounts in INR", "", 1, "R", false, 0, "")
+	pdf.SetFont("Arial", "B", 8)
+	for i, h := range headers {
+		pdf.CellFormat(widths[i], 7, h, "1", 0, "C", false, 0, "")
+	}
+	pdf.CellFormat(0, 7, taxHeader, "1", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "", 8)
+	exclusive := false
+	for i, line := range lines {
+		rate := strconv.Itoa(line.Rate)
+		if line.Exclusive {
+			rate += "*"
+			exclusive = true
+		}
+		cells := []string{
+			strconv.Itoa(i + 1),
+			truncate(line.Name, 24),
+			line.HSNCode,
+			strconv.Itoa(line.Quantity),
+			line.UnitPrice.Grouped(),
+			line.Price.Grouped(),
+			line.Discount.Grouped(),
+			line.Taxable.Grouped(),
+			rate,
+		}
+		for j, cell := range cells {
+			align := "R"
+			if j == 1 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[j], 7, cell, "1", 0, align, false, 0, "")
+		}
+		pdf.CellFormat(0, 7, line.Tax().Grouped(), "1", 1, "R", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	var gross, offer money.Money
+	for _, line := range lines {
+		gross += line.UnitPrice.Mul(line.Quantity)
+		if line.UnitPrice > line.Price {
+			offer += (line.UnitPrice - line.Price).Mul(line.Quantity)
+		}
+	}
+	totals := [][2]string{
+		{"Gross Amount (MRP)", gross.Format()},
+		{"Offer Discount", (-offer).Format()},
+		{"Coupon Discount", (-order.Discount).Format()},
+		{"Taxable Value", invoice.Taxable.Format()},
+	}
+	if intraState {
+		totals = append(totals,
+			[2]string{"CGST", invoice.CGST.Format()},
+			[2]string{"SGST", invoice.SGST.Format()})
+	} else {
+		totals = append(totals, [2]string{"IGST", invoice.IGST.Format()})
+	}
+	for _, t := range totals {
+		pdf.CellFormat(150, 6, t[0], "", 0, "R", false, 0, "")
+		pdf.CellFormat(0, 6, t[1], "", 1, "R", false, 0, "")
+	}
+	pdf.SetFont("Arial", "B", 10)
+	pdf.CellFormat(150, 8, "Invoice Total", "T", 0, "R", false, 0, "")
+	pdf.CellFormat(0, 8, invoice.Total.Format(), "T", 1, "R", false, 0, "")
+	pdf.Ln(4)
+	pdf.SetFont("Arial", "", 8)
+	if exclusive {
+		pdf.CellFormat(0, 5, "Prices are inclusive of GST, except at rates marked * where GST is added to the price.", "", 1, "L", false, 0, "")
+	} else {
+		pdf.CellFormat(0, 5, "Prices are inclusive of GST.", "", 1, "L", false, 0, "")
+	}
+	pdf.CellFormat(0, 5, "This is a computer generated invoice.", "", 1, "L", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, errors.New("error rendering invoice")
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,183 @@
+package order
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"project/domain/entity"
+	"project/domain/money"
+	"project/domain/payment"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func (co *OrderUseCase) gateway(provider string) (payment.PaymentGateway, error) {
+	switch provider {
+	case payment.ProviderRazorpay:
+		return co.razorpay, nil
+	case payment.ProviderStripe:
+		return co.stripe, nil
+	}
+	return nil, errors.New("unknown payment provider")
+}
+
+// ParseWebhook verifies a webhook request with the provider's gateway.
+func (co *OrderUseCase) ParseWebhook(provider string, payload []byte, header http.Header) (*payment.Event, error) {
+	gateway, err := co.gateway(provider)
+	if err != nil {
+		return nil, err
+	}
+	return gateway.ParseWebhook(payload, header)
+}
+
+// once runs fn for a gateway event that has not been processed yet and
+// records it afterwards. fn must itself be safe to repeat, since a failed
+// run is retried by the gateway. fn is given the record so it can save it
+// in its own transaction; a record that is already there, saved by fn or
+// by a parallel delivery of the same event, is not an error.
+func (co *OrderUseCase) once(provider, eventId, event string, fn func(record *entity.WebhookEvent) error) error {
+	seen, err := co.orderRepo.WebhookEventExists(provider, eventId)
+	if err != nil {
+		return err
+	}
+	if seen {
+		return nil
+	}
+	record := &entity.WebhookEvent{
+		Provider:    provider,
+		EventId:     eventId,
+		Event:       event,
+		ProcessedAt: time.Now(),
+	}
+	if err := fn(record); err != nil {
+		return err
+	}
+	if err := co.orderRepo.CreateWebhookEvent(record); err != nil && !errors.Is(err, gorm.ErrDuplicatedKey) {
+		return err
+	}
+	return nil
+}
+
+// ExecuteWebhook applies a verified gateway event. Each handler is safe to
+// run after the browser callback has done the same work. Stripe orders are
+// placed from their intent's events, using the metadata ExecuteStripeIntent
+// put on it, so the invoice is returned for events that place or fail one.
+func (co *OrderUseCase) ExecuteWebhook(provider string, event *payment.Event) (*entity.Invoice, error) {
+	if event.Type == "" {
+		return nil, nil
+	}
+	var invoice *entity.Invoice
+	err := co.once(provider, event.Id, event.Type, func(record *entity.WebhookEvent) error {
+		var err error
+		switch event.Type {
+		case payment.EventIntentCreated:
+			if provider == payment.ProviderStripe {
+				userid, addressid, walletAmount := intentMetadata(event.Metadata)
+				invoice, err = co.ExecuteInvoiceStripe(event.IntentId, userid, addressid, walletAmount, record)
+			}
+		case payment.EventPaymentAuthorized:
+			err = co.capturePayment(provider, event)
+		case payment.EventPaymentSucceeded:
+			if provider == payment.ProviderStripe {
+				err = co.ExecuteStripePaymentSucceeded(event.IntentId)
+				break
+			}
+			order, err2 := co.orderRepo.GetByRazorId(event.IntentId)
+			if err2 != nil {
+				log.Printf("%s webhook: no order for %s", provider, event.IntentId)
+				return nil
+			}
+			_, err = co.confirmRazorpayPayment(order.ID, event.PaymentId)
+			if errors.Is(err, ErrLatePayment) {
+				err = nil
+			}
+		case payment.EventPaymentFailed:
+			if provider == payment.ProviderStripe {
+				userid, addressid, _ := intentMetadata(event.Metadata)
+				invoice, err = co.CreateInvoiceForFailedPayment(event.IntentId, userid, addressid, record)
+				break
+			}
+			err = co.razorpayPaymentFailed(event.IntentId)
+		case payment.EventRefundProcessed:
+			err = co.refundProcessed(provider, event.RefundId)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// intentMetadata reads the checkout details a stripe intent carries. Intents
+// from before amounts were kept in paise have a wallet_amount in rupees.
+func intentMetadata(metadata map[string]string) (int, int, money.Money) {
+	userid, _ := strconv.Atoi(metadata["user_id"])
+	addressid, _ := strconv.Atoi(metadata["address_id"])
+	if paise, err := strconv.ParseInt(metadata["wallet_paise"], 10, 64); err == nil {
+		return userid, addressid, money.Money(paise)
+	}
+	rupees, _ := strconv.ParseInt(metadata["wallet_amount"], 10, 64)
+	return userid, addressid, money.FromMajor(rupees)
+}
+
+// capturePayment takes an authorised payment that the gateway did not
+// capture on its own.
+func (co *OrderUseCase) capturePayment(provider string, event *payment.Event) error {
+	order, err := co.orderRepo.GetByRazorId(event.IntentId)
+	if err != nil {
+		log.Printf("%s webhook: no order for %s", provider, event.IntentId)
+		return nil
+	}
+	if isPaid(order) {
+		return nil
+	}
+	// Left uncaptured, the authorisation lapses and the customer is not
+	// charged for an order that is gone.
+	if !awaitingPayment(order) {
+		log.Printf("%s webhook: not capturing payment for %s order %d", provider, order.Status, order.ID)
+		return nil
+	}
+	gateway, err := co.gateway(provider)
+	if err != nil {
+		return err
+	}
+	return gateway.Capture(event.PaymentId, gatewayAmount(order))
+}
+
+// razorpayPaymentFailed marks the order failed. The customer may still
+// retry on the same razorpay order, so the stock and wallet holds stay until
+// they expire.
+func (co *OrderUseCase) razorpayPaymentFailed(razorId string) error {
+	order, err := co.orderRepo.GetByRazorId(razorId)
+	if err != nil {
+		log.Printf("razorpay webhook: no order for %s", razorId)
+		return nil
+	}
+	if !isPaid(order) && awaitingPayment(order) {
+		order.PaymentStatus = entity.PaymentFailed
+		if err := co.orderRepo.Update(order); err != nil {
+			return errors.New("payment updation failed")
+		}
+	}
+	return nil
+}
+
+func (co *OrderUseCase) refundProcessed(provider, gatewayRefundId string) error {
+	refund, err := co.orderRepo.GetRefundByGatewayId(gatewayRefundId)
+	if err != nil {
+		log.Printf("%s webhook: no refund for %s", provider, gatewayRefundId)
+		return nil
+	}
+	if refund.Status != entity.RefundProcessed {
+		refund.Status = entity.RefundProcessed
+		refund.FailureReason = ""
+		refund.ProcessedAt = time.Now()
+		if err := co.orderRepo.UpdateRefund(refund); err != nil {
+			return errors.New("failed to update refund")
+		}
+	}
+	return nil
+}
@@ -7,8 +7,11 @@ import (
 	"project/config"
 	"project/delivery/models"
 	"project/domain/entity"
+	"project/domain/money"
 	"project/domain/utils"
 	repository "project/repository/product"
+	"project/usecase/offer"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"gorm.io/gorm"
@@ -17,10 +20,17 @@ import (
 type ProductUseCase struct {
 	productRepo *repository.ProductRepository
 	s3          config.S3Bucket
+	offers      *offer.Resolver
 }
 
 func NewProduct(productRepo *repository.ProductRepository, s3 *config.S3Bucket) *ProductUseCase {
-	return &ProductUseCase{productRepo: productRepo, s3: *s3}
+	return &ProductUseCase{productRepo: productRepo, s3: *s3, offers: offer.NewResolver(productRepo)}
+}
+
+// StartOfferScheduler starts and ends the scheduled offers as their time
+// comes. It blocks, so run it in its own goroutine.
+func (pu *ProductUseCase) StartOfferScheduler(interval time.Duration) {
+	pu.offers.StartScheduler(interval)
 }
 
 func (pu *ProductUseCase) ExecuteProductList(page, limit int) ([]models.ProductWithQuantityResponse, error) {
@@ -28,9 +38,16 @@ func (pu *ProductUseCase) ExecuteProductList(page, limit int) ([]models.ProductW
 	productlist, err := pu.productRepo.GetAllProducts(offset, limit)
 	if err != nil {
 		return nil, err
-	} else {
-		return *productlist, nil
 	}
+	live, err := pu.offers.Live(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range *productlist {
+		product := &(*productlist)[i]
+		product.OfferPrize = live.Price(product.ID, product.Category, product.Price)
+	}
+	return *productlist, nil
 }
 
 func (pu *ProductUseCase) ExecuteProductDetails(id int) (*entity.Product, *entity.ProductDetails, error) {
@@ -38,6 +55,9 @@ func (pu *ProductUseCase) ExecuteProductDetails(id int) (*entity.Product, *entit
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := pu.offers.Product(product); err != nil {
+		return nil, nil, err
+	}
 	productdetails, err := pu.productRepo.GetProductDetailsById(id)
 	if err != nil {
 		return nil, nil, err
@@ -106,9 +126,11 @@ func (pu *ProductUseCase) ExecuteCreateProduct(product entity.Product, image *mu
 	productid, err := pu.productRepo.CreateProduct(newprod)
 	if err != nil {
 		return 0, err
-	} else {
-		return productid, nil
 	}
+	if err := pu.offers.RepriceProducts([]entity.Product{*newprod}); err != nil {
+		return 0, err
+	}
+	return productid, nil
 }
 
 // func PositiveNumeric(fl validator.FieldLevel) bool {
@@ -125,6 +147,21 @@ func PositiveNumeric(fl validator.FieldLevel) bool {
 	return value >= 0
 }
 
+// discountAmount checks that a coupon or offer sets what its type takes
+// off, a percent or a flat amount, and leaves the other unset.
+func discountAmount(percentage bool, percent int, flat money.Money) error {
+	if percentage {
+		if percent <= 0 || flat != 0 {
+			return errors.New("percentage discount needs a percent and no flat amount")
+		}
+		return nil
+	}
+	if flat <= 0 || percent != 0 {
+		return errors.New("flat discount needs a flat amount and no percent")
+	}
+	return nil
+}
+
 func (pu *ProductUseCase) ExecuteCreateProductDetails(details entity.ProductDetails) error {
 	validate := validator.New()
 	if err := validate.Struct(details); err != nil {
@@ -193,9 +230,8 @@ func (pt *ProductUseCase) ExecuteEditProduct(product entity.Product, id int) err
 	err1 := pt.productRepo.UpdateProduct(existingProduct)
 	if err1 != nil {
 		return err1
-	} else {
-		return nil
 	}
+	return pt.offers.RepriceProducts([]entity.Product{*existingProduct})
 }
 
 func (de *ProductUseCase) ExecuteDeleteProduct(id int) error {
@@ -236,8 +272,11 @@ func (pu *ProductUseCase) ExecuteCreateCategory(category entity.Category) (int,
 		return 0, errors.New("category already exists")
 	}
 	newcat := &entity.Category{
-		Name:        category.Name,
-		Description: category.Description,
+		Name:         category.Name,
+		Description:  category.Description,
+		HSNCode:      category.HSNCode,
+		GSTRate:      category.GSTRate,
+		TaxExclusive: category.TaxExclusive,
 	}
 	categoryid, err := pu.productRepo.CreateCategory(newcat)
 	if err != nil {
@@ -282,6 +321,9 @@ func (pt *ProductUseCase) ExecuteEditCategory(category entity.Category, id int)
 
 	existingCat.Name = category.Name
 	existingCat.Description = category.Description
+	existingCat.HSNCode = category.HSNCode
+	existingCat.GSTRate = category.GSTRate
+	existingCat.TaxExclusive = category.TaxExclusive
 
 	err = pt.productRepo.UpdateCategory(existingCat)
 	if err != nil {
@@ -373,6 +415,22 @@ func (p *ProductUseCase) ExecuteAddCoupon(coupon *entity.Coupon) error {
 		}
 		return fmt.Errorf(errorMsg)
 	}
+	if err := discountAmount(coupon.Type == entity.CouponPercentage, coupon.Percent, coupon.Flat); err != nil {
+		return err
+	}
+	if !coupon.Validuntil.After(coupon.ValidFrom) {
+		return errors.New("coupon validity ends before it starts")
+	}
+	if coupon.Category != 0 {
+		if _, err := p.productRepo.GetCategoryById(coupon.Category); err != nil {
+			return errors.New("category not found")
+		}
+	}
+	if coupon.ProductId != 0 {
+		if _, err := p.productRepo.GetProductById(coupon.ProductId); err != nil {
+			return errors.New("product not found")
+		}
+	}
 
 	err := p.productRepo.CreateCoupon(coupon)
 	if err != nil {
@@ -382,13 +440,81 @@ func (p *ProductUseCase) ExecuteAddCoupon(coupon *entity.Coupon) error {
 	}
 }
 
-func (p *ProductUseCase) ExecuteAddOffer(offer *entity.Offer) error {
-	err := p.productRepo.CreateOffer(offer)
-	if err != nil {
-		return errors.New("error creating offer")
+// ExecuteAddOffer schedules the offer and prices its products. An offer
+// without a start begins now.
+func (p *ProductUseCase) ExecuteAddOffer(newoffer *entity.Offer) error {
+	validate := validator.New()
+	validate.RegisterValidation("positive", PositiveNumeric)
+	if err := validate.Struct(newoffer); err != nil {
+		if _, ok := err.(*validator.InvalidValidationError); ok {
+			return err
+		}
+		errors := err.(validator.ValidationErrors)
+		errorMsg := "Validation failed: "
+		for _, e := range errors {
+			switch e.Tag() {
+			case "required":
+				errorMsg += fmt.Sprintf("%s is required; ", e.Field())
+			case "numeric":
+				errorMsg += fmt.Sprintf("%s should contain only numeric characters; ", e.Field())
+			case "positive":
+				errorMsg += fmt.Sprintf("%s should be a positive numeric value; ", e.Field())
+			default:
+				errorMsg += fmt.Sprintf("%s has an invalid value; ", e.Field())
+			}
+		}
+		return fmt.Errorf(errorMsg)
+	}
+	if err := discountAmount(newoffer.Type == entity.OfferPercentage, newoffer.Percent, newoffer.Flat); err != nil {
+		return err
+	}
+	if newoffer.ProductId != 0 {
+		if _, err := p.productRepo.GetProductById(newoffer.ProductId); err != nil {
+			return errors.New("product not found")
+		}
+	} else if newoffer.Category != 0 {
+		if _, err := p.productRepo.GetCategoryById(newoffer.Category); err != nil {
+			return errors.New("category not found")
+		}
 	} else {
-		return nil
+		return errors.New("offer needs a product or a category")
+	}
+	now := time.Now()
+	if newoffer.ValidFrom.IsZero() {
+		newoffer.ValidFrom = now
+	}
+	if newoffer.ValidUntil != nil && !newoffer.ValidUntil.After(newoffer.ValidFrom) {
+		return errors.New("offer validity ends before it starts")
+	}
+	newoffer.Status = offer.Status(newoffer, now)
+	if err := p.productRepo.CreateOffer(newoffer); err != nil {
+		return errors.New("error creating offer")
+	}
+	return p.offers.Reprice(newoffer)
+}
+
+// ExecuteCancelOffer ends the offer now and prices its products without it.
+func (p *ProductUseCase) ExecuteCancelOffer(id int) (*entity.Offer, error) {
+	result, err := p.productRepo.GetOfferById(id)
+	if err != nil {
+		return nil, errors.New("offer not found")
+	}
+	if result.Status == entity.OfferCancelled || result.Status == entity.OfferExpired {
+		return nil, errors.New("offer already ended")
 	}
+	result.Status = entity.OfferCancelled
+	if err := p.productRepo.UpdateOffer(result); err != nil {
+		return nil, errors.New("error cancelling offer")
+	}
+	if err := p.offers.Reprice(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (p *ProductUseCase) ExecuteAdminOffers(page, limit int, status string) ([]entity.Offer, error) {
+	offset := (page - 1) * limit
+	return p.productRepo.GetOffers(offset, limit, status)
 }
 
 func (p *ProductUseCase) ExecuteAvailableCoupons() (*[]entity.Coupon, error) {
@@ -453,7 +579,9 @@ func (pu *ProductUseCase) ExecuteProductSearch(page, limit int, search string) (
 			Size:     product.Size,
 		})
 	}
-
+	if err := pu.offers.Products(result); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 func (pu *ProductUseCase) ExecuteProductByCategory(page, limit, id int) ([]entity.Product, error) {
@@ -462,68 +590,77 @@ func (pu *ProductUseCase) ExecuteProductByCategory(page, limit, id int) ([]entit
 	if err != nil {
 		return nil, err
 	}
+	if err := pu.offers.Products(products); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
 
-func (pu *ProductUseCase) ExecuteProductFilter(size string, minPrize, maxPrize, category int) ([]entity.Product, error) {
+func (pu *ProductUseCase) ExecuteProductFilter(size string, minPrize, maxPrize money.Money, category int) ([]entity.Product, error) {
 	products, err := pu.productRepo.GetProductsByFilter(minPrize, maxPrize, category, size)
 	if err != nil {
 		return nil, err
 	}
+	if err := pu.offers.Products(products); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
 
-func (pu *ProductUseCase) ExecuteGetOffers() (*[]entity.Offer, error) {
-	offers, err := pu.productRepo.GetAllOffers()
-	if err != nil {
-		return nil, err
-	}
-	avialableoffers := []entity.Offer{}
-	for _, offers := range offers {
-		if offers.UsageLimit != offers.UsedCount {
-			avialableoffers = append(avialableoffers, offers)
-		}
-	}
-	return &avialableoffers, nil
+// ExecuteGetOffers returns the offers that are running or yet to start.
+func (pu *ProductUseCase) ExecuteGetOffers() ([]entity.Offer, error) {
+	return pu.productRepo.GetAllOffers()
 }
 
-func (pu *ProductUseCase) ExecuteAddProductOffer(productid, offer int) (*entity.Product, error) {
+// ExecuteAddProductOffer puts a percentage offer on the product from now
+// until it is cancelled.
+func (pu *ProductUseCase) ExecuteAddProductOffer(productid, percent int) (*entity.Product, error) {
 
 	product, err := pu.productRepo.GetProductById(productid)
 	if err != nil {
 		return nil, err
 	}
-	if offer < 0 || offer > 100 {
+	if percent <= 0 || percent >= 100 {
 		return nil, errors.New("Invalid offer percentage")
 	}
-
-	amount := float64(offer) / 100.0 * float64(product.Price)
-	product.OfferPrize = product.Price - int(amount)
-	err1 := pu.productRepo.UpdateProduct(product)
-	if err1 != nil {
+	newoffer := &entity.Offer{
+		Name:      "Offer on " + product.Name,
+		Type:      entity.OfferPercentage,
+		Percent:   percent,
+		ProductId: productid,
+	}
+	if err := pu.ExecuteAddOffer(newoffer); err != nil {
+		return nil, err
+	}
+	if err := pu.offers.Product(product); err != nil {
 		return nil, err
 	}
 	return product, nil
 }
 
-func (pu *ProductUseCase) ExecuteCategoryOffer(catid, offer int) ([]entity.Product, error) {
+// ExecuteCategoryOffer puts a percentage offer on every product of the
+// category from now until it is cancelled.
+func (pu *ProductUseCase) ExecuteCategoryOffer(catid, percent int) ([]entity.Product, error) {
 
-	productlist, err := pu.productRepo.GetProductsByCategoryoffer(catid)
+	category, err := pu.productRepo.GetCategoryById(catid)
 	if err != nil {
 		return nil, err
 	}
-	if offer < 0 || offer > 100 {
+	if percent <= 0 || percent >= 100 {
 		return nil, errors.New("Invalid offer percentage")
 	}
-	for i := range productlist {
-		product := &(productlist)[i]
-
-		amount := float64(offer) / 100.0 * float64(product.Price)
-		product.OfferPrize = product.Price - int(amount)
-		err := pu.productRepo.UpdateProduct(product)
-		if err != nil {
-			return nil, err
-		}
+	newoffer := &entity.Offer{
+		Name:     "Offer on " + category.Name,
+		Type:     entity.OfferPercentage,
+		Percent:  percent,
+		Category: catid,
+	}
+	if err := pu.ExecuteAddOffer(newoffer); err != nil {
+		return nil, err
+	}
+	productlist, err := pu.productRepo.GetProductsByCategoryoffer(catid)
+	if err != nil {
+		return nil, err
 	}
 	return productlist, nil
 
@@ -543,15 +680,14 @@ func (au *ProductUseCase) ExecuteDeleteProductAdd(id int) error {
 
 func (au *ProductUseCase) ExecuteAddStock(productId, stock int) (*entity.Inventory, error) {
 
+	err := au.productRepo.IncreaseProductQuantity(productId, stock)
+	if err != nil {
+		return nil, errors.New("error updating inventory")
+	}
 	product, err := au.productRepo.GetInventoryByID(productId)
 	if err != nil {
 		return nil, err
 	}
-	product.Quantity = product.Quantity + stock
-	err1 := au.productRepo.UpdateInventory(product)
-	if err1 != nil {
-		return nil, errors.New("error updating inventory")
-	}
 	return product, nil
 
 }
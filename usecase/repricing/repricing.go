@@ -0,0 +1,154 @@
+// Package repricing keeps cart lines at the current product price and
+// stock. Lines whose price moved, or that can no longer be sold as they
+// are, are flagged until the user acknowledges them.
+package repricing
+
+import (
+	"errors"
+	"fmt"
+	"project/domain/entity"
+	"project/domain/money"
+	cartrepository "project/repository/cart"
+	productrepository "project/repository/product"
+	"project/usecase/offer"
+
+	"gorm.io/gorm"
+)
+
+// ErrReview is returned at checkout while the cart has lines waiting to
+// be acknowledged.
+var ErrReview = errors.New("cart has changed, review and acknowledge it before placing the order")
+
+type Repricer struct {
+	productRepo *productrepository.ProductRepository
+	cartRepo    *cartrepository.CartRepository
+	offers      *offer.Resolver
+}
+
+func NewRepricer(productRepo *productrepository.ProductRepository, cartRepo *cartrepository.CartRepository) *Repricer {
+	return &Repricer{productRepo: productRepo, cartRepo: cartRepo, offers: offer.NewResolver(productRepo)}
+}
+
+// WithTx returns a copy of the repricer whose repositories are bound to the
+// given transaction.
+func (r *Repricer) WithTx(tx *gorm.DB) *Repricer {
+	productRepo := r.productRepo.WithTx(tx)
+	return &Repricer{productRepo: productRepo, cartRepo: r.cartRepo.WithTx(tx), offers: offer.NewResolver(productRepo)}
+}
+
+// Reprice brings the items to the current price and checks their stock,
+// updating the cart totals. It reports whether any line needs review.
+func (r *Repricer) Reprice(cart *entity.Cart, items []entity.CartItem) (bool, error) {
+	review := false
+	for i := range items {
+		item := items[i]
+		if err := r.line(&item); err != nil {
+			return false, err
+		}
+		if item.Status != "" {
+			review = true
+		}
+		if item != items[i] {
+			if err := r.cartRepo.UpdateCartItem(&item); err != nil {
+				return false, errors.New("error updating cart item")
+			}
+			items[i] = item
+		}
+	}
+	return review, r.totals(cart, items)
+}
+
+// Acknowledge accepts the changes to the cart: new prices are taken,
+// unavailable lines dropped and short lines cut down to the stock left.
+// It returns the items that stay in the cart.
+func (r *Repricer) Acknowledge(cart *entity.Cart, items []entity.CartItem) ([]entity.CartItem, error) {
+	if _, err := r.Reprice(cart, items); err != nil {
+		return nil, err
+	}
+	var kept []entity.CartItem
+	for i := range items {
+		item := items[i]
+		if item.Status == entity.CartItemUnavailable {
+			if err := r.cartRepo.RemoveCartItem(&item); err != nil {
+				return nil, errors.New("reomving products failed")
+			}
+			continue
+		}
+		if item.Status == entity.CartItemShort {
+			item.Quantity = r.Available(item.ProductId)
+		}
+		item.PreviousPrice = 0
+		item.Status = ""
+		item.Note = ""
+		if err := r.cartRepo.UpdateCartItem(&item); err != nil {
+			return nil, errors.New("error updating cart item")
+		}
+		kept = append(kept, item)
+	}
+	return kept, r.totals(cart, kept)
+}
+
+// line reprices a single item and sets its status.
+func (r *Repricer) line(item *entity.CartItem) error {
+	item.Status, item.Note = "", ""
+	product, err := r.productRepo.GetProductById(item.ProductId)
+	if err != nil || product.Removed {
+		item.Status, item.Note = entity.CartItemUnavailable, "product is no longer sold"
+		return nil
+	}
+	if err := r.offers.Product(product); err != nil {
+		return err
+	}
+	price := product.Price
+	if product.OfferPrize != 0 {
+		price = product.OfferPrize
+	}
+	if price != item.Price {
+		if item.PreviousPrice == 0 {
+			item.PreviousPrice = item.Price
+		}
+		item.Price = price
+	}
+	if item.PreviousPrice == item.Price {
+		item.PreviousPrice = 0
+	}
+	item.ProductName = product.Name
+	available := r.Available(item.ProductId)
+	switch {
+	case available <= 0:
+		item.Status, item.Note = entity.CartItemUnavailable, "out of stock"
+	case available < item.Quantity:
+		item.Status, item.Note = entity.CartItemShort, fmt.Sprintf("only %d available", available)
+	case item.PreviousPrice != 0:
+		item.Status, item.Note = entity.CartItemPriceChanged, "price changed from "+item.PreviousPrice.Format()
+	}
+	return nil
+}
+
+// Available is the stock left to sell. A product without an inventory
+// has none.
+func (r *Repricer) Available(productid int) int {
+	inventory, err := r.productRepo.GetInventoryByID(productid)
+	if err != nil {
+		return 0
+	}
+	return inventory.Quantity - inventory.Reserved
+}
+
+func (r *Repricer) totals(cart *entity.Cart, items []entity.CartItem) error {
+	var total money.Money
+	quantity := 0
+	for _, item := range items {
+		total += item.Price.Mul(item.Quantity)
+		quantity += item.Quantity
+	}
+	if cart.TotalPrize == total && cart.ProductQuantity == quantity {
+		return nil
+	}
+	cart.TotalPrize = total
+	cart.ProductQuantity = quantity
+	if err := r.cartRepo.UpdateCart(cart); err != nil {
+		return errors.New("cart updation failed")
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+// Package tax works out the GST on what the shop sells. Rates come from
+// the product's category; a sale within the seller's state pays CGST and
+// SGST, one to another state pays IGST.
+package tax
+
+import (
+	"errors"
+	"project/config"
+	"project/domain/entity"
+	"project/domain/money"
+	productrepository "project/repository/product"
+	"strings"
+)
+
+// DefaultRate is the GST rate used when GSTRATE is not configured.
+const DefaultRate = 18
+
+// Rate is how a category is taxed. Exclusive prices have the GST added on
+// top; otherwise the price already includes it.
+type Rate struct {
+	HSNCode   string `json:"hsncode"`
+	Percent   int    `json:"gstrate"`
+	Exclusive bool   `json:"taxexclusive"`
+}
+
+// Amounts splits what the customer pays into the taxable value and the
+// GST on it. Total includes the tax.
+type Amounts struct {
+	Taxable money.Money `json:"taxable"`
+	CGST    money.Money `json:"cgst"`
+	SGST    money.Money `json:"sgst"`
+	IGST    money.Money `json:"igst"`
+	Total   money.Money `json:"total"`
+}
+
+func (a Amounts) Tax() money.Money {
+	return a.CGST + a.SGST + a.IGST
+}
+
+func (a *Amounts) add(b Amounts) {
+	a.Taxable += b.Taxable
+	a.CGST += b.CGST
+	a.SGST += b.SGST
+	a.IGST += b.IGST
+	a.Total += b.Total
+}
+
+// Compute taxes an amount at rate, rounding to the paisa. For inclusive
+// rates the tax is taken out of the amount, for exclusive ones it is added.
+func Compute(amount money.Money, rate Rate, intraState bool) Amounts {
+	var a Amounts
+	if rate.Exclusive {
+		a.Taxable = amount
+		a.Total = amount + amount.Percent(rate.Percent)
+	} else {
+		a.Taxable = amount.MulDiv(100, int64(100+rate.Percent))
+		a.Total = amount
+	}
+	tax := a.Total - a.Taxable
+	if intraState {
+		a.CGST = tax / 2
+		a.SGST = tax - a.CGST
+	} else {
+		a.IGST = tax
+	}
+	return a
+}
+
+// IntraState reports whether goods shipped to state stay within the
+// seller's state. An unknown state is treated as a local sale.
+func IntraState(sellerState, state string) bool {
+	state = strings.TrimSpace(state)
+	return state == "" || strings.EqualFold(strings.TrimSpace(sellerState), state)
+}
+
+// Line is a cart line with its coupon discount and its tax.
+type Line struct {
+	ProductId int         `json:"productid"`
+	Category  int         `json:"category"`
+	Quantity  int         `json:"quantity"`
+	Price     money.Money `json:"price"`
+	Discount  money.Money `json:"discount"`
+	HSNCode   string      `json:"hsncode"`
+	Rate      int         `json:"gstrate"`
+	Exclusive bool        `json:"taxexclusive"`
+	Amounts
+}
+
+// Bill is a cart priced for a place of supply. Its Total is what the
+// customer pays.
+type Bill struct {
+	Lines      []Line `json:"lines"`
+	IntraState bool   `json:"intrastate"`
+	Amounts
+}
+
+type Calculator struct {
+	productRepo *productrepository.ProductRepository
+	seller      *config.Seller
+}
+
+func NewCalculator(productRepo *productrepository.ProductRepository, seller *config.Seller) *Calculator {
+	return &Calculator{productRepo: productRepo, seller: seller}
+}
+
+// Rate is the category's GST rate. Categories without an HSN code have not
+// been classified yet and are taxed at the configured GSTRATE, inclusive.
+func (tc *Calculator) Rate(category int) (Rate, error) {
+	cat, err := tc.productRepo.GetCategoryById(category)
+	if err != nil {
+		return Rate{}, errors.New("category not found")
+	}
+	if cat.HSNCode == "" {
+		return Rate{Percent: tc.defaultRate()}, nil
+	}
+	return Rate{HSNCode: cat.HSNCode, Percent: cat.GSTRate, Exclusive: cat.TaxExclusive}, nil
+}
+
+func (tc *Calculator) defaultRate() int {
+	if tc.seller.GSTRate > 0 {
+		return tc.seller.GSTRate
+	}
+	return DefaultRate
+}
+
+// IntraState reports whether a sale shipped to state is within the
+// seller's state.
+func (tc *Calculator) IntraState(state string) bool {
+	return IntraState(tc.seller.SellerState, state)
+}
+
+// Cart prices the cart items, less their coupon discounts, for delivery
+// to state.
+func (tc *Calculator) Cart(items []entity.CartItem, state string) (*Bill, error) {
+	bill := &Bill{IntraState: tc.IntraState(state)}
+	rates := map[int]Rate{}
+	for _, item := range items {
+		rate, ok := rates[item.Category]
+		if !ok {
+			var err error
+			if rate, err = tc.Rate(item.Category); err != nil {
+				return nil, err
+			}
+			rates[item.Category] = rate
+		}
+		line := Line{
+			ProductId: item.ProductId,
+			Category:  item.Category,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+			Discount:  item.Discount,
+			HSNCode:   rate.HSNCode,
+			Rate:      rate.Percent,
+			Exclusive: rate.Exclusive,
+			Amounts:   Compute(item.Price.Mul(item.Quantity)-item.Discount, rate, bill.IntraState),
+		}
+		bill.Lines = append(bill.Lines, line)
+		bill.add(line.Amounts)
+	}
+	return bill, nil
+}
@@ -7,23 +7,43 @@ import (
 	"math/rand"
 	"regexp"
 
-	"project/config"
 	"project/delivery/models"
 	"project/domain/entity"
-	"project/domain/utils"
+	"project/domain/money"
+	"project/domain/otp"
 	repository "project/repository/user"
+	walletrepository "project/repository/wallet"
 
 	"github.com/go-playground/validator/v10"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type UserUseCase struct {
-	userRepo *repository.UserRepository
-	otp      *config.OTP
+	userRepo   *repository.UserRepository
+	walletRepo *walletrepository.WalletRepository
+	otp        otp.OTPProvider
 }
 
-func NewUser(userRepo *repository.UserRepository, otp *config.OTP) *UserUseCase {
-	return &UserUseCase{userRepo: userRepo, otp: otp}
+func NewUser(userRepo *repository.UserRepository, walletRepo *walletrepository.WalletRepository, otpProvider otp.OTPProvider) *UserUseCase {
+	return &UserUseCase{userRepo: userRepo, walletRepo: walletRepo, otp: otpProvider}
+}
+
+// referralBonus is credited to both the referrer and the new user.
+var referralBonus = money.FromMajor(500)
+
+func (uu *UserUseCase) creditReferral(referrerId, userId int) error {
+	for _, id := range []int{referrerId, userId} {
+		err := uu.walletRepo.Credit(&entity.WalletTransaction{
+			UserId: id,
+			Amount: referralBonus,
+			Reason: entity.WalletReasonReferral,
+		})
+		if err != nil {
+			return errors.New("referral credit failed")
+		}
+	}
+	return nil
 }
 
 func (us *UserUseCase) ExecuteSignup(user entity.User) (*entity.User, error) {
@@ -120,7 +140,7 @@ func (uu *UserUseCase) ExecuteSignupWithOtp(user models.Signup) (string, error)
 		}
 	}
 
-	key, err := utils.SendOtp(user.Phone, *uu.otp)
+	key, err := uu.otp.Send(user.Phone)
 	if err != nil {
 		return "", err
 	} else {
@@ -135,20 +155,20 @@ func (uu *UserUseCase) ExecuteSignupWithOtp(user models.Signup) (string, error)
 	}
 }
 
-func (uu *UserUseCase) ExecuteSignupOtpValidation(key string, otp string) error {
+func (uu *UserUseCase) ExecuteSignupOtpValidation(key string, otp string) (int, error) {
 	result, err := uu.userRepo.GetByKey(key)
 
 	if err != nil {
-		return errors.New("error in key")
+		return 0, errors.New("error in key")
 	}
 	fmt.Printf("GetByKey Result: %+v\n", result)
 	user, err := uu.userRepo.GetSignupByPhone(result.Phone)
 	if err != nil {
-		return errors.New("error in phone")
+		return 0, errors.New("error in phone")
 	}
-	err = utils.CheckOtp(result.Phone, otp, *uu.otp)
+	err = uu.otp.Check(result.Phone, otp)
 	if err != nil {
-		return err
+		return 0, err
 	} else {
 		newUser := &entity.User{
 			Name:     user.Name,
@@ -158,23 +178,12 @@ func (uu *UserUseCase) ExecuteSignupOtpValidation(key string, otp string) error
 		}
 		err1 := uu.userRepo.Create(newUser)
 		if err1 != nil {
-			return errors.New("error while crearting user")
+			return 0, errors.New("error while crearting user")
 		}
-		if user.ReferalCode != "" {
-		referduser, err := uu.userRepo.GetByReferalCode(user.ReferalCode)
-		if err != nil{
-			return err
-		}
-		if referduser != nil {
-			referduser.Wallet = referduser.Wallet + 500
-			err = uu.userRepo.Update(referduser)
-			newUser.Wallet = 500
-		}
-	}
 		randomBytes := make([]byte, 3)
 		_, err2 := rand.Read(randomBytes)
 		if err2 != nil {
-			return err
+			return 0, err
 		}
 
 		referralCode := hex.EncodeToString(randomBytes)
@@ -184,10 +193,19 @@ func (uu *UserUseCase) ExecuteSignupOtpValidation(key string, otp string) error
 
 		err3 := uu.userRepo.Update(newUser)
 		if err3 != nil {
-			return errors.New("user update failed")
+			return 0, errors.New("user update failed")
+		}
+		if user.ReferalCode != "" {
+			referduser, err := uu.userRepo.GetByReferalCode(user.ReferalCode)
+			if err != nil {
+				return 0, err
+			}
+			if referduser != nil {
+				return newUser.Id, uu.creditReferral(referduser.Id, newUser.Id)
+			}
 		}
 
-		return nil
+		return newUser.Id, nil
 	}
 
 }
@@ -233,7 +251,7 @@ func (u *UserUseCase) ExecuteLogin(phone string) (string, error) {
 	if permission == false {
 		return "", errors.New("permission denied")
 	}
-	key, err := utils.SendOtp(phone, *u.otp)
+	key, err := u.otp.Send(phone)
 	if err != nil {
 		return "", err
 	} else {
@@ -257,9 +275,9 @@ func (uu *UserUseCase) ExecuteOtpValidation(key, otp string) (*entity.User, erro
 	if err != nil {
 		return nil, err
 	}
-	err1 := utils.CheckOtp(result.Phone, otp, *uu.otp)
+	err1 := uu.otp.Check(result.Phone, otp)
 	if err1 != nil {
-		return nil, err
+		return nil, err1
 	}
 	return user, nil
 }
@@ -285,23 +303,32 @@ func (uu *UserUseCase) ExecuteAddAddress(address *entity.UserAddress) error {
 		return fmt.Errorf(errorMsg)
 	}
 
+	// The flag is set separately so the previous default is cleared first.
+	makeDefault := address.IsDefault
+	address.IsDefault = false
 	err := uu.userRepo.CreateAddress(address)
 	if err != nil {
 		return err
 	}
+	if !makeDefault {
+		if _, err := uu.userRepo.GetDefaultAddress(address.User_id); !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+	}
+	if err := uu.userRepo.SetDefaultAddress(address.User_id, address.Id); err != nil {
+		return errors.New("error setting default address")
+	}
+	address.IsDefault = true
 	return nil
 
 }
 
-func (uu *UserUseCase) CheckAddress(id int,adtype string) error{
-	ad,err:=uu.userRepo.GetAddressByType(id,adtype)
-	if err != nil{
-		return errors.New("err geting address")
-	}
-	if ad != nil{
-		return errors.New("address with the type already exists")
-	}
-	return nil
+func (uu *UserUseCase) ExecuteAddresses(userid int) ([]entity.UserAddress, error) {
+	return uu.userRepo.GetAddresses(userid)
+}
+
+func (uu *UserUseCase) ExecuteSetDefaultAddress(userid, addressid int) error {
+	return uu.userRepo.SetDefaultAddress(userid, addressid)
 }
 
 func (uu *UserUseCase) ExecuteEditProfile(user entity.User, userid int) error {
@@ -339,11 +366,11 @@ func (uu *UserUseCase) ExecuteShowUserDetails(userid int) (*entity.User, *entity
 	if err != nil {
 		return nil, nil, err
 	}
-	address, err1 := uu.userRepo.GetAddressByID(userid)
-	if err1 != nil {
+	address, err1 := uu.userRepo.GetDefaultAddress(userid)
+	if err1 != nil && !errors.Is(err1, gorm.ErrRecordNotFound) {
 		return nil, nil, err1
 	}
-	if user != nil && address != nil {
+	if user != nil {
 		return user, address, nil
 	} else {
 		return nil, nil, errors.New("user with this id not found")
@@ -356,7 +383,7 @@ func (uu *UserUseCase) ExecuteChangePassword(userid int) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	key, err1 := utils.SendOtp(user.Phone, *uu.otp)
+	key, err1 := uu.otp.Send(user.Phone)
 	if err1 != nil {
 		return "", err1
 	} else {
@@ -375,7 +402,7 @@ func (uu *UserUseCase) ExecuteOtpValidationPassword(password string, otp string,
 	if err != nil {
 		return err
 	}
-	err = utils.CheckOtp(user.Phone, otp, *uu.otp)
+	err = uu.otp.Check(user.Phone, otp)
 	if err != nil {
 		return err
 	}
@@ -390,7 +417,7 @@ func (uu *UserUseCase) ExecuteOtpValidationPassword(password string, otp string,
 
 }
 
-func (uu *UserUseCase) ExecuteEditAddress(usaddress entity.UserAddress, id int, useraddress string) error {
+func (uu *UserUseCase) ExecuteEditAddress(usaddress entity.UserAddress, id int, addressid int) error {
 	validate := validator.New()
 	if err := validate.Struct(usaddress); err != nil {
 		if _, ok := err.(*validator.InvalidValidationError); ok {
@@ -414,9 +441,9 @@ func (uu *UserUseCase) ExecuteEditAddress(usaddress entity.UserAddress, id int,
 		}
 		return fmt.Errorf(errorMsg)
 	}
-	exisitingaddress, err := uu.userRepo.GetAddressByType(id, useraddress)
+	exisitingaddress, err := uu.userRepo.GetUserAddress(id, addressid)
 	if err != nil {
-		return err
+		return errors.New("address not found")
 	}
 
 	exisitingaddress.User_id = id
@@ -430,17 +457,30 @@ func (uu *UserUseCase) ExecuteEditAddress(usaddress entity.UserAddress, id int,
 	if err1 != nil {
 		return err1
 	}
+	if usaddress.IsDefault && !exisitingaddress.IsDefault {
+		return uu.userRepo.SetDefaultAddress(id, addressid)
+	}
 	return nil
 }
 
-func (uu *UserUseCase) ExecuteDeleteAddress(id int, addtype string) error {
-	address, err := uu.userRepo.GetAddressByType(id, addtype)
+// ExecuteDeleteAddress removes an address from the address book. When it
+// was the default, the oldest remaining address takes over. Orders keep
+// their own copy of the address, so they are not affected.
+func (uu *UserUseCase) ExecuteDeleteAddress(id int, addressid int) error {
+	address, err := uu.userRepo.GetUserAddress(id, addressid)
 	if err != nil {
-		return err
+		return errors.New("address not found")
 	}
 	err1 := uu.userRepo.DeleteAddress(address.Id)
 	if err1 != nil {
 		return err1
 	}
-	return nil
+	if !address.IsDefault {
+		return nil
+	}
+	remaining, err := uu.userRepo.GetAddresses(id)
+	if err != nil || len(remaining) == 0 {
+		return err
+	}
+	return uu.userRepo.SetDefaultAddress(id, remaining[0].Id)
 }
@@ -0,0 +1,83 @@
+package wallet
+
+import (
+	"errors"
+	"log"
+	"project/domain/entity"
+	"project/domain/money"
+	userrepository "project/repository/user"
+	repository "project/repository/wallet"
+)
+
+type WalletUseCase struct {
+	walletRepo *repository.WalletRepository
+	userRepo   *userrepository.UserRepository
+}
+
+func NewWallet(walletRepo *repository.WalletRepository, userRepo *userrepository.UserRepository) *WalletUseCase {
+	return &WalletUseCase{walletRepo: walletRepo, userRepo: userRepo}
+}
+
+// ExecuteWalletHistory returns the current balance, the part of it held for
+// pending gateway payments and a page of ledger entries, newest first.
+func (wu *WalletUseCase) ExecuteWalletHistory(userid, page, limit int) (money.Money, money.Money, []entity.WalletTransaction, error) {
+	user, err := wu.userRepo.GetById(userid)
+	if err != nil || user == nil {
+		return 0, 0, nil, errors.New("user not found")
+	}
+	offset := (page - 1) * limit
+	txns, err := wu.walletRepo.GetTransactions(userid, offset, limit)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return user.Wallet, user.WalletHeld, txns, nil
+}
+
+// ExecuteAdminAdjustment credits a positive amount or debits a negative one.
+func (wu *WalletUseCase) ExecuteAdminAdjustment(adminId, userid int, amount money.Money, remark string) (*entity.WalletTransaction, error) {
+	if amount == 0 {
+		return nil, errors.New("amount can't be zero")
+	}
+	if remark == "" {
+		return nil, errors.New("remark is required")
+	}
+	txn := &entity.WalletTransaction{
+		UserId:  userid,
+		Amount:  amount,
+		Reason:  entity.WalletReasonAdjustment,
+		Remark:  remark,
+		AdminId: adminId,
+	}
+	var err error
+	if amount > 0 {
+		err = wu.walletRepo.Credit(txn)
+	} else {
+		txn.Amount = -amount
+		err = wu.walletRepo.Debit(txn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+// ExecuteReconcile brings stored balances in line with the ledger. Users
+// without any ledger entry get their stored balance recorded as an opening
+// balance, everyone else gets the ledger balance written back.
+func (wu *WalletUseCase) ExecuteReconcile() ([]entity.WalletDiscrepancy, error) {
+	discrepancies, err := wu.walletRepo.GetDiscrepancies()
+	if err != nil {
+		return nil, errors.New("failed to reconcile wallets")
+	}
+	for _, d := range discrepancies {
+		if d.Entries == 0 {
+			err = wu.walletRepo.CreateOpeningBalance(d.UserId, d.Stored)
+		} else {
+			err = wu.walletRepo.SetBalance(d.UserId, d.Ledger)
+		}
+		if err != nil {
+			log.Printf("reconciling wallet of user %d failed: %v", d.UserId, err)
+		}
+	}
+	return discrepancies, nil
+}